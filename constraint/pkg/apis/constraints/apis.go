@@ -4,6 +4,7 @@ package constraints
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -43,6 +44,9 @@ const (
 	Deny EnforcementAction = "deny"
 	// Warn indicates that violations should be reported but not rejected.
 	Warn EnforcementAction = "warn"
+	// Dryrun indicates that violations should be recorded but neither rejected
+	// nor reported to the requester.
+	Dryrun EnforcementAction = "dryrun"
 	// Scoped indicates that enforcement actions are scoped to specific enforcement points.
 	Scoped EnforcementAction = "scoped"
 )
@@ -80,6 +84,50 @@ func GetEnforcementAction(constraint *unstructured.Unstructured) (string, error)
 	return action, nil
 }
 
+// GetActiveWindow returns the times from which and until which a Constraint is
+// enforced, from spec.activeFrom and spec.activeUntil. Either is nil if the
+// Constraint does not specify it.
+//
+// Returns an error if either field is defined and is not an RFC 3339 timestamp,
+// or if activeUntil is not after activeFrom.
+func GetActiveWindow(constraint *unstructured.Unstructured) (from, until *time.Time, err error) {
+	from, err = getTime(constraint, "activeFrom")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	until, err = getTime(constraint, "activeUntil")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if from != nil && until != nil && !until.After(*from) {
+		return nil, nil, fmt.Errorf("%w: spec.activeUntil must be after spec.activeFrom", ErrInvalidConstraint)
+	}
+
+	return from, until, nil
+}
+
+// getTime returns the RFC 3339 timestamp in the Constraint's spec.field, or nil
+// if it is not set.
+func getTime(constraint *unstructured.Unstructured, field string) (*time.Time, error) {
+	value, found, err := unstructured.NestedString(constraint.Object, "spec", field)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid spec.%s", ErrInvalidConstraint, field)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid spec.%s: %v", ErrInvalidConstraint, field, err)
+	}
+
+	return &t, nil
+}
+
 // IsEnforcementActionScoped returns true if the action is scoped to specific enforcement points.
 func IsEnforcementActionScoped(action string) bool {
 	return action == string(Scoped)
@@ -1,8 +1,10 @@
 package constraints
 
 import (
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -208,3 +210,65 @@ func TestIsEnforcementActionScoped(t *testing.T) {
 		})
 	}
 }
+
+func TestGetActiveWindow(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		spec      map[string]interface{}
+		wantFrom  *time.Time
+		wantUntil *time.Time
+		wantErr   error
+	}{
+		{
+			name: "no window",
+			spec: map[string]interface{}{},
+		},
+		{
+			name:     "only activeFrom",
+			spec:     map[string]interface{}{"activeFrom": "2025-01-01T00:00:00Z"},
+			wantFrom: &from,
+		},
+		{
+			name:      "both",
+			spec:      map[string]interface{}{"activeFrom": "2025-01-01T00:00:00Z", "activeUntil": "2025-02-01T00:00:00Z"},
+			wantFrom:  &from,
+			wantUntil: &until,
+		},
+		{
+			name:    "invalid timestamp",
+			spec:    map[string]interface{}{"activeUntil": "tomorrow"},
+			wantErr: ErrInvalidConstraint,
+		},
+		{
+			name:    "wrong type",
+			spec:    map[string]interface{}{"activeFrom": int64(1)},
+			wantErr: ErrInvalidConstraint,
+		},
+		{
+			name:    "empty window",
+			spec:    map[string]interface{}{"activeFrom": "2025-02-01T00:00:00Z", "activeUntil": "2025-01-01T00:00:00Z"},
+			wantErr: ErrInvalidConstraint,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			gotFrom, gotUntil, err := GetActiveWindow(constraint)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(gotFrom, tt.wantFrom) {
+				t.Errorf("got activeFrom %v, want %v", gotFrom, tt.wantFrom)
+			}
+			if !reflect.DeepEqual(gotUntil, tt.wantUntil) {
+				t.Errorf("got activeUntil %v, want %v", gotUntil, tt.wantUntil)
+			}
+		})
+	}
+}
@@ -211,6 +211,7 @@ func autoConvert_v1_CRDSpec_To_templates_CRDSpec(in *CRDSpec, out *templates.CRD
 	if err := Convert_v1_Names_To_templates_Names(&in.Names, &out.Names, s); err != nil {
 		return err
 	}
+	out.Scope = in.Scope
 	if in.Validation != nil {
 		in, out := &in.Validation, &out.Validation
 		*out = new(templates.Validation)
@@ -232,6 +233,7 @@ func autoConvert_templates_CRDSpec_To_v1_CRDSpec(in *templates.CRDSpec, out *CRD
 	if err := Convert_templates_Names_To_v1_Names(&in.Names, &out.Names, s); err != nil {
 		return err
 	}
+	out.Scope = in.Scope
 	if in.Validation != nil {
 		in, out := &in.Validation, &out.Validation
 		*out = new(Validation)
@@ -39,6 +39,10 @@ type CRD struct {
 // CRDSpec defines the spec for the CRD.
 type CRDSpec struct {
 	Names Names `json:"names,omitempty"`
+	// Scope is the scope of Constraints of this kind. Namespaced Constraints
+	// only apply to objects in their own namespace. Defaults to Cluster.
+	// +kubebuilder:validation:Enum=Cluster;Namespaced
+	Scope string `json:"scope,omitempty"`
 	// +kubebuilder:default={legacySchema: true}
 	Validation *Validation `json:"validation,omitempty"`
 }
@@ -211,6 +211,7 @@ func autoConvert_v1beta1_CRDSpec_To_templates_CRDSpec(in *CRDSpec, out *template
 	if err := Convert_v1beta1_Names_To_templates_Names(&in.Names, &out.Names, s); err != nil {
 		return err
 	}
+	out.Scope = in.Scope
 	if in.Validation != nil {
 		in, out := &in.Validation, &out.Validation
 		*out = new(templates.Validation)
@@ -232,6 +233,7 @@ func autoConvert_templates_CRDSpec_To_v1beta1_CRDSpec(in *templates.CRDSpec, out
 	if err := Convert_templates_Names_To_v1beta1_Names(&in.Names, &out.Names, s); err != nil {
 		return err
 	}
+	out.Scope = in.Scope
 	if in.Validation != nil {
 		in, out := &in.Validation, &out.Validation
 		*out = new(Validation)
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	regoSchema "github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego/schema"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// stagedData is data a target has processed, ready to be written atomically.
+type stagedData struct {
+	target string
+	key    []string
+	// object is the processed data, as stored in the target's Cache.
+	object interface{}
+	// data is the untyped JSON copy of object, as stored by drivers.
+	data  interface{}
+	cache handler.Cache
+}
+
+// cacheUndo reverts a single Cache.Add.
+type cacheUndo struct {
+	cache handler.Cache
+	key   []string
+	// previous is the object key held before the Add, or nil if there was none.
+	previous interface{}
+}
+
+func (u cacheUndo) revert() {
+	if u.previous == nil {
+		u.cache.Remove(u.key)
+		return
+	}
+
+	// The Cache accepted previous before, so we expect it to accept it again.
+	// If it does not, removing the key is the closest we can get to the prior
+	// state.
+	if err := u.cache.Add(u.key, u.previous); err != nil {
+		u.cache.Remove(u.key)
+	}
+}
+
+// addDataAtomic is AddData for Clients created with AtomicData.
+//
+// Every target processes data before anything is written. Writes to the Rego
+// driver are then staged in a single DataTransaction, and handler Caches are
+// updated while recording how to undo each change. The transaction is committed
+// only once every Cache accepts the data; otherwise all changes are reverted.
+// Reviews may observe the Cache and storage changes before they are reverted.
+func (c *Client) addDataAtomic(ctx context.Context, data interface{}) (*types.Responses, error) {
+	resp := types.NewResponses()
+
+	staged, errMap := c.stageData(data, true)
+	if len(errMap) != 0 {
+		return resp, &errMap
+	}
+	if len(staged) == 0 {
+		return resp, nil
+	}
+
+	txn, err := c.newDataTransaction(ctx)
+	if err != nil {
+		return resp, dataError(staged, err)
+	}
+
+	if txn != nil {
+		for _, s := range staged {
+			err = txn.AddData(ctx, s.target, s.key, s.data)
+			if err != nil {
+				txn.Abort(ctx)
+				errMap[s.target] = err
+				return resp, &errMap
+			}
+		}
+	}
+
+	var undo []cacheUndo
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i].revert()
+		}
+		if txn != nil {
+			txn.Abort(ctx)
+		}
+	}
+
+	for _, s := range staged {
+		if s.cache == nil {
+			continue
+		}
+
+		// NewClient guarantees every Cache is a CacheReader if atomicData is set.
+		reader, ok := s.cache.(handler.CacheReader)
+		if !ok {
+			rollback()
+			errMap[s.target] = fmt.Errorf("cache of target %q does not support reading objects", s.target)
+			return resp, &errMap
+		}
+
+		u := cacheUndo{cache: s.cache, key: s.key}
+		u.previous, err = reader.Get(s.key)
+		if err != nil {
+			rollback()
+			errMap[s.target] = err
+			return resp, &errMap
+		}
+
+		err = s.cache.Add(s.key, s.object)
+		if err != nil {
+			rollback()
+			errMap[s.target] = err
+			return resp, &errMap
+		}
+		undo = append(undo, u)
+	}
+
+	if txn != nil {
+		err = txn.Commit(ctx)
+		if err != nil {
+			// The transaction has already been released, so only the Caches need
+			// to be reverted.
+			txn = nil
+			rollback()
+			return resp, dataError(staged, err)
+		}
+	}
+
+	return resp, c.dataHandled(resp, staged, DataAdded)
+}
+
+// removeDataAtomic is RemoveData for Clients created with AtomicData.
+//
+// Every target processes data before anything is removed. Removals from the
+// Rego driver are staged in a single DataTransaction, and handler Caches are
+// only updated once the transaction commits, since Cache.Remove cannot fail.
+func (c *Client) removeDataAtomic(ctx context.Context, data interface{}) (*types.Responses, error) {
+	resp := types.NewResponses()
+
+	staged, errMap := c.stageData(data, false)
+	if len(errMap) != 0 {
+		return resp, &errMap
+	}
+	if len(staged) == 0 {
+		return resp, nil
+	}
+
+	txn, err := c.newDataTransaction(ctx)
+	if err != nil {
+		return resp, dataError(staged, err)
+	}
+
+	if txn != nil {
+		for _, s := range staged {
+			err = txn.RemoveData(ctx, s.target, s.key)
+			if err != nil {
+				txn.Abort(ctx)
+				errMap[s.target] = err
+				return resp, &errMap
+			}
+		}
+
+		err = txn.Commit(ctx)
+		if err != nil {
+			return resp, dataError(staged, err)
+		}
+	}
+
+	for _, s := range staged {
+		if s.cache != nil {
+			s.cache.Remove(s.key)
+		}
+	}
+
+	return resp, c.dataHandled(resp, staged, DataRemoved)
+}
+
+// stageData has every target process data, returning the targets which handle
+// data in sorted order so that storage transactions are always opened in the
+// same order. If toJSON is true, also converts the processed data to untyped
+// JSON for drivers.
+func (c *Client) stageData(data interface{}, toJSON bool) ([]stagedData, clienterrors.ErrorMap) {
+	errMap := make(clienterrors.ErrorMap)
+
+	names := make([]string, 0, len(c.targets))
+	for name := range c.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var staged []stagedData
+	for _, name := range names {
+		target := c.targets[name]
+
+		handled, key, processedData, err := target.ProcessData(data)
+		if err != nil {
+			errMap[name] = err
+			continue
+		}
+		if !handled {
+			continue
+		}
+
+		s := stagedData{target: name, key: key, object: processedData}
+		if toJSON {
+			s.data, err = toUntypedJSON(processedData)
+			if err != nil {
+				errMap[name] = err
+				continue
+			}
+		}
+
+		if cacher, ok := target.(handler.Cacher); ok {
+			s.cache = cacher.GetCache()
+		}
+
+		staged = append(staged, s)
+	}
+
+	return staged, errMap
+}
+
+// newDataTransaction begins a DataTransaction on the Rego driver. Returns nil
+// if the Client has no Rego driver.
+func (c *Client) newDataTransaction(ctx context.Context) (drivers.DataTransaction, error) {
+	d, ok := c.drivers[regoSchema.Name]
+	if !ok {
+		return nil, nil
+	}
+
+	// NewClient guarantees the Rego driver is a DataTransactor if atomicData is set.
+	transactor, ok := d.(drivers.DataTransactor)
+	if !ok {
+		return nil, fmt.Errorf("driver %q does not support atomic data writes", d.Name())
+	}
+
+	return transactor.NewDataTransaction(ctx)
+}
+
+// dataHandled marks the staged targets as handled in resp and publishes an
+// Event of eventType for each. Mirrors AddData and RemoveData by also reporting
+// ErrNoReferentialDriver for each target if the Client has no Rego driver and
+// the warning is not suppressed.
+func (c *Client) dataHandled(resp *types.Responses, staged []stagedData, eventType EventType) error {
+	for _, s := range staged {
+		resp.Handled[s.target] = true
+		c.publish(Event{Type: eventType, Target: s.target, Key: s.key})
+	}
+
+	_, hasRego := c.drivers[regoSchema.Name]
+	if hasRego || c.ignoreNoReferentialDriverWarning {
+		return nil
+	}
+
+	return dataError(staged, ErrNoReferentialDriver)
+}
+
+// dataError returns an ErrorMap with err for every staged target.
+func dataError(staged []stagedData, err error) error {
+	errMap := make(clienterrors.ErrorMap, len(staged))
+	for _, s := range staged {
+		errMap[s.target] = err
+	}
+	return &errMap
+}
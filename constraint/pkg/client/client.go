@@ -4,10 +4,14 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	apiconstraints "github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/crds"
@@ -23,6 +27,7 @@ import (
 	admissionv1 "k8s.io/api/admission/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/clock"
 )
 
 const statusField = "status"
@@ -30,12 +35,17 @@ const statusField = "status"
 // Client tracks ConstraintTemplates and Constraints for a set of Targets.
 // Allows validating reviews against Constraints.
 //
-// Threadsafe. Does not support concurrent mutation operations.
+// Threadsafe. Reviews read an immutable snapshot of Templates, Constraints,
+// and Exemptions, so they never wait for mutations, and see each mutation
+// either entirely or not at all. Mutations of the same Template and its
+// Constraints are serialized, while mutations of different Templates proceed
+// concurrently.
 //
-// Note that adding per-identifier locking would not fix this completely - the
-// thread for the first-sent call could be put to sleep while the second is
-// allowed to continue running. Thus, this problem can only safely be handled
-// by the caller.
+// Note that concurrent mutations of the same object are applied in the order
+// they acquire the Template's lock, which need not be the order they were
+// sent in - the thread for the first-sent call could be put to sleep while the
+// second is allowed to continue running. Thus, this problem can only safely be
+// handled by the caller.
 type Client struct {
 	// driver priority specifies the preference for which driver should
 	// be preferred if a template specifies multiple kinds of source
@@ -57,14 +67,58 @@ type Client struct {
 	// Assumed to be constant after initialization.
 	targets map[string]handler.TargetHandler
 
-	// mtx guards reading and writing data outside of Driver.
-	mtx sync.RWMutex
+	// state is the most recently published state of Templates, Constraints,
+	// and Exemptions.
+	state atomic.Pointer[state]
 
-	// templates is a map from a Template's name to its entry.
-	templates map[string]*templateClient
+	// mtx serializes publishing new states. It is held only while copying and
+	// replacing state, never while calling drivers.
+	mtx sync.Mutex
+
+	// templateLocks serializes the mutations of each Template.
+	templateLocks templateLocks
 
 	// enforcementPoints is array of enforcement points for which this client may be used.
 	enforcementPoints []string
+
+	// reviewConcurrency is the maximum number of targets and drivers which a
+	// single call to Review or ReviewBatch evaluates at once. Values less than 2
+	// mean they are evaluated sequentially.
+	reviewConcurrency int
+
+	// atomicData toggles whether AddData and RemoveData apply changes to all
+	// targets at once, or to each target independently.
+	atomicData bool
+
+	// restoreFrom, if set, is read by NewClient for a snapshot to restore.
+	restoreFrom io.Reader
+
+	// decisions caches the Responses of Review, if enabled with DecisionCache.
+	decisions *decisionCache
+
+	// generation is incremented after every change to Templates, Constraints,
+	// or data, so decisions cached before the change are never returned.
+	generation atomic.Uint64
+
+	// subscribers receive an Event for every change to Templates, Constraints,
+	// or data.
+	subscribers subscribers
+
+	// subscriberQueueSize is the most Events which may wait to be delivered to
+	// each subscriber.
+	subscriberQueueSize int
+
+	// shadowResults, if set, is called by Review with the outcome of evaluating
+	// each shadow Template.
+	shadowResults func(ShadowResult)
+
+	// schemaChangePolicy is what AddTemplate does with existing Constraints
+	// which an updated Template's schema does not accept.
+	schemaChangePolicy SchemaChangePolicy
+
+	// clock tells the time used to decide whether Constraints with active
+	// windows apply to a review.
+	clock clock.PassiveClock
 }
 
 // ARGetter is an interface for getting an AdmissionRequest.
@@ -73,14 +127,31 @@ type ARGetter interface {
 }
 
 // driverForTemplate returns the driver to be used for a template according
-// to the driver priority in the client. An empty string means the constraint
-// template does not contain a language the client has a driver for.
+// to the driver priority in the client. Templates with multiple targets must
+// be executed by a single driver, so only drivers with code for every target
+// are considered. An empty string means the constraint template does not
+// contain a language the client has a driver for.
 func (c *Client) driverForTemplate(template *templates.ConstraintTemplate) string {
 	if len(template.Spec.Targets) == 0 {
 		return ""
 	}
+
+	engineTargets := make(map[string]int)
+	for _, target := range template.Spec.Targets {
+		engines := make(map[string]bool)
+		for _, code := range target.Code {
+			engines[code.Engine] = true
+		}
+		for engine := range engines {
+			engineTargets[engine]++
+		}
+	}
+
 	language := ""
 	for _, v := range template.Spec.Targets[0].Code {
+		if engineTargets[v.Engine] != len(template.Spec.Targets) {
+			continue
+		}
 		priority, ok := c.driverPriority[v.Engine]
 		if !ok {
 			continue
@@ -104,69 +175,53 @@ func (c *Client) CreateCRD(ctx context.Context, templ *templates.ConstraintTempl
 		return nil, err
 	}
 
-	target, err := c.getTargetHandler(templ)
+	targets, err := c.getTargetHandlers(templ)
 	if err != nil {
 		return nil, err
 	}
 
-	return createCRD(ctx, templ, target)
+	return createCRD(ctx, templ, targets)
 }
 
 // AddTemplate adds the template source code to OPA and registers the CRD with the client for
 // schema validation on calls to AddConstraint. On error, the responses return value
 // will still be populated so that partial results can be analyzed.
 func (c *Client) AddTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*types.Responses, error) {
-	resp := types.NewResponses()
+	defer c.templateLocks.lock(templ.GetName())()
+	defer c.invalidateDecisions()
+
+	return c.addTemplate(ctx, templ)
+}
 
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
+// addTemplate is AddTemplate for callers which hold the Template's lock.
+func (c *Client) addTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*types.Responses, error) {
+	resp := types.NewResponses()
 
 	// Return immediately if no change.
-	targetName, err := getTargetName(templ)
+	targetNames, err := getTargetNames(templ)
 	if err != nil {
 		return resp, err
 	}
 
 	var cachedCpy *templates.ConstraintTemplate
-	hasConstraints := false
-	var oldTargets []string
 
-	cached := c.templates[templ.GetName()]
+	cached := c.getState().templates[templ.GetName()]
 	if cached != nil {
 		cachedCpy = cached.getTemplate()
-		hasConstraints = len(cached.constraints) > 0
-		for _, target := range cached.targets {
-			oldTargets = append(oldTargets, target.GetName())
-		}
 	}
 
 	// if there is more than one active driver for the template, there is some cleanup to do
 	// from a botched driver swap.
 	if cachedCpy != nil && cachedCpy.SemanticEqual(templ) && len(cached.activeDrivers) == 1 {
-		resp.Handled[targetName] = true
+		for _, targetName := range targetNames {
+			resp.Handled[targetName] = true
+		}
 		return resp, nil
 	}
 
-	if hasConstraints {
-		var newTargets []string
-		for _, target := range templ.Spec.Targets {
-			newTargets = append(newTargets, target.Target)
-		}
-
-		if len(oldTargets) != len(newTargets) {
-			return resp, fmt.Errorf("%w: old targets %v, new targets %v",
-				clienterrors.ErrChangeTargets, oldTargets, newTargets)
-		}
-
-		sort.Strings(oldTargets)
-		sort.Strings(newTargets)
-
-		for i, target := range oldTargets {
-			if target != newTargets[i] {
-				return resp, fmt.Errorf("%w: old targets %v, new targets %v",
-					clienterrors.ErrChangeTargets, oldTargets, newTargets)
-			}
-		}
+	err = checkTemplateChange(cached, templ)
+	if err != nil {
+		return resp, err
 	}
 
 	err = validateTemplateMetadata(templ)
@@ -174,16 +229,27 @@ func (c *Client) AddTemplate(ctx context.Context, templ *templates.ConstraintTem
 		return resp, err
 	}
 
-	target, err := c.getTargetHandler(templ)
+	targets, err := c.getTargetHandlers(templ)
 	if err != nil {
 		return resp, err
 	}
 
-	crd, err := createCRD(ctx, templ, target)
+	crd, err := createCRD(ctx, templ, targets)
 	if err != nil {
 		return resp, err
 	}
 
+	// The new schema may no longer accept the Template's existing Constraints.
+	var revalidated *revalidation
+	if cached != nil && c.schemaChangePolicy != IgnoreSchemaChange {
+		revalidated = revalidateConstraints(cached, crd, targets)
+		resp.InvalidConstraints = revalidated.report(cached)
+		if len(resp.InvalidConstraints) != 0 && c.schemaChangePolicy == RejectSchemaChange {
+			return resp, fmt.Errorf("%w: %d existing constraints of template %q do not conform to the new schema",
+				ErrSchemaChange, len(resp.InvalidConstraints), templ.GetName())
+		}
+	}
+
 	newDriverN := c.driverForTemplate(templ)
 
 	driver, ok := c.drivers[newDriverN]
@@ -191,24 +257,35 @@ func (c *Client) AddTemplate(ctx context.Context, templ *templates.ConstraintTem
 		return resp, fmt.Errorf("%w: available drivers: %v, wanted %q", clienterrors.ErrNoDriver, c.driverPriority, c.driverForTemplate(templ))
 	}
 
-	// TODO: because different targets may have different code sets,
-	// the driver should be told which targets to load code for.
-	// this is moot right now, since templates only have one target
+	// driverForTemplate only selects drivers with code for every target, so
+	// the driver loads code for all of the Template's targets.
 	if err := driver.AddTemplate(ctx, templ); err != nil {
 		return resp, err
 	}
 
 	templateName := templ.GetName()
 
-	cacheEntry := c.templates[templateName]
-
 	// We don't want to use the usual "if found/ok" idiom here - if the value
 	// stored for templateName is nil, we need to update it to be non-nil to avoid
 	// a panic.
-	if cacheEntry == nil {
+	var cacheEntry *templateClient
+	if cached == nil {
 		cacheEntry = newTemplateClient()
-		c.templates[templateName] = cacheEntry
-	}
+	} else {
+		cacheEntry = cached.clone()
+	}
+
+	// The driver now has the new Template, so whatever happens next must be
+	// recorded, including state needed to recover from a botched update. Events
+	// are only published once the state they describe has been.
+	var events []Event
+	committed := false
+	defer func() {
+		if !committed {
+			c.setTemplate(templateName, cacheEntry)
+			c.publish(events...)
+		}
+	}()
 
 	cacheEntry.activeDrivers[newDriverN] = true
 
@@ -221,8 +298,17 @@ func (c *Client) AddTemplate(ctx context.Context, templ *templates.ConstraintTem
 		}
 	}
 
+	// Quarantined Constraints must not be replayed into the new driver.
+	var quarantined, released []string
+	if revalidated != nil {
+		quarantined, released = revalidated.quarantine(cacheEntry)
+	}
+
 	if cacheEntry.needsConstraintReplay {
-		for _, constraintEntry := range cacheEntry.constraints {
+		for _, constraintEntry := range cacheEntry.constraints.all() {
+			if constraintEntry.quarantined != "" {
+				continue
+			}
 			cstr := constraintEntry.getConstraint()
 			if err := driver.AddConstraint(ctx, cstr); err != nil {
 				return resp, fmt.Errorf("%w: while replaying constraints", err)
@@ -233,7 +319,14 @@ func (c *Client) AddTemplate(ctx context.Context, templ *templates.ConstraintTem
 
 	// This state mutation needs to happen after the new driver is fully ready
 	// to enforce the template
-	cacheEntry.Update(templ, crd, target)
+	cacheEntry.Update(templ, crd, targets...)
+
+	if revalidated != nil {
+		events, err = c.applyRevalidation(ctx, driver, cacheEntry, revalidated, quarantined, released)
+		if err != nil {
+			return resp, fmt.Errorf("%w: while revalidating constraints", err)
+		}
+	}
 
 	// Remove old drivers last so that templates can be enforced
 	// despite a botched update
@@ -245,30 +338,108 @@ func (c *Client) AddTemplate(ctx context.Context, templ *templates.ConstraintTem
 		if !ok {
 			return resp, fmt.Errorf("%w: while changing drivers", clienterrors.ErrNoDriver)
 		}
+		// The shadow Template was compiled by the old driver, so it can no longer
+		// be compared with the active Template.
+		if cacheEntry.shadow != nil {
+			if err := discardShadow(ctx, oldDriver, cacheEntry); err != nil {
+				return resp, fmt.Errorf("%w: while changing drivers", err)
+			}
+		}
 		if err := oldDriver.RemoveTemplate(ctx, cachedCpy); err != nil {
 			return resp, fmt.Errorf("%w: while changing drivers", err)
 		}
 		delete(cacheEntry.activeDrivers, oldDriverN)
 	}
 
-	resp.Handled[targetName] = true
+	event := Event{Type: TemplateAdded, Template: cacheEntry.getTemplate(), Driver: newDriverN}
+	if cachedCpy != nil {
+		event.Type = TemplateUpdated
+		if oldDriverN := c.driverForTemplate(cachedCpy); oldDriverN != newDriverN {
+			event.PreviousDriver = oldDriverN
+		}
+	}
+	events = append(events, event)
+
+	c.setTemplate(templateName, cacheEntry)
+	committed = true
+	c.publish(events...)
+
+	for _, targetName := range targetNames {
+		resp.Handled[targetName] = true
+	}
 	return resp, nil
 }
 
-func getTargetName(templ *templates.ConstraintTemplate) (string, error) {
-	targets := templ.Spec.Targets
+// checkTemplateChange returns an error if replacing the Template in cached with
+// templ would invalidate the Template's existing Constraints. cached may be nil.
+func checkTemplateChange(cached *templateClient, templ *templates.ConstraintTemplate) error {
+	if cached == nil || cached.constraints.len() == 0 {
+		return nil
+	}
 
-	if len(targets) != 1 {
-		return "", fmt.Errorf("%w: must declare exactly one target",
-			clienterrors.ErrInvalidConstraintTemplate)
+	return checkCompatible(cached, templ)
+}
+
+// checkCompatible returns an error if templ has different targets or a
+// different scope than the Template in cached.
+func checkCompatible(cached *templateClient, templ *templates.ConstraintTemplate) error {
+	var oldTargets []string
+	for _, target := range cached.targets {
+		oldTargets = append(oldTargets, target.GetName())
 	}
 
-	if targets[0].Target == "" {
-		return "", fmt.Errorf("%w: target name must not be empty",
-			clienterrors.ErrInvalidConstraintTemplate)
+	var newTargets []string
+	for _, target := range templ.Spec.Targets {
+		newTargets = append(newTargets, target.Target)
+	}
+
+	if len(oldTargets) != len(newTargets) {
+		return fmt.Errorf("%w: old targets %v, new targets %v",
+			clienterrors.ErrChangeTargets, oldTargets, newTargets)
+	}
+
+	sort.Strings(oldTargets)
+	sort.Strings(newTargets)
+
+	for i, target := range oldTargets {
+		if target != newTargets[i] {
+			return fmt.Errorf("%w: old targets %v, new targets %v",
+				clienterrors.ErrChangeTargets, oldTargets, newTargets)
+		}
+	}
+
+	// Existing Constraints are only valid for the scope they were added with.
+	if oldScope, newScope := templateScope(cached.template), templateScope(templ); oldScope != newScope {
+		return fmt.Errorf("%w: old scope %q, new scope %q",
+			clienterrors.ErrChangeScope, oldScope, newScope)
+	}
+
+	return nil
+}
+
+// getTargetNames returns the names of the Template's targets, in the order the
+// Template declares them.
+func getTargetNames(templ *templates.ConstraintTemplate) ([]string, error) {
+	err := crds.ValidateTargets(templ)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(templ.Spec.Targets))
+	for i, target := range templ.Spec.Targets {
+		names[i] = target.Target
 	}
 
-	return targets[0].Target, nil
+	return names, nil
+}
+
+// templateScope returns the scope of the Template's Constraints, defaulting to
+// templates.ScopeCluster.
+func templateScope(templ *templates.ConstraintTemplate) string {
+	if templ.Spec.CRD.Spec.Scope == "" {
+		return templates.ScopeCluster
+	}
+	return templ.Spec.CRD.Spec.Scope
 }
 
 // RemoveTemplate removes the template source code from OPA and removes the CRD from the validation
@@ -278,18 +449,35 @@ func getTargetName(templ *templates.ConstraintTemplate) (string, error) {
 func (c *Client) RemoveTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*types.Responses, error) {
 	resp := types.NewResponses()
 
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
-
 	name := templ.GetName()
 
-	cached, found := c.templates[name]
+	defer c.templateLocks.lock(name)()
+	defer c.invalidateDecisions()
+
+	cached, found := c.getState().templates[name]
 	if !found {
 		return resp, nil
 	}
 
 	template := cached.getTemplate()
 
+	// Stop matching the Template's Constraints before removing its code from
+	// drivers. Reviews which began before then may still fail as the Template
+	// is removed.
+	c.update(func(s *state) {
+		delete(s.templates, name)
+	})
+
+	// If a driver fails to remove the Template, keep the Template so removing
+	// it may be retried.
+	remaining := cached.clone()
+	removed := false
+	defer func() {
+		if !removed {
+			c.setTemplate(name, remaining)
+		}
+	}()
+
 	// remove the template from all active drivers
 	// to ensure cleanup in case of a botched update
 	for driverN := range cached.activeDrivers {
@@ -298,14 +486,26 @@ func (c *Client) RemoveTemplate(ctx context.Context, templ *templates.Constraint
 			return resp, fmt.Errorf("%w: could not clean up %q", clienterrors.ErrNoDriver, driverN)
 		}
 
+		if remaining.shadow != nil && driverN == c.driverForTemplate(template) {
+			err := discardShadow(ctx, driver, remaining)
+			if err != nil {
+				return resp, err
+			}
+		}
+
 		err := driver.RemoveTemplate(ctx, template)
 		if err != nil {
 			return resp, err
 		}
-		delete(cached.activeDrivers, driverN)
+		delete(remaining.activeDrivers, driverN)
 	}
 
-	delete(c.templates, name)
+	removed = true
+
+	for _, key := range cached.constraintKeys() {
+		c.publish(Event{Type: ConstraintRemoved, Constraint: cached.constraint(key).getConstraint()})
+	}
+	c.publish(Event{Type: TemplateRemoved, Template: template})
 
 	for _, target := range cached.targets {
 		resp.Handled[target.GetName()] = true
@@ -323,10 +523,7 @@ func templateNotFound(name string) error {
 func (c *Client) GetTemplate(templ *templates.ConstraintTemplate) (*templates.ConstraintTemplate, error) {
 	name := templ.GetName()
 
-	c.mtx.RLock()
-	defer c.mtx.RUnlock()
-
-	template := c.templates[name]
+	template := c.getState().templates[name]
 	if template == nil {
 		return nil, templateNotFound(name)
 	}
@@ -335,10 +532,10 @@ func (c *Client) GetTemplate(templ *templates.ConstraintTemplate) (*templates.Co
 }
 
 // getTemplateClientForKind returns the template entry for a given constraint.
-func (c *Client) getTemplateClientForKind(kind string) *templateClient {
+func (s *state) getTemplateClientForKind(kind string) *templateClient {
 	name := strings.ToLower(kind)
 
-	return c.templates[name]
+	return s.templates[name]
 }
 
 // AddConstraint validates the constraint and, if valid, inserts it into OPA.
@@ -347,18 +544,20 @@ func (c *Client) getTemplateClientForKind(kind string) *templateClient {
 func (c *Client) AddConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*types.Responses, error) {
 	resp := types.NewResponses()
 
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
+	kind := constraint.GetKind()
+	templateName := strings.ToLower(kind)
+
+	defer c.templateLocks.lock(templateName)()
+	defer c.invalidateDecisions()
 
-	err := c.validateConstraint(constraint)
+	s := c.getState()
+	err := s.validateConstraint(constraint)
 	if err != nil {
 		return resp, err
 	}
 
-	kind := constraint.GetKind()
-	cached := c.getTemplateClientForKind(kind)
+	cached := s.getTemplateClientForKind(kind)
 	if cached == nil {
-		templateName := strings.ToLower(kind)
 		return resp, templateNotFound(templateName)
 	}
 
@@ -374,16 +573,28 @@ func (c *Client) AddConstraint(ctx context.Context, constraint *unstructured.Uns
 		return resp, err
 	}
 
-	changed, err := cached.AddConstraint(constraintWithDefaults, c.enforcementPoints)
+	_, exists := cached.constraints.get(constraintKey(constraintWithDefaults))
+
+	next := cached.clone()
+	changed, err := next.AddConstraint(constraintWithDefaults, c.enforcementPoints)
 	if err != nil {
 		return resp, err
 	}
 
 	if changed {
+		// Publish the Constraint only once driver has it, so reviews never
+		// match a Constraint driver does not know about.
 		err = driver.AddConstraint(ctx, constraintWithDefaults)
 		if err != nil {
 			return resp, err
 		}
+		c.setTemplate(templateName, next)
+
+		event := Event{Type: ConstraintAdded, Constraint: constraintWithDefaults.DeepCopy()}
+		if exists {
+			event.Type = ConstraintUpdated
+		}
+		c.publish(event)
 	}
 
 	for _, target := range cached.targets {
@@ -398,17 +609,18 @@ func (c *Client) AddConstraint(ctx context.Context, constraint *unstructured.Uns
 func (c *Client) RemoveConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*types.Responses, error) {
 	resp := types.NewResponses()
 
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
-
 	err := validateConstraintMetadata(constraint)
 	if err != nil {
 		return resp, err
 	}
 
 	kind := constraint.GetKind()
+	templateName := strings.ToLower(kind)
+
+	defer c.templateLocks.lock(templateName)()
+	defer c.invalidateDecisions()
 
-	cached := c.getTemplateClientForKind(kind)
+	cached := c.getState().getTemplateClientForKind(kind)
 	if cached == nil {
 		// The Template has been deleted, so nothing to do and no reason to return
 		// error.
@@ -433,7 +645,13 @@ func (c *Client) RemoveConstraint(ctx context.Context, constraint *unstructured.
 		resp.Handled[target.GetName()] = true
 	}
 
-	cached.RemoveConstraint(constraint.GetName())
+	key := constraintKey(constraint)
+	if removed, found := cached.constraints.get(key); found {
+		next := cached.clone()
+		next.RemoveConstraint(key)
+		c.setTemplate(templateName, next)
+		c.publish(Event{Type: ConstraintRemoved, Constraint: removed.getConstraint()})
+	}
 
 	return resp, nil
 }
@@ -445,17 +663,14 @@ func (c *Client) GetConstraint(constraint *unstructured.Unstructured) (*unstruct
 		return nil, err
 	}
 
-	c.mtx.RLock()
-	defer c.mtx.RUnlock()
-
 	kind := constraint.GetKind()
-	template := c.getTemplateClientForKind(kind)
+	template := c.getState().getTemplateClientForKind(kind)
 	if template == nil {
 		templateName := strings.ToLower(kind)
 		return nil, templateNotFound(templateName)
 	}
 
-	return template.GetConstraint(constraint.GetName())
+	return template.GetConstraint(constraintKey(constraint))
 }
 
 func validateConstraintMetadata(constraint *unstructured.Unstructured) error {
@@ -476,14 +691,14 @@ func validateConstraintMetadata(constraint *unstructured.Unstructured) error {
 	return nil
 }
 
-func (c *Client) validateConstraint(constraint *unstructured.Unstructured) error {
+func (s *state) validateConstraint(constraint *unstructured.Unstructured) error {
 	err := validateConstraintMetadata(constraint)
 	if err != nil {
 		return err
 	}
 
 	kind := constraint.GetKind()
-	template := c.getTemplateClientForKind(kind)
+	template := s.getTemplateClientForKind(kind)
 	if template == nil {
 		templateName := strings.ToLower(kind)
 		return templateNotFound(templateName)
@@ -495,17 +710,21 @@ func (c *Client) validateConstraint(constraint *unstructured.Unstructured) error
 // ValidateConstraint returns an error if the constraint is not recognized or does not conform to
 // the registered CRD for that constraint.
 func (c *Client) ValidateConstraint(constraint *unstructured.Unstructured) error {
-	c.mtx.RLock()
-	defer c.mtx.RUnlock()
-
-	return c.validateConstraint(constraint)
+	return c.getState().validateConstraint(constraint)
 }
 
 // AddData inserts the provided data into OPA for every target that can handle the data.
 // On error, the responses return value will still be populated so that
 // partial results can be analyzed.
+//
+// If the Client was created with AtomicData, either every target which handles
+// the data is updated or none are.
 func (c *Client) AddData(ctx context.Context, data interface{}) (*types.Responses, error) {
-	// TODO(#189): Make AddData atomic across all Drivers/Targets.
+	defer c.invalidateDecisions()
+
+	if c.atomicData {
+		return c.addDataAtomic(ctx, data)
+	}
 
 	resp := types.NewResponses()
 	errMap := make(clienterrors.ErrorMap)
@@ -523,18 +742,7 @@ func (c *Client) AddData(ctx context.Context, data interface{}) (*types.Response
 			continue
 		}
 
-		// Round trip data to force untyped JSON, as drivers are not type-aware.
-		// Marshal first to reject invalid JSON values, then use OPA's JSON decoder
-		// to preserve numeric precision by decoding numbers as json.Number instead
-		// of float64.
-		bytes, err := json.Marshal(processedData)
-		if err != nil {
-			errMap[name] = err
-
-			continue
-		}
-		var processedDataCpy interface{}
-		err = util.UnmarshalJSON(bytes, &processedDataCpy)
+		processedDataCpy, err := toUntypedJSON(processedData)
 		if err != nil {
 			errMap[name] = err
 
@@ -577,6 +785,7 @@ func (c *Client) AddData(ctx context.Context, data interface{}) (*types.Response
 		}
 
 		resp.Handled[name] = true
+		c.publish(Event{Type: DataAdded, Target: name, Key: key})
 	}
 
 	if len(errMap) == 0 {
@@ -585,10 +794,161 @@ func (c *Client) AddData(ctx context.Context, data interface{}) (*types.Response
 	return resp, &errMap
 }
 
+// AddDataBatch inserts many objects into OPA for every target that can handle
+// them. The returned slice contains, for each object in objs, the Responses
+// which AddData would return for it.
+//
+// Each target's objects are passed to the Rego driver together, so a driver
+// implementing drivers.DataBatcher can write them in a single transaction. An
+// object which fails does not prevent the others from being added.
+//
+// If the Client was created with AtomicData, each object is added atomically
+// as with AddData instead.
+//
+// On error, the responses return value will still be populated so that
+// partial results can be analyzed. The error is an ErrorMap from the index of
+// each object which failed to the error AddData would have returned for it.
+func (c *Client) AddDataBatch(ctx context.Context, objs []interface{}) ([]*types.Responses, error) {
+	defer c.invalidateDecisions()
+
+	responses := make([]*types.Responses, len(objs))
+	errMaps := make([]clienterrors.ErrorMap, len(objs))
+	for i := range objs {
+		responses[i] = types.NewResponses()
+		errMaps[i] = make(clienterrors.ErrorMap)
+	}
+
+	if c.atomicData {
+		for i, obj := range objs {
+			var err error
+			responses[i], err = c.addDataAtomic(ctx, obj)
+
+			var errMap *clienterrors.ErrorMap
+			if errors.As(err, &errMap) {
+				errMaps[i] = *errMap
+			}
+		}
+
+		return responses, batchError(errMaps)
+	}
+
+	// As with AddData, no locking is required here.
+	for name, target := range c.targets {
+		c.addTargetDataBatch(ctx, name, target, objs, responses, errMaps)
+	}
+
+	return responses, batchError(errMaps)
+}
+
+// addTargetDataBatch adds the objects in objs which target handles, recording
+// the outcome for each object in responses and errMaps.
+func (c *Client) addTargetDataBatch(ctx context.Context, name string, target handler.TargetHandler, objs []interface{}, responses []*types.Responses, errMaps []clienterrors.ErrorMap) {
+	var cache handler.Cache
+	if cacher, ok := target.(handler.Cacher); ok {
+		cache = cacher.GetCache()
+	}
+
+	var indices []int
+	var items []drivers.DataItem
+	for i, obj := range objs {
+		handled, key, processedData, err := target.ProcessData(obj)
+		if err != nil {
+			errMaps[i][name] = err
+			continue
+		}
+		if !handled {
+			continue
+		}
+
+		processedDataCpy, err := toUntypedJSON(processedData)
+		if err != nil {
+			errMaps[i][name] = err
+			continue
+		}
+
+		// As in AddData, add to the target cache first because cache.Remove
+		// cannot fail.
+		if cache != nil {
+			err = cache.Add(key, processedData)
+			if err != nil {
+				errMaps[i][name] = err
+				continue
+			}
+		}
+
+		indices = append(indices, i)
+		items = append(items, drivers.DataItem{Path: key, Data: processedDataCpy})
+	}
+
+	d, ok := c.drivers[regoSchema.Name]
+	if !ok {
+		for j, i := range indices {
+			if c.ignoreNoReferentialDriverWarning {
+				responses[i].Handled[name] = true
+				c.publish(Event{Type: DataAdded, Target: name, Key: items[j].Path})
+			} else {
+				errMaps[i][name] = ErrNoReferentialDriver
+			}
+		}
+		return
+	}
+
+	var errs []error
+	if batcher, ok := d.(drivers.DataBatcher); ok {
+		errs = batcher.AddDataBatch(ctx, name, items)
+	} else {
+		errs = make([]error, len(items))
+		for j, item := range items {
+			errs[j] = d.AddData(ctx, name, item.Path, item.Data)
+		}
+	}
+
+	for j, i := range indices {
+		if errs[j] != nil {
+			errMaps[i][name] = errs[j]
+			if cache != nil {
+				cache.Remove(items[j].Path)
+			}
+			continue
+		}
+
+		responses[i].Handled[name] = true
+		c.publish(Event{Type: DataAdded, Target: name, Key: items[j].Path})
+	}
+}
+
+// toUntypedJSON round trips data to force untyped JSON, as drivers are not
+// type-aware. Marshals first to reject invalid JSON values, then uses OPA's JSON
+// decoder to preserve numeric precision by decoding numbers as json.Number
+// instead of float64.
+func toUntypedJSON(data interface{}) (interface{}, error) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var cpy interface{}
+	err = util.UnmarshalJSON(bytes, &cpy)
+	if err != nil {
+		return nil, err
+	}
+
+	return cpy, nil
+}
+
 // RemoveData removes data from OPA for every target that can handle the data.
 // On error, the responses return value will still be populated so that
 // partial results can be analyzed.
+//
+// If the Client was created with AtomicData, either every target which handles
+// the data is updated or none are.
 func (c *Client) RemoveData(ctx context.Context, data interface{}) (*types.Responses, error) {
+	defer c.invalidateDecisions()
+
+	if c.atomicData {
+		return c.removeDataAtomic(ctx, data)
+	}
+
 	resp := types.NewResponses()
 	errMap := make(clienterrors.ErrorMap)
 	// Similar to AddData - no locking is required here. See AddData for full
@@ -625,6 +985,8 @@ func (c *Client) RemoveData(ctx context.Context, data interface{}) (*types.Respo
 				cache.Remove(relPath)
 			}
 		}
+
+		c.publish(Event{Type: DataRemoved, Target: target, Key: relPath})
 	}
 
 	if len(errMap) == 0 {
@@ -635,13 +997,188 @@ func (c *Client) RemoveData(ctx context.Context, data interface{}) (*types.Respo
 }
 
 func (c *Client) actionKey(constraint *unstructured.Unstructured) string {
-	return fmt.Sprintf("%s.%s", constraint.GetKind(), constraint.GetName())
+	return fmt.Sprintf("%s.%s", constraint.GetKind(), constraintKey(constraint))
 }
 
 // Review makes sure the provided object satisfies constraints applicable for specific enforcement points.
+// Targets and drivers are evaluated concurrently if enabled with ReviewConcurrency.
+// If enabled with DecisionCache, repeated reviews of identical objects may be
+// answered from the cache.
 // On error, the responses return value will still be populated so that
 // partial results can be analyzed.
 func (c *Client) Review(ctx context.Context, obj interface{}, opts ...reviews.ReviewOpt) (*types.Responses, error) {
+	cfg, eps, err := c.reviewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := types.NewResponses()
+	errMap := make(clienterrors.ErrorMap)
+
+	targetReviews := c.handleReview(obj, errMap)
+
+	// Mutations publish a new state before incrementing the generation, so
+	// reading the generation first ensures decisions are never cached under a
+	// generation newer than the state they were made with.
+	generation := c.generation.Load()
+	s := c.getState()
+
+	// Reviews which failed to be handled by a target are not cached so the error
+	// is returned every time.
+	var cacheKey string
+	cacheable := false
+	if c.decisions != nil && len(errMap) == 0 {
+		cacheKey, cacheable = decisionKey(generation, cfg, c.targets, targetReviews)
+	}
+	if cacheable {
+		if cached, found := c.decisions.get(cacheKey); found {
+			markCached(cached)
+			return cached, nil
+		}
+	}
+
+	matches := c.matchReviews(s, cfg, eps, targetReviews)
+
+	// Fan out the per-target reviews, then merge them serially so Responses and
+	// errMap are only ever written from this goroutine.
+	targetNames := make([]string, 0, len(targetReviews))
+	for target := range targetReviews {
+		targetNames = append(targetNames, target)
+	}
+
+	limit := c.newReviewLimiter()
+	outcomes := make([]reviewOutcome, len(targetNames))
+	limit.parallelize(len(targetNames), func(i int) {
+		target := targetNames[i]
+		outcomes[i] = c.review(ctx, s, limit, target, matches[target].constraints, targetReviews[target], opts...)
+	})
+
+	c.reviewShadows(ctx, s, targetNames, targetReviews, matches, outcomes, opts...)
+
+	for i, target := range targetNames {
+		// Reviews matching Constraints with active windows may have a different
+		// outcome later, so they are not cached.
+		if outcomes[i].uncacheable || matches[target].windowed {
+			cacheable = false
+		}
+
+		if outcomes[i].err != nil {
+			errMap.Add(target, outcomes[i].err)
+			continue
+		}
+
+		c.addTargetResponse(responses, target, outcomes[i], matches[target])
+	}
+
+	// Drivers may not report evaluation which was cut short because ctx was
+	// done, so those Responses are never cached.
+	if ctx.Err() != nil {
+		cacheable = false
+	}
+
+	if len(errMap) == 0 {
+		if cacheable {
+			c.decisions.add(cacheKey, responses)
+		}
+		return responses, nil
+	}
+
+	return responses, &errMap
+}
+
+// ReviewBatch reviews many objects at once. The returned slice contains, for
+// each object in objs, the Responses which Review would return for it.
+//
+// Every object is matched against a single snapshot of Client's state, and the
+// reviews for each target are passed to drivers together so drivers
+// implementing drivers.BatchQuerier can amortize per-query setup across the
+// whole batch.
+//
+// On error, the responses return value will still be populated so that
+// partial results can be analyzed. The error is an ErrorMap from the index of
+// each object which failed to the error Review would have returned for it.
+func (c *Client) ReviewBatch(ctx context.Context, objs []interface{}, opts ...reviews.ReviewOpt) ([]*types.Responses, error) {
+	cfg, eps, err := c.reviewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*types.Responses, len(objs))
+	errMaps := make([]clienterrors.ErrorMap, len(objs))
+	targetReviews := make([]map[string]interface{}, len(objs))
+	for i, obj := range objs {
+		responses[i] = types.NewResponses()
+		errMaps[i] = make(clienterrors.ErrorMap)
+		targetReviews[i] = c.handleReview(obj, errMaps[i])
+	}
+
+	s := c.getState()
+
+	matches := make([]map[string]*targetMatches, len(objs))
+	for i := range objs {
+		matches[i] = c.matchReviews(s, cfg, eps, targetReviews[i])
+	}
+
+	// Group the reviews by target so each target's drivers are queried once for
+	// the entire batch.
+	targetNames := c.knownTargets()
+	indices := make([][]int, len(targetNames))
+	outcomes := make([][]reviewOutcome, len(targetNames))
+	limit := c.newReviewLimiter()
+	limit.parallelize(len(targetNames), func(t int) {
+		target := targetNames[t]
+
+		var queries []drivers.BatchQuery
+		for i := range objs {
+			review, handled := targetReviews[i][target]
+			if !handled {
+				continue
+			}
+
+			indices[t] = append(indices[t], i)
+			queries = append(queries, drivers.BatchQuery{
+				Constraints: matches[i][target].constraints,
+				Review:      review,
+			})
+		}
+
+		outcomes[t] = c.reviewBatch(ctx, s, limit, target, queries, opts...)
+	})
+
+	for t, target := range targetNames {
+		for j, i := range indices[t] {
+			if outcomes[t][j].err != nil {
+				errMaps[i].Add(target, outcomes[t][j].err)
+				continue
+			}
+
+			c.addTargetResponse(responses[i], target, outcomes[t][j], matches[i][target])
+		}
+	}
+
+	return responses, batchError(errMaps)
+}
+
+// batchError returns an ErrorMap from the index of each non-empty ErrorMap in
+// errMaps to that ErrorMap, or nil if all are empty.
+func batchError(errMaps []clienterrors.ErrorMap) error {
+	batchErrs := make(clienterrors.ErrorMap)
+	for i := range errMaps {
+		if len(errMaps[i]) > 0 {
+			batchErrs.Add(strconv.Itoa(i), &errMaps[i])
+		}
+	}
+
+	if len(batchErrs) == 0 {
+		return nil
+	}
+
+	return &batchErrs
+}
+
+// reviewConfig applies opts and returns the resulting configuration along with
+// the enforcement points of this Client the review applies to.
+func (c *Client) reviewConfig(opts ...reviews.ReviewOpt) (*reviews.ReviewCfg, []string, error) {
 	var eps []string
 	cfg := &reviews.ReviewCfg{}
 	for _, opt := range opts {
@@ -656,16 +1193,16 @@ func (c *Client) Review(ctx context.Context, obj interface{}, opts ...reviews.Re
 		}
 	}
 	if eps == nil {
-		return nil, fmt.Errorf("%w, supported enforcement points: %v", ErrUnsupportedEnforcementPoints, c.enforcementPoints)
+		return nil, nil, fmt.Errorf("%w, supported enforcement points: %v", ErrUnsupportedEnforcementPoints, c.enforcementPoints)
 	}
 
-	responses := types.NewResponses()
-	errMap := make(clienterrors.ErrorMap)
+	return cfg, eps, nil
+}
 
-	ignoredTargets := make(map[string]bool)
-	reviews := make(map[string]interface{})
-	// The set of targets should not change after Client is initialized, so it
-	// is safe to defer locking until after reviews have been created.
+// handleReview returns the review of obj for each target which handles it.
+// Errors returned by targets are recorded in errMap.
+func (c *Client) handleReview(obj interface{}, errMap clienterrors.ErrorMap) map[string]interface{} {
+	targetReviews := make(map[string]interface{})
 	for name, target := range c.targets {
 		handled, review, err := target.HandleReview(obj)
 		if err != nil {
@@ -674,138 +1211,369 @@ func (c *Client) Review(ctx context.Context, obj interface{}, opts ...reviews.Re
 		}
 
 		if !handled {
-			ignoredTargets[name] = true
 			continue
 		}
 
-		reviews[name] = review
+		targetReviews[name] = review
 	}
 
-	constraintsByTarget := make(map[string][]*unstructured.Unstructured)
-	autorejections := make(map[string][]constraintMatchResult)
-	scopedEnforcementActionsByTarget := make(map[string]map[string][]string)
-	enforcementActionByTarget := make(map[string]map[string]string)
+	return targetReviews
+}
 
-	var templateList []*templateClient
+// targetMatches are the results of running a target's Matchers against a
+// single review.
+type targetMatches struct {
+	// constraints are the Constraints which matched the review.
+	constraints []*unstructured.Unstructured
+	// autorejections are the Constraints whose Matchers returned an error.
+	autorejections []constraintMatchResult
+	// scopedEnforcementActions are the scoped actions of matched Constraints,
+	// keyed by actionKey.
+	scopedEnforcementActions map[string][]string
+	// enforcementActions are the actions of matched Constraints, keyed by
+	// actionKey.
+	enforcementActions map[string]string
+	// explanations explain the decision made for each Constraint, if requested
+	// with reviews.ExplainMatching.
+	explanations []*types.MatchExplanation
+	// exempted are the Constraints which matched, but were not evaluated
+	// because of an Exemption.
+	exempted []*types.ExemptedConstraint
+	// inactive are the Constraints which matched, but were not evaluated
+	// because they were not active at the time of the review.
+	inactive []*types.InactiveConstraint
+	// windowed is true if any Constraint which matched has an active window,
+	// so the outcome of the review depends on when it happened.
+	windowed bool
+}
 
-	c.mtx.RLock()
-	defer c.mtx.RUnlock()
+// skippedOperations returns the Templates which do not apply to the admission
+// operation of a webhook Review, and the operation each rejected. Templates
+// may restrict the operations they apply to for each of their targets, but the
+// targets' reviews share one admission request, so a Template which rejects the
+// operation of any target's review is skipped for every target.
+func skippedOperations(s *state, cfg *reviews.ReviewCfg, targetReviews map[string]interface{}) map[*templateClient]string {
+	if cfg.EnforcementPoint != apiconstraints.WebhookEnforcementPoint {
+		return nil
+	}
 
-	for _, template := range c.templates {
-		if cfg.EnforcementPoint == apiconstraints.WebhookEnforcementPoint {
-			// for backward compatibility, matching all templates by default
-			operationMatched := true
-			for _, review := range reviews {
-				arGetter, ok := review.(ARGetter)
-				if !ok {
-					continue
-				}
-				req := arGetter.GetAdmissionRequest()
-				if !template.MatchesOperation(string(req.Operation)) {
-					operationMatched = false
-					break
-				}
-			}
-			if !operationMatched {
+	// Targets are checked in order so the rejected operation reported for a
+	// Template is stable.
+	targets := make([]string, 0, len(targetReviews))
+	for target := range targetReviews {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	skipped := make(map[*templateClient]string)
+	for _, target := range targets {
+		arGetter, ok := targetReviews[target].(ARGetter)
+		if !ok {
+			continue
+		}
+		operation := string(arGetter.GetAdmissionRequest().Operation)
+
+		for _, template := range s.templates {
+			if _, found := skipped[template]; found {
 				continue
 			}
+			if !template.MatchesTargetOperation(target, operation) {
+				skipped[template] = operation
+			}
 		}
-		templateList = append(templateList, template)
 	}
 
-	for target, review := range reviews {
-		var targetConstraints []*unstructured.Unstructured
-		targetScopedEnforcementActions := make(map[string][]string)
-		targetEnforcementAction := make(map[string]string)
-		for _, template := range templateList {
-			matchingConstraints := template.Matches(target, review, eps)
+	return skipped
+}
+
+// matchReviews runs the Matchers of every Constraint in s against each
+// target's review.
+func (c *Client) matchReviews(s *state, cfg *reviews.ReviewCfg, eps []string, targetReviews map[string]interface{}) map[string]*targetMatches {
+	result := make(map[string]*targetMatches, len(targetReviews))
+	// Every Constraint is matched against the same time, so a review never sees
+	// a Constraint's window open or close partway through.
+	now := c.clock.Now()
+	skipped := skippedOperations(s, cfg, targetReviews)
+	for target, review := range targetReviews {
+
+		// Targets may index reviews so only candidate Constraints' Matchers run.
+		var reviewKeys []string
+		indexed := false
+		if indexer, ok := c.targets[target].(handler.IndexableMatcher); ok {
+			reviewKeys, indexed = indexer.ReviewIndexKeys(review)
+		}
+
+		matches := &targetMatches{
+			scopedEnforcementActions: make(map[string][]string),
+			enforcementActions:       make(map[string]string),
+		}
+		exemptions := s.exemptionsFor(target, review)
+		for _, template := range s.templates {
+			if operation, found := skipped[template]; found {
+				if cfg.ExplainMatching {
+					matches.explanations = append(matches.explanations, template.SkipOperation(target, operation)...)
+				}
+				continue
+			}
+
+			// Explaining why Constraints did not match requires running every
+			// Constraint's Matcher, so the index is not used.
+			var matchingConstraints map[string]constraintMatchResult
+			var explanations []*types.MatchExplanation
+			if cfg.ExplainMatching {
+				matchingConstraints, explanations = template.ExplainMatches(target, review, now, eps)
+				matches.explanations = append(matches.explanations, explanations...)
+			} else {
+				matchingConstraints = template.Matches(target, review, now, reviewKeys, indexed, eps)
+			}
 			for _, matchResult := range matchingConstraints {
+				if matchResult.windowed {
+					matches.windowed = true
+				}
+
+				if matchResult.inactive != "" {
+					matches.inactive = append(matches.inactive, &types.InactiveConstraint{
+						Constraint: matchResult.constraint,
+						Reason:     matchResult.inactive,
+					})
+					continue
+				}
+
+				// Exemptions apply whether or not the Matcher returned an error,
+				// as exempt objects are never subject to the Constraint.
+				if exemption := exemptions.exemption(matchResult.constraint); exemption != "" {
+					matches.exempted = append(matches.exempted, &types.ExemptedConstraint{
+						Constraint: matchResult.constraint,
+						Exemption:  exemption,
+					})
+					markExempted(explanations, matchResult.constraint, exemption)
+					continue
+				}
+
 				if matchResult.error == nil {
-					targetConstraints = append(targetConstraints, matchResult.constraint)
-					targetScopedEnforcementActions[c.actionKey(matchResult.constraint)] = matchResult.scopedEnforcementActions
-					targetEnforcementAction[c.actionKey(matchResult.constraint)] = matchResult.enforcementAction
+					matches.constraints = append(matches.constraints, matchResult.constraint)
+					matches.scopedEnforcementActions[c.actionKey(matchResult.constraint)] = matchResult.scopedEnforcementActions
+					matches.enforcementActions[c.actionKey(matchResult.constraint)] = matchResult.enforcementAction
 				} else {
-					autorejections[target] = append(autorejections[target], matchResult)
+					matches.autorejections = append(matches.autorejections, matchResult)
 				}
 			}
 		}
-		constraintsByTarget[target] = targetConstraints
-		scopedEnforcementActionsByTarget[target] = targetScopedEnforcementActions
-		enforcementActionByTarget[target] = targetEnforcementAction
+		result[target] = matches
 	}
 
-	for target, review := range reviews {
-		constraints := constraintsByTarget[target]
+	return result
+}
 
-		resp, stats, err := c.review(ctx, target, constraints, review, opts...)
-		if err != nil {
-			errMap.Add(target, err)
-			continue
+// addTargetResponse records the outcome of reviewing an object for target in
+// responses. Fills in the enforcement actions determined while matching, and
+// adds results for autorejected Constraints.
+func (c *Client) addTargetResponse(responses *types.Responses, target string, outcome reviewOutcome, matches *targetMatches) {
+	resp := outcome.resp
+
+	c.fillEnforcementActions(resp.Results, matches)
+
+	for _, autorejection := range matches.autorejections {
+		resp.AddResult(autorejection.ToResult())
+	}
+
+	if matches.explanations != nil {
+		// Explanations refer to the Constraints cached in Client, so they must be
+		// copied before being returned.
+		resp.MatchExplanations = make([]*types.MatchExplanation, len(matches.explanations))
+		for i, explanation := range matches.explanations {
+			resp.MatchExplanations[i] = explanation.DeepCopy()
 		}
+		types.SortMatchExplanations(resp.MatchExplanations)
+	}
 
-		for i := range resp.Results {
-			if val, ok := scopedEnforcementActionsByTarget[target][c.actionKey(resp.Results[i].Constraint)]; ok {
-				resp.Results[i].ScopedEnforcementActions = val
-			}
-			if val, ok := enforcementActionByTarget[target][c.actionKey(resp.Results[i].Constraint)]; ok {
-				resp.Results[i].EnforcementAction = val
-			}
+	if matches.exempted != nil {
+		resp.Exempted = make([]*types.ExemptedConstraint, len(matches.exempted))
+		for i, exempted := range matches.exempted {
+			resp.Exempted[i] = exempted.DeepCopy()
 		}
+		types.SortExemptedConstraints(resp.Exempted)
+	}
 
-		for _, autorejection := range autorejections[target] {
-			resp.AddResult(autorejection.ToResult())
+	if matches.inactive != nil {
+		resp.Inactive = make([]*types.InactiveConstraint, len(matches.inactive))
+		for i, inactive := range matches.inactive {
+			resp.Inactive[i] = inactive.DeepCopy()
 		}
+		types.SortInactiveConstraints(resp.Inactive)
+	}
 
-		// Ensure deterministic result ordering.
-		resp.Sort()
+	// Ensure deterministic result ordering.
+	resp.Sort()
 
-		responses.ByTarget[target] = resp
-		if stats != nil {
-			// add the target label to these stats for future collation.
-			targetLabel := &instrumentation.Label{Name: "target", Value: target}
-			for _, stat := range stats {
-				if len(stat.Labels) == 0 {
-					stat.Labels = []*instrumentation.Label{targetLabel}
-				} else {
-					stat.Labels = append(stat.Labels, targetLabel)
-				}
+	responses.ByTarget[target] = resp
+	if outcome.stats != nil {
+		// add the target label to these stats for future collation.
+		targetLabel := &instrumentation.Label{Name: "target", Value: target}
+		for _, stat := range outcome.stats {
+			if len(stat.Labels) == 0 {
+				stat.Labels = []*instrumentation.Label{targetLabel}
+			} else {
+				stat.Labels = append(stat.Labels, targetLabel)
 			}
-			responses.StatsEntries = append(responses.StatsEntries, stats...)
 		}
+		responses.StatsEntries = append(responses.StatsEntries, outcome.stats...)
 	}
+}
 
-	if len(errMap) == 0 {
-		return responses, nil
+// fillEnforcementActions sets the enforcement actions of results to those
+// determined while matching.
+func (c *Client) fillEnforcementActions(results []*types.Result, matches *targetMatches) {
+	for i := range results {
+		if val, ok := matches.scopedEnforcementActions[c.actionKey(results[i].Constraint)]; ok {
+			results[i].ScopedEnforcementActions = val
+		}
+		if val, ok := matches.enforcementActions[c.actionKey(results[i].Constraint)]; ok {
+			results[i].EnforcementAction = val
+		}
 	}
+}
 
-	return responses, &errMap
+func (c *Client) review(ctx context.Context, s *state, limit reviewLimiter, target string, constraints []*unstructured.Unstructured, review interface{}, opts ...reviews.ReviewOpt) reviewOutcome {
+	driverToConstraints, err := c.constraintsByDriver(s, constraints)
+	if err != nil {
+		return reviewOutcome{err: err}
+	}
+
+	driverNames := queriedDrivers(driverToConstraints)
+
+	// Drivers are threadsafe, so they may be queried concurrently. Results are
+	// collected per driver and merged in order afterwards.
+	queryResponses := make([]*drivers.QueryResponse, len(driverNames))
+	queryErrs := make([]error, len(driverNames))
+	limit.parallelize(len(driverNames), func(i int) {
+		driverName := driverNames[i]
+		queryResponses[i], queryErrs[i] = c.drivers[driverName].Query(ctx, target, driverToConstraints[driverName], review, opts...)
+	})
+
+	return mergeQueryResponses(target, driverNames, queryResponses, queryErrs)
 }
 
-func (c *Client) review(ctx context.Context, target string, constraints []*unstructured.Unstructured, review interface{}, opts ...reviews.ReviewOpt) (*types.Response, []*instrumentation.StatsEntry, error) {
-	var results []*types.Result
-	var stats []*instrumentation.StatsEntry
-	var tracesBuilder strings.Builder
-	errs := &clienterrors.ErrorMap{}
+// reviewBatch runs each query against target. Drivers which implement
+// drivers.BatchQuerier receive all of their queries in a single call; other
+// drivers are queried once per review. Returns one outcome per query.
+func (c *Client) reviewBatch(ctx context.Context, s *state, limit reviewLimiter, target string, queries []drivers.BatchQuery, opts ...reviews.ReviewOpt) []reviewOutcome {
+	outcomes := make([]reviewOutcome, len(queries))
+
+	driverToConstraints := make([]map[string][]*unstructured.Unstructured, len(queries))
+	driverNames := make([][]string, len(queries))
+	queryResponses := make([][]*drivers.QueryResponse, len(queries))
+	queryErrs := make([][]error, len(queries))
+
+	// driverQueries is a map from each driver to the positions of the queries
+	// it must run: the index of the query, and the driver's index in that
+	// query's driverNames.
+	driverQueries := make(map[string][][2]int)
+	for i, query := range queries {
+		byDriver, err := c.constraintsByDriver(s, query.Constraints)
+		if err != nil {
+			outcomes[i].err = err
+			continue
+		}
+
+		driverToConstraints[i] = byDriver
+		driverNames[i] = queriedDrivers(byDriver)
+		queryResponses[i] = make([]*drivers.QueryResponse, len(driverNames[i]))
+		queryErrs[i] = make([]error, len(driverNames[i]))
+		for j, driverName := range driverNames[i] {
+			driverQueries[driverName] = append(driverQueries[driverName], [2]int{i, j})
+		}
+	}
+
+	batchDrivers := queriedDrivers(driverQueries)
+	limit.parallelize(len(batchDrivers), func(d int) {
+		driverName := batchDrivers[d]
+		driver := c.drivers[driverName]
+		positions := driverQueries[driverName]
+
+		batcher, ok := driver.(drivers.BatchQuerier)
+		if !ok {
+			for _, pos := range positions {
+				i, j := pos[0], pos[1]
+				queryResponses[i][j], queryErrs[i][j] = driver.Query(ctx, target, driverToConstraints[i][driverName], queries[i].Review, opts...)
+			}
+			return
+		}
 
+		batch := make([]drivers.BatchQuery, len(positions))
+		for k, pos := range positions {
+			batch[k] = drivers.BatchQuery{
+				Constraints: driverToConstraints[pos[0]][driverName],
+				Review:      queries[pos[0]].Review,
+			}
+		}
+
+		results := batcher.QueryBatch(ctx, target, batch, opts...)
+		for k, pos := range positions {
+			i, j := pos[0], pos[1]
+			queryResponses[i][j], queryErrs[i][j] = results[k].Response, results[k].Err
+		}
+	})
+
+	for i := range queries {
+		if outcomes[i].err != nil {
+			continue
+		}
+
+		outcomes[i] = mergeQueryResponses(target, driverNames[i], queryResponses[i], queryErrs[i])
+	}
+
+	return outcomes
+}
+
+// constraintsByDriver groups constraints by the name of the driver which
+// enforces their Template in s.
+func (c *Client) constraintsByDriver(s *state, constraints []*unstructured.Unstructured) (map[string][]*unstructured.Unstructured, error) {
 	driverToConstraints := map[string][]*unstructured.Unstructured{}
 
 	for _, constraint := range constraints {
-		template, ok := c.templates[strings.ToLower(constraint.GetObjectKind().GroupVersionKind().Kind)]
+		template, ok := s.templates[strings.ToLower(constraint.GetObjectKind().GroupVersionKind().Kind)]
 		if !ok {
-			return nil, nil, fmt.Errorf("%w: while loading driver for constraint %s", ErrMissingConstraintTemplate, constraint.GetName())
+			return nil, fmt.Errorf("%w: while loading driver for constraint %s", ErrMissingConstraintTemplate, constraint.GetName())
 		}
 		driver := c.driverForTemplate(template.template)
 		if driver == "" {
-			return nil, nil, fmt.Errorf("%w: while loading driver for constraint %s", clienterrors.ErrNoDriver, constraint.GetName())
+			return nil, fmt.Errorf("%w: while loading driver for constraint %s", clienterrors.ErrNoDriver, constraint.GetName())
 		}
 		driverToConstraints[driver] = append(driverToConstraints[driver], constraint)
 	}
 
-	for driverName, driver := range c.drivers {
-		if len(driverToConstraints[driverName]) == 0 {
+	return driverToConstraints, nil
+}
+
+// queriedDrivers returns the sorted names of the drivers in byDriver with
+// at least one entry.
+func queriedDrivers[T any](byDriver map[string][]T) []string {
+	var driverNames []string
+	for driverName, entries := range byDriver {
+		if len(entries) == 0 {
 			continue
 		}
-		qr, err := driver.Query(ctx, target, driverToConstraints[driverName], review, opts...)
+		driverNames = append(driverNames, driverName)
+	}
+	sort.Strings(driverNames)
+
+	return driverNames
+}
+
+// mergeQueryResponses combines the responses of the drivers queried for target
+// into a single Response. queryResponses and queryErrs are parallel to
+// driverNames.
+func mergeQueryResponses(target string, driverNames []string, queryResponses []*drivers.QueryResponse, queryErrs []error) reviewOutcome {
+	var results []*types.Result
+	var stats []*instrumentation.StatsEntry
+	var tracesBuilder strings.Builder
+	var traceEvents []*types.TraceEvent
+	uncacheable := false
+	errs := &clienterrors.ErrorMap{}
+
+	for i, driverName := range driverNames {
+		qr, err := queryResponses[i], queryErrs[i]
 		if err != nil {
 			errs.Add(driverName, err)
 			continue
@@ -814,12 +1582,16 @@ func (c *Client) review(ctx context.Context, target string, constraints []*unstr
 			results = append(results, qr.Results...)
 
 			stats = append(stats, qr.StatsEntries...)
+			// Traces describe a single evaluation, including when the driver
+			// traces every query, so traced responses are not reused.
+			uncacheable = uncacheable || qr.Uncacheable || qr.Trace != nil || len(qr.TraceEvents) != 0
 
 			if qr.Trace != nil {
 				fmt.Fprintf(&tracesBuilder, "DRIVER %s:\n\n", driverName)
 				tracesBuilder.WriteString(*qr.Trace)
 				tracesBuilder.WriteString("\n\n")
 			}
+			traceEvents = append(traceEvents, qr.TraceEvents...)
 		}
 	}
 
@@ -838,11 +1610,77 @@ func (c *Client) review(ctx context.Context, target string, constraints []*unstr
 		errRet = errs
 	}
 
-	return &types.Response{
-		Trace:   trace,
-		Target:  target,
-		Results: results,
-	}, stats, errRet
+	return reviewOutcome{
+		resp: &types.Response{
+			Trace:       trace,
+			TraceEvents: traceEvents,
+			Target:      target,
+			Results:     results,
+		},
+		stats:       stats,
+		uncacheable: uncacheable,
+		err:         errRet,
+	}
+}
+
+// reviewOutcome is the result of reviewing an object for a single target.
+type reviewOutcome struct {
+	resp  *types.Response
+	stats []*instrumentation.StatsEntry
+	// uncacheable is true if any driver reported its results may not be reused
+	// for identical reviews, or returned a trace.
+	uncacheable bool
+	err         error
+}
+
+// reviewLimiter limits the goroutines a single call to Review or ReviewBatch
+// uses. It is shared by every level the call fans out at, so targets and their
+// drivers together never use more than reviewConcurrency goroutines. A nil
+// reviewLimiter runs everything on the calling goroutine.
+type reviewLimiter chan struct{}
+
+// newReviewLimiter returns a reviewLimiter for a single call to Review or
+// ReviewBatch. The calling goroutine counts towards the limit.
+func (c *Client) newReviewLimiter() reviewLimiter {
+	if c.reviewConcurrency < 2 {
+		return nil
+	}
+	return make(reviewLimiter, c.reviewConcurrency-1)
+}
+
+// parallelize calls fn once for each index in [0, n), and returns after all
+// calls have completed. Each call runs on a new goroutine if l has one to
+// spare, and otherwise on the calling goroutine. Never blocking for a spare
+// goroutine keeps nested calls from deadlocking while their callers hold
+// every goroutine. If l is nil, calls fn sequentially in index order.
+func (l reviewLimiter) parallelize(n int, fn func(i int)) {
+	if l == nil || n < 2 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		// The calling goroutine would otherwise be idle while waiting for the
+		// last call.
+		if i == n-1 {
+			fn(i)
+			break
+		}
+
+		select {
+		case l <- struct{}{}:
+			wg.Go(func() {
+				defer func() { <-l }()
+				fn(i)
+			})
+		default:
+			fn(i)
+		}
+	}
+	wg.Wait()
 }
 
 // Dump dumps the state of OPA to aid in debugging.
@@ -891,31 +1729,54 @@ func (c *Client) knownTargets() []string {
 	return knownTargets
 }
 
-// getTargetHandler returns the TargetHandler for the Template, or an error if
-// it does not exist.
+// getTargetHandlers returns the TargetHandlers for the Template's targets, or an
+// error if any do not exist or, for namespaced Templates, are not
+// handler.ReviewNamespacers.
 //
 // The set of targets is assumed to be constant.
-func (c *Client) getTargetHandler(templ *templates.ConstraintTemplate) (handler.TargetHandler, error) {
-	targetName, err := getTargetName(templ)
+func (c *Client) getTargetHandlers(templ *templates.ConstraintTemplate) ([]handler.TargetHandler, error) {
+	targetNames, err := getTargetNames(templ)
 	if err != nil {
 		return nil, err
 	}
 
-	targetHandler, found := c.targets[targetName]
+	targetHandlers := make([]handler.TargetHandler, len(targetNames))
+	for i, targetName := range targetNames {
+		targetHandler, found := c.targets[targetName]
+		if !found {
+			knownTargets := c.knownTargets()
 
-	if !found {
-		knownTargets := c.knownTargets()
+			return nil, fmt.Errorf("%w: target %q not recognized, known targets %v",
+				clienterrors.ErrInvalidConstraintTemplate, targetName, knownTargets)
+		}
 
-		return nil, fmt.Errorf("%w: target %q not recognized, known targets %v",
-			clienterrors.ErrInvalidConstraintTemplate, targetName, knownTargets)
+		if templateScope(templ) == templates.ScopeNamespaced {
+			if _, ok := targetHandler.(handler.ReviewNamespacer); !ok {
+				return nil, fmt.Errorf("%w: target %q does not support namespaced constraints",
+					clienterrors.ErrInvalidConstraintTemplate, targetName)
+			}
+		}
+
+		targetHandlers[i] = targetHandler
 	}
 
-	return targetHandler, nil
+	return targetHandlers, nil
 }
 
-// createCRD creates the Template's CRD and validates the result.
-func createCRD(ctx context.Context, templ *templates.ConstraintTemplate, target handler.TargetHandler) (*apiextensions.CustomResourceDefinition, error) {
-	sch := crds.CreateSchema(templ, target)
+// createCRD creates the Template's CRD and validates the result. The CRD's
+// match schema is the merged match schema of all of the Template's targets.
+func createCRD(ctx context.Context, templ *templates.ConstraintTemplate, targets []handler.TargetHandler) (*apiextensions.CustomResourceDefinition, error) {
+	providers := make([]crds.MatchSchemaProvider, len(targets))
+	for i, target := range targets {
+		providers[i] = target
+	}
+
+	matchSchema, err := crds.MergeMatchSchemas(providers...)
+	if err != nil {
+		return nil, err
+	}
+
+	sch := crds.CreateSchema(templ, matchSchema)
 
 	crd, err := crds.CreateCRD(templ, sch)
 	if err != nil {
@@ -944,3 +1805,9 @@ func validateTemplateMetadata(templ *templates.ConstraintTemplate) error {
 
 	return nil
 }
+
+// invalidateDecisions ensures no decision cached before it is called is
+// returned by Review.
+func (c *Client) invalidateDecisions() {
+	c.generation.Add(1)
+}
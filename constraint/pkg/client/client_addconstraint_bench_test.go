@@ -85,3 +85,48 @@ func BenchmarkClient_AddConstraint_Parallel(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkClient_AddConstraint_Existing measures adding and removing a
+// Constraint of a Template which already has many Constraints. The cost should
+// grow at most logarithmically with the number of existing Constraints.
+func BenchmarkClient_AddConstraint_Existing(b *testing.B) {
+	nConstraints := []int{10, 1000, 5000}
+
+	for _, n := range nConstraints {
+		b.Run(fmt.Sprintf("%d-Constraints", n), func(b *testing.B) {
+			ctx := context.Background()
+			c := clienttest.New(b)
+
+			_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for i := 0; i < n; i++ {
+				name := fmt.Sprintf("foo-%d", i)
+				constraint := cts.MakeConstraint(b, clienttest.KindCheckData, name, cts.WantData("bar"))
+
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			constraint := cts.MakeConstraint(b, clienttest.KindCheckData, "bar", cts.WantData("bar"))
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				_, err = c.RemoveConstraint(ctx, constraint)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
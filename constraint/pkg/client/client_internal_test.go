@@ -2,17 +2,27 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	apiconstraints "github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest/cts"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/fake"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/fake/schema"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/reviews"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler/handlertest"
+	"github.com/open-policy-agent/opa/v1/ast"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/ptr"
 )
@@ -322,8 +332,8 @@ func TestMultiDriverAddTemplate(t *testing.T) {
 		if !reflect.DeepEqual(driverA.GetConstraintsForTemplate(anyTemplate), constraints) {
 			t.Errorf("Missing constraints: %v", cmp.Diff(driverA.GetConstraintsForTemplate(anyTemplate), constraints))
 		}
-		if len(client.templates[anyTemplate.Name].activeDrivers) != 3 {
-			t.Errorf("Wanted 3 active drivers, got %d", len(client.templates[anyTemplate.Name].activeDrivers))
+		if len(client.getState().templates[anyTemplate.Name].activeDrivers) != 3 {
+			t.Errorf("Wanted 3 active drivers, got %d", len(client.getState().templates[anyTemplate.Name].activeDrivers))
 		}
 		if len(driverB.GetTemplateCode()) != 1 {
 			t.Errorf("Wanted 1 template in driver B; got %d", len(driverB.GetTemplateCode()))
@@ -1240,3 +1250,261 @@ func TestDriverForTemplate(t *testing.T) {
 		})
 	}
 }
+
+// TestClient_AddConstraint_DriverError verifies that Constraints the driver
+// fails to add are not matched by reviews, and that adding them again reaches
+// the driver.
+func TestClient_AddConstraint_DriverError(t *testing.T) {
+	ctx := context.Background()
+
+	driver := fake.New("driverA")
+	client, err := NewClient(
+		Targets(&handlertest.Handler{Name: ptr.To[string]("h1")}),
+		Driver(driver),
+		EnforcementPoints("test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := cts.New(cts.OptTargets(
+		cts.TargetCustomEngines(
+			"h1",
+			cts.Code("driverA", (&schema.Source{RejectWith: "MUCH REJECTING"}).ToUnstructured()),
+		),
+	))
+	if _, err := client.AddTemplate(ctx, template); err != nil {
+		t.Fatal(err)
+	}
+
+	constraint := cts.MakeConstraint(t, "Fakes", "constraint1")
+
+	driver.SetErrOnAddConstraint(true)
+	if _, err := client.AddConstraint(ctx, constraint.DeepCopy()); err == nil {
+		t.Fatal("expected err; got nil")
+	}
+
+	resp, err := client.Review(ctx, handlertest.NewReview("", "foo", "bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results()) != 0 {
+		t.Errorf("got results %v for a Constraint the driver does not have", resp.Results())
+	}
+	if _, err := client.GetConstraint(constraint); !errors.Is(err, ErrMissingConstraint) {
+		t.Errorf("got error %v, want %v", err, ErrMissingConstraint)
+	}
+
+	driver.SetErrOnAddConstraint(false)
+	if _, err := client.AddConstraint(ctx, constraint.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(driver.GetConstraintsForTemplate(template)) != 1 {
+		t.Errorf("got driver constraints %v, want %q", driver.GetConstraintsForTemplate(template), constraint.GetName())
+	}
+
+	resp, err = client.Review(ctx, handlertest.NewReview("", "foo", "bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results()) != 1 {
+		t.Errorf("got results %v, want 1 result", resp.Results())
+	}
+}
+
+// admissionReview is a review of an admission request.
+type admissionReview struct {
+	handlertest.Review
+	request *admissionv1.AdmissionRequest
+}
+
+func (r *admissionReview) GetAdmissionRequest() *admissionv1.AdmissionRequest {
+	return r.request
+}
+
+func TestSkippedOperations(t *testing.T) {
+	ctx := context.Background()
+
+	// Only the Rego driver supports Templates with multiple targets.
+	driver, err := rego.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(
+		Targets(&handlertest.Handler{Name: ptr.To[string]("h1")}, &handlertest.Handler{Name: ptr.To[string]("h2")}),
+		Driver(driver),
+		EnforcementPoints(apiconstraints.WebhookEnforcementPoint),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := func(name string, operations ...admissionregistrationv1.OperationType) templates.Target {
+		out := cts.TargetWithVersion(name, cts.ModuleDeny, ast.RegoV1)
+		out.Operations = operations
+		return out
+	}
+
+	// multi only applies to creation through h1, but to any operation through h2.
+	multi := cts.New(cts.OptName("multi"), cts.OptCRDNames("Multi"),
+		cts.OptTargets(target("h1", admissionregistrationv1.Create), target("h2")))
+	// single only applies to creation, and only has h1.
+	single := cts.New(cts.OptName("single"), cts.OptCRDNames("Single"),
+		cts.OptTargets(target("h1", admissionregistrationv1.Create)))
+	for _, templ := range []*templates.ConstraintTemplate{multi, single} {
+		if _, err := client.AddTemplate(ctx, templ); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	review := func(operation admissionv1.Operation) *admissionReview {
+		return &admissionReview{request: &admissionv1.AdmissionRequest{Operation: operation}}
+	}
+
+	tests := []struct {
+		name          string
+		point         string
+		targetReviews map[string]interface{}
+		want          map[string]string
+	}{
+		{
+			name:          "allowed operation",
+			point:         apiconstraints.WebhookEnforcementPoint,
+			targetReviews: map[string]interface{}{"h1": review(admissionv1.Create), "h2": review(admissionv1.Create)},
+			want:          map[string]string{},
+		},
+		{
+			// multi is skipped for h2 too, although it allows updates through h2.
+			name:          "operation rejected by one target",
+			point:         apiconstraints.WebhookEnforcementPoint,
+			targetReviews: map[string]interface{}{"h1": review(admissionv1.Update), "h2": review(admissionv1.Update)},
+			want:          map[string]string{"multi": "UPDATE", "single": "UPDATE"},
+		},
+		{
+			// single has no entry for h2, so its operation is checked against
+			// single's own target.
+			name:          "operation of a target the template does not have",
+			point:         apiconstraints.WebhookEnforcementPoint,
+			targetReviews: map[string]interface{}{"h1": handlertest.NewReview("", "foo", "bar"), "h2": review(admissionv1.Update)},
+			want:          map[string]string{"single": "UPDATE"},
+		},
+		{
+			name:          "not a webhook",
+			point:         "audit",
+			targetReviews: map[string]interface{}{"h1": review(admissionv1.Update), "h2": review(admissionv1.Update)},
+			want:          map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skipped := skippedOperations(client.getState(), &reviews.ReviewCfg{EnforcementPoint: tt.point}, tt.targetReviews)
+
+			got := make(map[string]string)
+			for template, operation := range skipped {
+				got[template.getTemplate().GetName()] = operation
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// TestTemplateLocks tests that each Template's lock serializes its holders,
+// and that locks are removed once they are released, including those of
+// Templates which were removed while other goroutines waited on them.
+func TestTemplateLocks(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := NewClient(
+		Targets(&handlertest.Handler{Name: ptr.To[string]("h1")}),
+		Driver(fake.New("driverA")),
+		EnforcementPoints("test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := cts.New(cts.OptTargets(
+		cts.TargetCustomEngines(
+			"h1",
+			cts.Code("driverA", (&schema.Source{RejectWith: "MUCH REJECTING"}).ToUnstructured()),
+		),
+	))
+
+	// counts are incremented without atomics, so the race detector reports
+	// any two holders of a Template's lock overlapping.
+	counts := make([]int, 4)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("template-%d", j%len(counts))
+				unlock := client.templateLocks.lock(name)
+				counts[j%len(counts)]++
+				unlock()
+			}
+
+			if _, err := client.AddTemplate(ctx, template.DeepCopy()); err != nil {
+				t.Error(err)
+			}
+			if _, err := client.RemoveTemplate(ctx, template.DeepCopy()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, count := range counts {
+		if count != 250 {
+			t.Errorf("got count %d for template-%d, want 250", count, i)
+		}
+	}
+
+	if len(client.templateLocks.locks) != 0 {
+		t.Errorf("got locks for %d Templates after releasing all of them, want none", len(client.templateLocks.locks))
+	}
+}
+
+// TestReviewLimiter_Parallelize verifies that nested calls to parallelize
+// share a single limit, rather than each level being limited separately.
+func TestReviewLimiter_Parallelize(t *testing.T) {
+	for _, concurrency := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			c := &Client{reviewConcurrency: concurrency}
+			limit := c.newReviewLimiter()
+
+			var calls, running, maxRunning atomic.Int64
+			limit.parallelize(4, func(int) {
+				limit.parallelize(4, func(int) {
+					n := running.Add(1)
+					for {
+						m := maxRunning.Load()
+						if n <= m || maxRunning.CompareAndSwap(m, n) {
+							break
+						}
+					}
+
+					time.Sleep(time.Millisecond)
+
+					running.Add(-1)
+					calls.Add(1)
+				})
+			})
+
+			if got := calls.Load(); got != 16 {
+				t.Errorf("got %d calls, want 16", got)
+			}
+			if got := maxRunning.Load(); got > int64(concurrency) {
+				t.Errorf("got %d concurrent calls, want at most %d", got, concurrency)
+			}
+		})
+	}
+}
@@ -2,11 +2,13 @@ package client
 
 import (
 	"fmt"
+	"io"
 	"regexp"
 	"sort"
 
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"k8s.io/utils/clock"
 )
 
 // Opt is a functional option for configuring a Client.
@@ -82,3 +84,150 @@ func EnforcementPoints(eps ...string) Opt {
 		return nil
 	}
 }
+
+// ReviewConcurrency sets the maximum number of targets and drivers that a
+// single call to Review or ReviewBatch evaluates at once, counting every driver
+// of every target. Drivers are threadsafe, so this allows slow targets or
+// engines to be evaluated side by side. Results are identical to those of
+// sequential evaluation.
+//
+// Defaults to 1, meaning Review evaluates targets and drivers sequentially.
+func ReviewConcurrency(n int) Opt {
+	return func(client *Client) error {
+		if n < 1 {
+			return fmt.Errorf("%w: review concurrency must be at least 1, got %d",
+				ErrCreatingClient, n)
+		}
+		client.reviewConcurrency = n
+		return nil
+	}
+}
+
+// AtomicData makes AddData and RemoveData all-or-nothing across targets. Changes
+// to the "Rego" driver's storage are staged for every target and committed only
+// if all targets succeed, and handler Caches are rolled back on failure.
+//
+// Changes are not isolated from concurrent Reviews. Targets are committed one
+// after another, so a Review may see new data for some targets and old data for
+// others, and may see changes which are then rolled back.
+//
+// Requires the "Rego" driver, if added, to implement drivers.DataTransactor,
+// and the Cache of every target to implement handler.CacheReader so replaced
+// objects can be restored.
+func AtomicData(atomic bool) Opt {
+	return func(client *Client) error {
+		client.atomicData = atomic
+		return nil
+	}
+}
+
+// RestoreFrom restores the Templates, Constraints, Exemptions, and cached data
+// in a snapshot written by Client.Snapshot once all other Opts have been
+// applied.
+// Targets and Drivers are not part of snapshots, so the Client must be given
+// Targets and Drivers able to handle everything in the snapshot.
+//
+// Snapshots hold Templates as source rather than compiled, so restoring costs
+// as much as adding everything in the snapshot: each Template is compiled, and
+// each Constraint is partially evaluated if the driver is configured to do so.
+// Restoring saves fetching Templates, Constraints, and data from their source,
+// not compiling them.
+//
+// The snapshot's enforcement points are used unless EnforcementPoints is also
+// specified.
+func RestoreFrom(r io.Reader) Opt {
+	return func(client *Client) error {
+		client.restoreFrom = r
+		return nil
+	}
+}
+
+// DecisionCache caches the Responses of up to size Reviews, so reviewing an
+// object identical to a recently reviewed one returns the earlier Responses
+// without evaluating Constraints again. Cached Responses carry a review-scoped
+// Stat named CachedStatName. Adding or removing Templates, Constraints, or data
+// invalidates every cached decision.
+//
+// Reviews with tracing enabled, reviews handled by targets which do not
+// implement handler.ReviewKeyer, reviews which drivers traced or failed to
+// evaluate, reviews which queried non-idempotent external data, and reviews
+// matching Constraints with active windows are never cached. ReviewBatch does
+// not use the cache.
+//
+// Disabled by default.
+func DecisionCache(size int) Opt {
+	return func(client *Client) error {
+		if size < 1 {
+			return fmt.Errorf("%w: decision cache size must be at least 1, got %d",
+				ErrCreatingClient, size)
+		}
+		client.decisions = newDecisionCache(size)
+		return nil
+	}
+}
+
+// ShadowResults sets the function Review calls with the outcome of evaluating
+// each shadow Template added with AddShadowTemplate. Shadow Templates are only
+// evaluated if this is set.
+//
+// fn is called before Review returns, and may be called by concurrent Reviews
+// at once, so it should be fast and threadsafe. Reviews returned from the
+// decision cache and ReviewBatch do not evaluate shadow Templates.
+func ShadowResults(fn func(ShadowResult)) Opt {
+	return func(client *Client) error {
+		client.shadowResults = fn
+		return nil
+	}
+}
+
+// Clock sets the clock Client uses to decide whether Constraints are within the
+// window set by their spec.activeFrom and spec.activeUntil. Defaults to the
+// system clock.
+func Clock(c clock.PassiveClock) Opt {
+	return func(client *Client) error {
+		if c == nil {
+			return fmt.Errorf("%w: clock must not be nil", ErrCreatingClient)
+		}
+		client.clock = c
+		return nil
+	}
+}
+
+// OnSchemaChange sets what AddTemplate does when an updated Template's schema
+// does not accept some of the Template's existing Constraints.
+//
+// With RejectSchemaChange or QuarantineInvalidConstraints, AddTemplate checks
+// every existing Constraint of updated Templates against the new schema,
+// reports the invalid ones in the InvalidConstraints of the returned Responses,
+// and applies the new schema's defaults to the rest.
+//
+// Defaults to IgnoreSchemaChange, which leaves existing Constraints unchecked
+// and unchanged.
+func OnSchemaChange(policy SchemaChangePolicy) Opt {
+	return func(client *Client) error {
+		switch policy {
+		case IgnoreSchemaChange, RejectSchemaChange, QuarantineInvalidConstraints:
+			client.schemaChangePolicy = policy
+			return nil
+		default:
+			return fmt.Errorf("%w: unknown schema change policy %q", ErrCreatingClient, policy)
+		}
+	}
+}
+
+// SubscriberQueueSize sets the most Events which may wait to be delivered to
+// each function passed to Subscribe. Events published while a subscriber's
+// queue is full are dropped, and the subscriber receives an EventsDropped Event
+// counting them once it has caught up.
+//
+// Defaults to 1024.
+func SubscriberQueueSize(size int) Opt {
+	return func(client *Client) error {
+		if size < 1 {
+			return fmt.Errorf("%w: subscriber queue size must be at least 1, got %d",
+				ErrCreatingClient, size)
+		}
+		client.subscriberQueueSize = size
+		return nil
+	}
+}
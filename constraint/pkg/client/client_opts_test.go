@@ -35,3 +35,54 @@ func TestNoDuplicates(t *testing.T) {
 		t.Errorf("wanted duplicate driver error, got %v", err)
 	}
 }
+
+func TestReviewConcurrency(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		_, err := NewClient(Targets(&handlertest.Handler{Name: ptr.To[string]("foo")}), ReviewConcurrency(n), EnforcementPoints("test"))
+		if !errors.Is(err, ErrCreatingClient) {
+			t.Errorf("ReviewConcurrency(%d): got error %v, want %v", n, err, ErrCreatingClient)
+		}
+	}
+
+	c, err := NewClient(Targets(&handlertest.Handler{Name: ptr.To[string]("foo")}), ReviewConcurrency(4), EnforcementPoints("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.reviewConcurrency != 4 {
+		t.Errorf("got reviewConcurrency %d, want 4", c.reviewConcurrency)
+	}
+}
+
+func TestDecisionCache(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		_, err := NewClient(Targets(&handlertest.Handler{Name: ptr.To[string]("foo")}), DecisionCache(size), EnforcementPoints("test"))
+		if !errors.Is(err, ErrCreatingClient) {
+			t.Errorf("DecisionCache(%d): got error %v, want %v", size, err, ErrCreatingClient)
+		}
+	}
+
+	c, err := NewClient(Targets(&handlertest.Handler{Name: ptr.To[string]("foo")}), DecisionCache(8), EnforcementPoints("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.decisions == nil || c.decisions.size != 8 {
+		t.Errorf("got decision cache %+v, want size 8", c.decisions)
+	}
+}
+
+func TestSubscriberQueueSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		_, err := NewClient(Targets(&handlertest.Handler{Name: ptr.To[string]("foo")}), SubscriberQueueSize(size), EnforcementPoints("test"))
+		if !errors.Is(err, ErrCreatingClient) {
+			t.Errorf("SubscriberQueueSize(%d): got error %v, want %v", size, err, ErrCreatingClient)
+		}
+	}
+
+	c, err := NewClient(Targets(&handlertest.Handler{Name: ptr.To[string]("foo")}), EnforcementPoints("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.subscriberQueueSize != defaultSubscriberQueueSize {
+		t.Errorf("got subscriberQueueSize %d, want %d", c.subscriberQueueSize, defaultSubscriberQueueSize)
+	}
+}
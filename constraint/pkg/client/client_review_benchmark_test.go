@@ -8,6 +8,7 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest/cts"
 
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/reviews"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler/handlertest"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -121,3 +122,46 @@ func BenchmarkClient_Review(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkClient_Review_PerKind measures the per-kind overhead of Review by
+// matching one Constraint of each of many Templates, so the cost of setting up
+// each kind's query dominates the cost of evaluating it.
+func BenchmarkClient_Review_PerKind(b *testing.B) {
+	review := handlertest.Review{
+		Object: handlertest.Object{
+			Name: "has-foo",
+			Data: "foo",
+		},
+	}
+
+	for _, templates := range []int{1, 10, 100} {
+		for _, tracing := range []bool{false, true} {
+			c := clienttest.New(b)
+
+			ctx := context.Background()
+			for ts := 0; ts < templates; ts++ {
+				_, err := c.AddTemplate(ctx, clienttest.TemplateCheckDataNumbered(ts))
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				constraint := cts.MakeConstraint(b, clienttest.KindCheckDataNumbered(ts), "wantbar", cts.WantData("bar"))
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.Run(fmt.Sprintf("%d Templates tracing %t", templates, tracing), func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, err := c.Review(ctx, review, reviews.Tracing(tracing))
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
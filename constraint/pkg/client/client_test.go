@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -23,11 +26,14 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler/handlertest"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/utils/ptr"
 )
 
@@ -799,6 +805,170 @@ func TestClient_GetTemplate(t *testing.T) {
 	}
 }
 
+func TestClient_AddTemplate_SchemaChange(t *testing.T) {
+	ctx := context.Background()
+
+	v1 := cts.New(cts.OptCRDSchema(cts.PropMap{
+		"foo": cts.PropTyped("string"),
+		"bar": cts.PropTyped("string"),
+	}))
+
+	defaultBaz := apiextensions.JSON("z")
+	baz := cts.PropTyped("string")
+	baz.Default = &defaultBaz
+	v2 := cts.New(cts.OptCRDSchema(cts.PropMap{
+		"foo": cts.PropTyped("string"),
+		"bar": cts.PropTyped("integer"),
+		"baz": baz,
+	}))
+
+	// v3 accepts "b" again.
+	v3 := cts.New(cts.OptCRDSchema(cts.PropMap{
+		"foo": cts.PropTyped("string"),
+		"bar": cts.PropTyped("string"),
+		"baz": baz,
+	}))
+
+	newClient := func(t *testing.T, opts ...client.Opt) *client.Client {
+		t.Helper()
+
+		c := clienttest.New(t, opts...)
+		_, err := c.AddTemplate(ctx, v1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, constraint := range []*unstructured.Unstructured{
+			cts.MakeConstraint(t, cts.MockTemplate, "a", cts.Set("x", "spec", "parameters", "foo")),
+			cts.MakeConstraint(t, cts.MockTemplate, "b", cts.Set("y", "spec", "parameters", "bar")),
+		} {
+			_, err = c.AddConstraint(ctx, constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return c
+	}
+
+	invalidNames := func(resp *types.Responses) []string {
+		var names []string
+		for _, invalid := range resp.InvalidConstraints {
+			names = append(names, invalid.Constraint.GetName())
+		}
+		return names
+	}
+
+	reviewed := func(t *testing.T, c *client.Client) []string {
+		t.Helper()
+
+		responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, result := range responses.Results() {
+			names = append(names, result.Constraint.GetName())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("ignore by default", func(t *testing.T) {
+		c := newClient(t)
+
+		resp, err := c.AddTemplate(ctx, v2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.InvalidConstraints) != 0 {
+			t.Errorf("got invalid constraints %v, want none", invalidNames(resp))
+		}
+
+		if diff := cmp.Diff([]string{"a", "b"}, reviewed(t, c)); diff != "" {
+			t.Error(diff)
+		}
+
+		// Existing Constraints are not given the new default.
+		a, err := c.GetConstraint(cts.MakeConstraint(t, cts.MockTemplate, "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, found, _ := unstructured.NestedString(a.Object, "spec", "parameters", "baz")
+		if found {
+			t.Error("got spec.parameters.baz set, want unset")
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		c := newClient(t, client.OnSchemaChange(client.RejectSchemaChange))
+
+		resp, err := c.AddTemplate(ctx, v2)
+		if !errors.Is(err, client.ErrSchemaChange) {
+			t.Fatalf("got AddTemplate() error = %v, want %v", err, client.ErrSchemaChange)
+		}
+		if diff := cmp.Diff([]string{"b"}, invalidNames(resp)); diff != "" {
+			t.Error(diff)
+		}
+
+		got, err := c.GetTemplate(v1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(v1, got); diff != "" {
+			t.Errorf("template changed after rejected update: %s", diff)
+		}
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		c := newClient(t, client.OnSchemaChange(client.QuarantineInvalidConstraints))
+
+		resp, err := c.AddTemplate(ctx, v2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"b"}, invalidNames(resp)); diff != "" {
+			t.Error(diff)
+		}
+
+		if diff := cmp.Diff([]string{"a"}, reviewed(t, c)); diff != "" {
+			t.Error(diff)
+		}
+
+		// The new default is applied to existing valid Constraints.
+		a, err := c.GetConstraint(cts.MakeConstraint(t, cts.MockTemplate, "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotBaz, _, _ := unstructured.NestedString(a.Object, "spec", "parameters", "baz")
+		if gotBaz != "z" {
+			t.Errorf("got spec.parameters.baz = %q, want %q", gotBaz, "z")
+		}
+
+		infos, err := c.ListConstraints(cts.MockTemplate, client.Selector{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, info := range infos {
+			if quarantined := info.QuarantineReason != ""; quarantined != (info.Constraint.GetName() == "b") {
+				t.Errorf("got QuarantineReason %q for constraint %q", info.QuarantineReason, info.Constraint.GetName())
+			}
+		}
+
+		resp, err = c.AddTemplate(ctx, v3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.InvalidConstraints) != 0 {
+			t.Errorf("got invalid constraints %v, want none", invalidNames(resp))
+		}
+
+		if diff := cmp.Diff([]string{"a", "b"}, reviewed(t, c)); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
 func TestClient_GetTemplate_ByNameOnly(t *testing.T) {
 	tcs := []struct {
 		name         string
@@ -869,6 +1039,125 @@ func TestClient_GetTemplate_ByNameOnly(t *testing.T) {
 	}
 }
 
+func TestClient_ListTemplates_ListConstraints(t *testing.T) {
+	ctx := context.Background()
+	c := clienttest.New(t)
+
+	deny := clienttest.TemplateDeny()
+	deny.SetLabels(map[string]string{"team": "a"})
+	for _, templ := range []*templates.ConstraintTemplate{clienttest.TemplateAllow(), deny} {
+		_, err := c.AddTemplate(ctx, templ)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prod := cts.MakeConstraint(t, clienttest.KindDeny, "prod", cts.EnforcementAction("warn"))
+	prod.SetLabels(map[string]string{"env": "prod"})
+	for _, constraint := range []*unstructured.Unstructured{
+		prod,
+		cts.MakeConstraint(t, clienttest.KindDeny, "dev"),
+		cts.MakeConstraint(t, clienttest.KindAllow, "allow"),
+	} {
+		_, err := c.AddConstraint(ctx, constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templateNames := func(infos []*client.TemplateInfo) []string {
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Template.GetName())
+		}
+		return names
+	}
+
+	constraintNames := func(infos []*client.ConstraintInfo) []string {
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Constraint.GetName())
+		}
+		return names
+	}
+
+	teamA, err := labels.Parse("team=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envProd, err := labels.Parse("env=prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		selector client.Selector
+		want     []string
+	}{
+		{name: "everything", want: []string{"allow", "deny"}},
+		{name: "labels", selector: client.Selector{Labels: teamA}, want: []string{"deny"}},
+		{name: "enforcement action", selector: client.Selector{EnforcementAction: "warn"}, want: []string{"deny"}},
+		{name: "target", selector: client.Selector{Target: handlertest.TargetName}, want: []string{"allow", "deny"}},
+		{name: "unknown target", selector: client.Selector{Target: "other"}},
+		{name: "driver", selector: client.Selector{Driver: schema.Name}, want: []string{"allow", "deny"}},
+		{name: "needs replay", selector: client.Selector{NeedsConstraintReplay: ptr.To(true)}},
+	} {
+		t.Run("templates "+tc.name, func(t *testing.T) {
+			got := c.ListTemplates(tc.selector)
+			if diff := cmp.Diff(tc.want, templateNames(got)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		kind     string
+		selector client.Selector
+		want     []string
+	}{
+		{name: "everything", want: []string{"allow", "dev", "prod"}},
+		{name: "kind", kind: clienttest.KindDeny, want: []string{"dev", "prod"}},
+		{name: "labels", selector: client.Selector{Labels: envProd}, want: []string{"prod"}},
+		{name: "template labels do not apply", selector: client.Selector{Labels: teamA}},
+		{name: "enforcement action", selector: client.Selector{EnforcementAction: "deny"}, want: []string{"allow", "dev"}},
+		{name: "needs no replay", selector: client.Selector{NeedsConstraintReplay: ptr.To(false)}, want: []string{"allow", "dev", "prod"}},
+	} {
+		t.Run("constraints "+tc.name, func(t *testing.T) {
+			got, err := c.ListConstraints(tc.kind, tc.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, constraintNames(got)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	infos := c.ListTemplates(client.Selector{Labels: teamA})
+	want := &client.TemplateInfo{
+		Template:    deny,
+		Driver:      schema.Name,
+		Targets:     []string{handlertest.TargetName},
+		Constraints: 2,
+	}
+	if diff := cmp.Diff([]*client.TemplateInfo{want}, infos); diff != "" {
+		t.Error(diff)
+	}
+
+	// Returned objects are copies.
+	infos[0].Template.SetLabels(nil)
+	if got := c.ListTemplates(client.Selector{Labels: teamA}); len(got) != 1 {
+		t.Errorf("got %d templates after modifying a listed template, want 1", len(got))
+	}
+
+	_, err = c.ListConstraints("Unknown", client.Selector{})
+	if !errors.Is(err, client.ErrMissingConstraintTemplate) {
+		t.Errorf("got ListConstraints() error = %v, want %v", err, client.ErrMissingConstraintTemplate)
+	}
+}
+
 func TestClient_RemoveTemplate_CascadingDelete(t *testing.T) {
 	h := &handlertest.Handler{}
 
@@ -2273,3 +2562,394 @@ func TestClient_RemoveData_NilCache(t *testing.T) {
 		t.Fatalf("got RemoveData() error = %v, want nil", err)
 	}
 }
+
+var (
+	errCacheAdd    = errors.New("cache add failed")
+	errProcessData = errors.New("process data failed")
+)
+
+// failingCache is a handlertest.Cache which rejects all Adds once fail is set.
+type failingCache struct {
+	handlertest.Cache
+	fail bool
+}
+
+func (c *failingCache) Add(key []string, object interface{}) error {
+	if c.fail {
+		return errCacheAdd
+	}
+	return c.Cache.Add(key, object)
+}
+
+type failingCacheHandler struct {
+	*handlertest.Handler
+	cache *failingCache
+}
+
+func (h *failingCacheHandler) GetCache() handler.Cache {
+	return h.cache
+}
+
+// readInventory returns the object stored for target at key, or nil if there is none.
+func readInventory(ctx context.Context, t *testing.T, store storage.Store, key []string) interface{} {
+	t.Helper()
+
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Abort(ctx, txn)
+
+	got, err := store.Read(ctx, txn, append(storage.Path{"inventory"}, key...))
+	if storage.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	return got
+}
+
+func TestClient_AddData_Atomic(t *testing.T) {
+	before := &handlertest.Object{Namespace: "foo", Data: "qux"}
+	add := &handlertest.Object{Namespace: "foo", Data: "bar"}
+
+	tcs := []struct {
+		name             string
+		processDataError error
+		failCache        bool
+		want             *handlertest.Object
+		wantHandled      map[string]bool
+		wantErr          error
+	}{
+		{
+			name:        "success",
+			want:        add,
+			wantHandled: map[string]bool{"a": true, "b": true},
+		},
+		{
+			name:             "process error writes nothing",
+			processDataError: errProcessData,
+			want:             before,
+			wantErr:          &clienterrors.ErrorMap{"b": errProcessData},
+		},
+		{
+			name:      "cache error rolls back",
+			failCache: true,
+			want:      before,
+			wantErr:   &clienterrors.ErrorMap{"b": errCacheAdd},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			stores := map[string]storage.Store{"a": inmem.New(), "b": inmem.New()}
+			d, err := rego.New(rego.Storage(stores))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cacheA := &handlertest.Cache{}
+			hA := &handlertest.Handler{Name: ptr.To[string]("a"), Cache: cacheA}
+			hB := &failingCacheHandler{
+				Handler: &handlertest.Handler{Name: ptr.To[string]("b")},
+				cache:   &failingCache{},
+			}
+
+			c, err := client.NewClient(client.Targets(hA, hB), client.Driver(d),
+				client.EnforcementPoints("test"), client.AtomicData(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.AddData(ctx, before)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hB.ProcessDataError = tc.processDataError
+			hB.cache.fail = tc.failCache
+
+			resp, err := c.AddData(ctx, add)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got AddData() error = %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.wantHandled, resp.Handled, cmpopts.EquateEmpty()); diff != "" {
+				t.Error(diff)
+			}
+
+			gotCache, err := cacheA.Get(add.Key())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, gotCache); diff != "" {
+				t.Errorf("unexpected object in Cache (-want +got):\n%s", diff)
+			}
+
+			wantStored := map[string]interface{}{"name": "", "namespace": "foo", "data": tc.want.Data}
+			for target, store := range stores {
+				gotStored := readInventory(ctx, t, store, add.Key())
+				if diff := cmp.Diff(wantStored, gotStored); diff != "" {
+					t.Errorf("unexpected object in storage for target %q (-want +got):\n%s", target, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_RemoveData_Atomic(t *testing.T) {
+	obj := &handlertest.Object{Namespace: "foo"}
+
+	tcs := []struct {
+		name             string
+		processDataError error
+		wantRemoved      bool
+		wantErr          error
+	}{
+		{
+			name:        "success",
+			wantRemoved: true,
+		},
+		{
+			name:             "process error removes nothing",
+			processDataError: errProcessData,
+			wantErr:          &clienterrors.ErrorMap{"b": errProcessData},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			stores := map[string]storage.Store{"a": inmem.New(), "b": inmem.New()}
+			d, err := rego.New(rego.Storage(stores))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cacheA := &handlertest.Cache{}
+			hA := &handlertest.Handler{Name: ptr.To[string]("a"), Cache: cacheA}
+			hB := &handlertest.Handler{Name: ptr.To[string]("b"), Cache: &handlertest.Cache{}}
+
+			c, err := client.NewClient(client.Targets(hA, hB), client.Driver(d),
+				client.EnforcementPoints("test"), client.AtomicData(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.AddData(ctx, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hB.ProcessDataError = tc.processDataError
+
+			_, err = c.RemoveData(ctx, obj)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got RemoveData() error = %v, want %v", err, tc.wantErr)
+			}
+
+			gotCache, err := cacheA.Get(obj.Key())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed := gotCache == nil; removed != tc.wantRemoved {
+				t.Errorf("got removed from Cache = %t, want %t", removed, tc.wantRemoved)
+			}
+
+			for target, store := range stores {
+				gotStored := readInventory(ctx, t, store, obj.Key())
+				if removed := gotStored == nil; removed != tc.wantRemoved {
+					t.Errorf("got removed from storage for target %q = %t, want %t", target, removed, tc.wantRemoved)
+				}
+			}
+		})
+	}
+}
+
+// TestClient_Data_NoReferentialDriver tests that AddData and RemoveData report
+// the same outcome with and without AtomicData when the Client has no Rego
+// driver.
+func TestClient_Data_NoReferentialDriver(t *testing.T) {
+	obj := &handlertest.Object{Namespace: "foo"}
+	wantErr := &clienterrors.ErrorMap{handlertest.TargetName: client.ErrNoReferentialDriver}
+	wantHandled := map[string]bool{handlertest.TargetName: true}
+
+	for _, atomic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("atomic %t", atomic), func(t *testing.T) {
+			ctx := context.Background()
+
+			c, err := client.NewClient(client.Targets(&handlertest.Handler{Cache: &handlertest.Cache{}}),
+				client.Driver(fake.New("fake")), client.EnforcementPoints("test"), client.AtomicData(atomic))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := c.AddData(ctx, obj)
+			if !errors.Is(err, wantErr) {
+				t.Errorf("got AddData() error = %v, want %v", err, wantErr)
+			}
+			if diff := cmp.Diff(wantHandled, resp.Handled); diff != "" {
+				t.Errorf("AddData(): %s", diff)
+			}
+
+			resp, err = c.RemoveData(ctx, obj)
+			if !errors.Is(err, wantErr) {
+				t.Errorf("got RemoveData() error = %v, want %v", err, wantErr)
+			}
+			if diff := cmp.Diff(wantHandled, resp.Handled); diff != "" {
+				t.Errorf("RemoveData(): %s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_AtomicData_RequiresDataTransactor(t *testing.T) {
+	_, err := client.NewClient(
+		client.Targets(&handlertest.Handler{}),
+		client.Driver(fake.New(schema.Name)),
+		client.EnforcementPoints("test"),
+		client.AtomicData(true),
+	)
+	if !errors.Is(err, client.ErrCreatingClient) {
+		t.Fatalf("got NewClient() error = %v, want %v", err, client.ErrCreatingClient)
+	}
+}
+
+// writeOnlyCache is a Cache which can't return the objects it holds.
+type writeOnlyCache struct{}
+
+func (writeOnlyCache) Add([]string, interface{}) error {
+	return nil
+}
+
+func (writeOnlyCache) Remove([]string) {}
+
+type writeOnlyCacheHandler struct {
+	*handlertest.Handler
+}
+
+func (h *writeOnlyCacheHandler) GetCache() handler.Cache {
+	return writeOnlyCache{}
+}
+
+func TestClient_AtomicData_RequiresCacheReader(t *testing.T) {
+	for _, atomic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("atomic %t", atomic), func(t *testing.T) {
+			d, err := rego.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.NewClient(
+				client.Targets(&writeOnlyCacheHandler{Handler: &handlertest.Handler{}}),
+				client.Driver(d),
+				client.EnforcementPoints("test"),
+				client.AtomicData(atomic),
+			)
+
+			var wantErr error
+			if atomic {
+				wantErr = client.ErrCreatingClient
+			}
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("got NewClient() error = %v, want %v", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_AddDataBatch(t *testing.T) {
+	objs := []interface{}{
+		&handlertest.Object{Namespace: "foo"},
+		"not an object",
+		&handlertest.Object{},
+		&handlertest.Object{Namespace: "bar", Name: "qux"},
+	}
+
+	newClient := func(t *testing.T, stores map[string]storage.Store, opts ...client.Opt) *client.Client {
+		d, err := rego.New(rego.Storage(stores))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts = append([]client.Opt{
+			client.Targets(
+				&handlertest.Handler{Name: ptr.To[string]("h1"), Cache: &handlertest.Cache{}},
+				&handlertest.Handler{
+					Name:         ptr.To[string]("h2"),
+					Cache:        &handlertest.Cache{},
+					ShouldHandle: func(o *handlertest.Object) bool { return o.Name == "" },
+				},
+			),
+			client.Driver(d),
+			client.EnforcementPoints("test"),
+		}, opts...)
+
+		c, err := client.NewClient(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tcs := []struct {
+		name string
+		opts []client.Opt
+	}{
+		{name: "default"},
+		{name: "atomic", opts: []client.Opt{client.AtomicData(true)}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			wantStores := map[string]storage.Store{"h1": inmem.New(), "h2": inmem.New()}
+			want := newClient(t, wantStores, tc.opts...)
+			wantErrs := make(clienterrors.ErrorMap)
+			var wantResponses []*types.Responses
+			for i, obj := range objs {
+				resp, err := want.AddData(ctx, obj)
+				if err != nil {
+					wantErrs.Add(strconv.Itoa(i), err)
+				}
+				wantResponses = append(wantResponses, resp)
+			}
+
+			gotStores := map[string]storage.Store{"h1": inmem.New(), "h2": inmem.New()}
+			got := newClient(t, gotStores, tc.opts...)
+			gotResponses, err := got.AddDataBatch(ctx, objs)
+
+			var gotErrs *clienterrors.ErrorMap
+			if !errors.As(err, &gotErrs) {
+				t.Fatalf("got AddDataBatch() error = %v, want ErrorMap", err)
+			}
+			if gotErrs.Error() != wantErrs.Error() {
+				t.Errorf("got AddDataBatch() error:\n%v\nwant:\n%v", gotErrs, wantErrs)
+			}
+
+			if diff := cmp.Diff(wantResponses, gotResponses, cmpopts.EquateEmpty()); diff != "" {
+				t.Error(diff)
+			}
+
+			for target := range wantStores {
+				for _, obj := range objs {
+					o, ok := obj.(*handlertest.Object)
+					if !ok {
+						continue
+					}
+
+					wantStored := readInventory(ctx, t, wantStores[target], o.Key())
+					gotStored := readInventory(ctx, t, gotStores[target], o.Key())
+					if diff := cmp.Diff(wantStored, gotStored); diff != "" {
+						t.Errorf("unexpected data for target %q at %v (-want +got):\n%s", target, o.Key(), diff)
+					}
+				}
+			}
+		})
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler/handlertest"
 )
 
-func defaults() []client.Opt {
-	d, err := rego.New()
+func defaults(args ...rego.Arg) []client.Opt {
+	d, err := rego.New(args...)
 	if err != nil {
 		panic(err)
 	}
@@ -26,7 +26,14 @@ func defaults() []client.Opt {
 func New(t testing.TB, opts ...client.Opt) *client.Client {
 	t.Helper()
 
-	opts = append(defaults(), opts...)
+	return NewWithRegoArgs(t, nil, opts...)
+}
+
+// NewWithRegoArgs is New, with the local driver constructed with args.
+func NewWithRegoArgs(t testing.TB, args []rego.Arg, opts ...client.Opt) *client.Client {
+	t.Helper()
+
+	opts = append(defaults(args...), opts...)
 
 	c, err := client.NewClient(opts...)
 	if err != nil {
@@ -122,6 +122,15 @@ func MatchNamespace(namespace string) ConstraintArg {
 	}
 }
 
+// Namespace sets the namespace of the Constraint. Only valid for Constraints
+// of namespaced kinds.
+func Namespace(namespace string) ConstraintArg {
+	return func(u *unstructured.Unstructured) error {
+		u.SetNamespace(namespace)
+		return nil
+	}
+}
+
 // WantData sets the Constraint to verify that data of objects under review is
 // set to wantData. Only meaningful for CheckData constraints.
 func WantData(data string) ConstraintArg {
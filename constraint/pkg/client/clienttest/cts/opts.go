@@ -70,6 +70,14 @@ func OptCRDNames(kind string) Opt {
 	}
 }
 
+// OptCRDScope sets the scope of the Constraint kind, either templates.ScopeCluster
+// or templates.ScopeNamespaced.
+func OptCRDScope(scope string) Opt {
+	return func(tmpl *templates.ConstraintTemplate) {
+		tmpl.Spec.CRD.Spec.Scope = scope
+	}
+}
+
 // OptLabels sets labels on the ConstraintTemplate.
 func OptLabels(labels map[string]string) Opt {
 	return func(tmpl *templates.ConstraintTemplate) {
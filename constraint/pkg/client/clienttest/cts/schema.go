@@ -12,6 +12,8 @@ type PropMap map[string]apiextensions.JSONSchemaProps
 func ExpectedSchema(pm PropMap) *apiextensions.JSONSchemaProps {
 	defaultEnforcementAction := apiextensions.JSON("deny")
 	pm["enforcementAction"] = apiextensions.JSONSchemaProps{Type: "string", Default: &defaultEnforcementAction}
+	pm["activeFrom"] = apiextensions.JSONSchemaProps{Type: "string", Format: "date-time"}
+	pm["activeUntil"] = apiextensions.JSONSchemaProps{Type: "string", Format: "date-time"}
 	pm["scopedEnforcementActions"] = apiextensions.JSONSchemaProps{
 		Type:    "array",
 		Default: nil,
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"time"
 
 	apiconstraints "github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
@@ -27,16 +28,59 @@ type constraintClient struct {
 
 	// enforcementActionsForEP stores precompiled enforcement actions for each enforcement point.
 	enforcementActionsForEP map[string][]string
+
+	// activeFrom and activeUntil, if set, bound the window of time in which the
+	// Constraint is enforced.
+	activeFrom  *time.Time
+	activeUntil *time.Time
+
+	// quarantined, if set, is why the Constraint does not conform to the
+	// current schema of its Template. Quarantined Constraints are not in their
+	// driver and never match reviews.
+	quarantined string
 }
 
 func (c *constraintClient) getConstraint() *unstructured.Unstructured {
 	return c.constraint.DeepCopy()
 }
 
-func (c *constraintClient) matches(target string, review interface{}, enforcementPoints ...string) *constraintMatchResult {
+// inactiveReason returns why the Constraint is not active at now, or "" if it
+// is.
+func (c *constraintClient) inactiveReason(now time.Time) string {
+	switch {
+	case c.activeFrom != nil && now.Before(*c.activeFrom):
+		return fmt.Sprintf("constraint is not active until %s", c.activeFrom.Format(time.RFC3339))
+	case c.activeUntil != nil && !now.Before(*c.activeUntil):
+		return fmt.Sprintf("constraint stopped being active at %s", c.activeUntil.Format(time.RFC3339))
+	default:
+		return ""
+	}
+}
+
+// matches runs the Constraint's Matcher for target against review. Returns nil
+// if the Constraint does not apply to the review. If explain is true, also
+// returns an explanation of the decision, or nil if the Constraint has no
+// Matcher for target.
+//
+// Constraints which would apply to the review but are not active at now are
+// returned with inactive set, and are neither evaluated nor autorejected.
+func (c *constraintClient) matches(target string, review interface{}, now time.Time, explain bool, enforcementPoints ...string) (*constraintMatchResult, *types.MatchExplanation) {
 	matcher, found := c.matchers[target]
 	if !found {
-		return nil
+		return nil, nil
+	}
+
+	var explanation *types.MatchExplanation
+	if explain {
+		explanation = &types.MatchExplanation{Constraint: c.constraint}
+	}
+
+	if c.quarantined != "" {
+		if explain {
+			explanation.Decision = types.MatchQuarantined
+			explanation.Reasons = []string{c.quarantined}
+		}
+		return nil, explanation
 	}
 
 	enforcementActions := make(map[string]bool)
@@ -52,7 +96,12 @@ func (c *constraintClient) matches(target string, review interface{}, enforcemen
 
 	// If enforcement action is scoped, constraint does not include enforcement point that needs to be enforced then there is no action to be taken.
 	if len(enforcementActions) == 0 && apiconstraints.IsEnforcementActionScoped(c.enforcementAction) {
-		return nil
+		if explain {
+			explanation.Decision = types.MatchSkippedEnforcementPoint
+			explanation.Reasons = []string{fmt.Sprintf("constraint has no scoped enforcement actions for enforcement points %v",
+				enforcementPoints)}
+		}
+		return nil, explanation
 	}
 
 	// If enforcement action is not scoped or constraint needs to be enforced for matching enforcement point,
@@ -62,7 +111,30 @@ func (c *constraintClient) matches(target string, review interface{}, enforcemen
 	for action := range enforcementActions {
 		actions = append(actions, action)
 	}
-	matches, err := matcher.Match(review)
+
+	var matches bool
+	var err error
+	if explain {
+		matches, explanation.Reasons, err = explainMatch(matcher, review)
+	} else {
+		matches, err = matcher.Match(review)
+	}
+
+	windowed := c.activeFrom != nil || c.activeUntil != nil
+	if err != nil || matches {
+		if reason := c.inactiveReason(now); reason != "" {
+			if explain {
+				explanation.Decision = types.MatchInactive
+				explanation.Reasons = append(explanation.Reasons, reason)
+			}
+
+			return &constraintMatchResult{
+				constraint: c.constraint,
+				inactive:   reason,
+				windowed:   true,
+			}, explanation
+		}
+	}
 
 	// We avoid DeepCopying the Constraint out of the Client cache here, only
 	// DeepCopying when we're about to return the Constraint to the user in
@@ -71,6 +143,11 @@ func (c *constraintClient) matches(target string, review interface{}, enforcemen
 	// is passed.
 	switch {
 	case err != nil:
+		if explain {
+			explanation.Decision = types.MatchError
+			explanation.Error = err.Error()
+		}
+
 		// Fill in the Constraint's enforcementAction since we were unable to
 		// determine if the Constraint matched, so we assume it violated the
 		// Constraint.
@@ -79,17 +156,27 @@ func (c *constraintClient) matches(target string, review interface{}, enforcemen
 			error:                    fmt.Errorf("%w: %v", errors.ErrAutoreject, err),
 			enforcementAction:        c.enforcementAction,
 			scopedEnforcementActions: actions,
-		}
+			windowed:                 windowed,
+		}, explanation
 	case matches:
+		if explain {
+			explanation.Decision = types.MatchEvaluated
+		}
+
 		// Fill in Constraint, so we can pass it to the Driver to run.
 		return &constraintMatchResult{
 			constraint:               c.constraint,
 			enforcementAction:        c.enforcementAction,
 			scopedEnforcementActions: actions,
-		}
+			windowed:                 windowed,
+		}, explanation
 	default:
+		if explain {
+			explanation.Decision = types.MatchNotMatched
+		}
+
 		// No match and no error, so no need to record a result.
-		return nil
+		return nil, explanation
 	}
 }
 
@@ -103,6 +190,12 @@ type constraintMatchResult struct {
 	// error is a problem encountered while attempting to run the Constraint's
 	// Matcher.
 	error error
+	// inactive, if set, is why the Constraint was not active at the time of
+	// the review, so it was not evaluated.
+	inactive string
+	// windowed is true if the Constraint has an active window, so whether it
+	// applies depends on when the review happens.
+	windowed bool
 }
 
 func (r *constraintMatchResult) ToResult() *types.Result {
@@ -0,0 +1,95 @@
+package client
+
+// constraintIndex is an inverted index from the index keys of a target's
+// reviews to the Constraints of a Template whose Matchers may match reviews
+// with those keys. Constraints are identified by constraintKey.
+//
+// Not threadsafe.
+type constraintIndex struct {
+	// byKey maps each index key to the Constraints which may match reviews
+	// with that key.
+	byKey persistentMap[persistentMap[struct{}]]
+
+	// unindexed are the Constraints which may match reviews with any key.
+	unindexed persistentMap[struct{}]
+
+	// keys are the index keys of each Constraint in byKey, so they can be
+	// removed without scanning byKey.
+	keys persistentMap[[]string]
+}
+
+func newConstraintIndex() *constraintIndex {
+	return &constraintIndex{}
+}
+
+// add indexes constraint under keys, replacing any keys it was previously
+// indexed under. If keys is empty, constraint is a candidate for every review.
+func (idx *constraintIndex) add(constraint string, keys []string) {
+	idx.remove(constraint)
+
+	if len(keys) == 0 {
+		idx.unindexed = idx.unindexed.set(constraint, struct{}{})
+		return
+	}
+
+	for _, key := range keys {
+		constraints, _ := idx.byKey.get(key)
+		idx.byKey = idx.byKey.set(key, constraints.set(constraint, struct{}{}))
+	}
+	idx.keys = idx.keys.set(constraint, keys)
+}
+
+// remove deletes constraint from the index. Succeeds if constraint is not
+// indexed.
+func (idx *constraintIndex) remove(constraint string) {
+	idx.unindexed = idx.unindexed.delete(constraint)
+
+	keys, _ := idx.keys.get(constraint)
+	for _, key := range keys {
+		constraints, _ := idx.byKey.get(key)
+		constraints = constraints.delete(constraint)
+		if constraints.len() == 0 {
+			idx.byKey = idx.byKey.delete(key)
+		} else {
+			idx.byKey = idx.byKey.set(key, constraints)
+		}
+	}
+	idx.keys = idx.keys.delete(constraint)
+}
+
+// candidates calls visit once for each Constraint which may match a review
+// with reviewKeys.
+func (idx *constraintIndex) candidates(reviewKeys []string, visit func(constraint string)) {
+	for constraint := range idx.unindexed.all() {
+		visit(constraint)
+	}
+
+	// Avoid tracking visited Constraints in the common case of reviews with a
+	// single key.
+	if len(reviewKeys) == 1 {
+		constraints, _ := idx.byKey.get(reviewKeys[0])
+		for constraint := range constraints.all() {
+			visit(constraint)
+		}
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range reviewKeys {
+		constraints, _ := idx.byKey.get(key)
+		for constraint := range constraints.all() {
+			if seen[constraint] {
+				continue
+			}
+			seen[constraint] = true
+			visit(constraint)
+		}
+	}
+}
+
+// clone returns a copy of idx which may be modified without affecting idx. The
+// copy shares the structure of idx, so cloning takes constant time.
+func (idx *constraintIndex) clone() *constraintIndex {
+	out := *idx
+	return &out
+}
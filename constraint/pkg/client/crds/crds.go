@@ -8,6 +8,7 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1alpha1"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -25,6 +26,11 @@ func init() {
 
 // CreateCRD takes a template and a schema and converts it to a CRD.
 func CreateCRD(templ *templates.ConstraintTemplate, schema *apiextensions.JSONSchemaProps) (*apiextensions.CustomResourceDefinition, error) {
+	scope, err := crdScope(templ)
+	if err != nil {
+		return nil, err
+	}
+
 	crd := &apiextensions.CustomResourceDefinition{
 		Spec: apiextensions.CustomResourceDefinitionSpec{
 			PreserveUnknownFields: ptr.To[bool](false),
@@ -43,7 +49,7 @@ func CreateCRD(templ *templates.ConstraintTemplate, schema *apiextensions.JSONSc
 			Validation: &apiextensions.CustomResourceValidation{
 				OpenAPIV3Schema: schema,
 			},
-			Scope:   apiextensions.ClusterScoped,
+			Scope:   scope,
 			Version: v1beta1.SchemeGroupVersion.Version,
 			Subresources: &apiextensions.CustomResourceSubresources{
 				Status: &apiextensions.CustomResourceSubresourceStatus{},
@@ -100,3 +106,17 @@ func CreateCRD(templ *templates.ConstraintTemplate, schema *apiextensions.JSONSc
 
 	return crd2, nil
 }
+
+// crdScope returns the scope of the CRD generated for templ. Constraints are
+// cluster-scoped unless the Template opts into namespaced Constraints.
+func crdScope(templ *templates.ConstraintTemplate) (apiextensions.ResourceScope, error) {
+	switch scope := templ.Spec.CRD.Spec.Scope; scope {
+	case "", templates.ScopeCluster:
+		return apiextensions.ClusterScoped, nil
+	case templates.ScopeNamespaced:
+		return apiextensions.NamespaceScoped, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported scope %q; must be %q or %q",
+			clienterrors.ErrInvalidConstraintTemplate, scope, templates.ScopeCluster, templates.ScopeNamespaced)
+	}
+}
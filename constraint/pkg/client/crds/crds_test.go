@@ -3,14 +3,17 @@ package crds_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest/cts"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/crds"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -96,6 +99,12 @@ func crName(name string) customResourceArg {
 	}
 }
 
+func crNamespace(namespace string) customResourceArg {
+	return func(u *unstructured.Unstructured) {
+		u.SetNamespace(namespace)
+	}
+}
+
 func enforcementAction(s string) customResourceArg {
 	return func(u *unstructured.Unstructured) {
 		if err := unstructured.SetNestedField(u.Object, s, "spec", "enforcementAction"); err != nil {
@@ -136,10 +145,22 @@ func TestValidateTemplate(t *testing.T) {
 			ErrorExpected: true,
 		},
 		{
-			Name: "Two Targets Fails",
+			Name: "Two Targets",
 			Template: cts.New(cts.OptTargets(
 				cts.Target("fooTarget", cts.ModuleDeny),
 				cts.Target("barTarget", cts.ModuleDeny))),
+			ErrorExpected: false,
+		},
+		{
+			Name: "Duplicate Targets Fails",
+			Template: cts.New(cts.OptTargets(
+				cts.Target("fooTarget", cts.ModuleDeny),
+				cts.Target("fooTarget", cts.ModuleDeny))),
+			ErrorExpected: true,
+		},
+		{
+			Name:          "Empty Target Name Fails",
+			Template:      cts.New(cts.OptTargets(cts.Target("", cts.ModuleDeny))),
 			ErrorExpected: true,
 		},
 	}
@@ -157,6 +178,110 @@ func TestValidateTemplate(t *testing.T) {
 	}
 }
 
+func TestMergeMatchSchemas(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []crds.MatchSchemaProvider
+		want      apiextensions.JSONSchemaProps
+		wantErr   error
+	}{
+		{
+			name: "single provider",
+			providers: []crds.MatchSchemaProvider{
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+			},
+			want: cts.Prop(cts.PropMap{"label": cts.PropTyped("string")}),
+		},
+		{
+			name: "disjoint properties",
+			providers: []crds.MatchSchemaProvider{
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+				createTestTargetHandler(matchSchema(cts.PropMap{"path": cts.PropTyped("string")})),
+			},
+			want: cts.Prop(cts.PropMap{
+				"label": cts.PropTyped("string"),
+				"path":  cts.PropTyped("string"),
+			}),
+		},
+		{
+			name: "identical shared property",
+			providers: []crds.MatchSchemaProvider{
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+			},
+			want: cts.Prop(cts.PropMap{"label": cts.PropTyped("string")}),
+		},
+		{
+			name: "untyped schema",
+			providers: []crds.MatchSchemaProvider{
+				createTestTargetHandler(),
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+			},
+			want: apiextensions.JSONSchemaProps{
+				Type:                   "object",
+				Properties:             cts.PropMap{"label": cts.PropTyped("string")},
+				XPreserveUnknownFields: ptr.To[bool](true),
+			},
+		},
+		{
+			name: "differing required fields",
+			providers: []crds.MatchSchemaProvider{
+				&testTargetHandler{matchSchema: apiextensions.JSONSchemaProps{
+					Type:       "object",
+					Properties: cts.PropMap{"label": cts.PropTyped("string"), "kind": cts.PropTyped("string")},
+					Required:   []string{"label", "kind"},
+				}},
+				&testTargetHandler{matchSchema: apiextensions.JSONSchemaProps{
+					Type:       "object",
+					Properties: cts.PropMap{"path": cts.PropTyped("string"), "kind": cts.PropTyped("string")},
+					Required:   []string{"path", "kind"},
+				}},
+			},
+			want: apiextensions.JSONSchemaProps{
+				Type: "object",
+				Properties: cts.PropMap{
+					"label": cts.PropTyped("string"),
+					"path":  cts.PropTyped("string"),
+					"kind":  cts.PropTyped("string"),
+				},
+				Required: []string{"kind"},
+			},
+		},
+		{
+			name: "conflicting shared property",
+			providers: []crds.MatchSchemaProvider{
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("integer")})),
+			},
+			wantErr: clienterrors.ErrInvalidConstraintTemplate,
+		},
+		{
+			name: "non-object schema",
+			providers: []crds.MatchSchemaProvider{
+				createTestTargetHandler(matchSchema(cts.PropMap{"label": cts.PropTyped("string")})),
+				&testTargetHandler{matchSchema: cts.PropTyped("string")},
+			},
+			wantErr: clienterrors.ErrInvalidConstraintTemplate,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := crds.MergeMatchSchemas(tc.providers...)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got MergeMatchSchemas() error = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got.MatchSchema(), cmpopts.EquateEmpty()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
 func TestCreateSchema(t *testing.T) {
 	tests := []crdTestCase{
 		{
@@ -277,6 +402,16 @@ func TestCRDCreationAndValidation(t *testing.T) {
 				})),
 			ErrorExpected: false,
 		},
+		{
+			Name: "Namespaced Template",
+			Template: cts.New(
+				cts.OptName("SomeName"),
+				cts.OptCRDNames("Horse"),
+				cts.OptCRDScope(templates.ScopeNamespaced),
+			),
+			Handler:       createTestTargetHandler(),
+			ErrorExpected: false,
+		},
 		{
 			Name:          "No CRD Names Fails",
 			Template:      cts.New(cts.OptCRDNames("")),
@@ -439,6 +574,49 @@ func TestCRValidation(t *testing.T) {
 			CR:            createCR(crName("mycr"), kind("Horse"), enforcementAction("dryrun")),
 			ErrorExpected: false,
 		},
+		{
+			Name: "Cluster-scoped CR with namespace",
+			Template: cts.New(
+				cts.OptName("SomeName"),
+				cts.OptCRDNames("Horse"),
+			),
+			Handler:       createTestTargetHandler(),
+			CR:            createCR(crName("mycr"), crNamespace("foo"), kind("Horse")),
+			ErrorExpected: true,
+		},
+		{
+			Name: "Namespaced CR",
+			Template: cts.New(
+				cts.OptName("SomeName"),
+				cts.OptCRDNames("Horse"),
+				cts.OptCRDScope(templates.ScopeNamespaced),
+			),
+			Handler:       createTestTargetHandler(),
+			CR:            createCR(crName("mycr"), crNamespace("foo"), kind("Horse")),
+			ErrorExpected: false,
+		},
+		{
+			Name: "Namespaced CR without namespace",
+			Template: cts.New(
+				cts.OptName("SomeName"),
+				cts.OptCRDNames("Horse"),
+				cts.OptCRDScope(templates.ScopeNamespaced),
+			),
+			Handler:       createTestTargetHandler(),
+			CR:            createCR(crName("mycr"), kind("Horse")),
+			ErrorExpected: true,
+		},
+		{
+			Name: "Namespaced CR with invalid namespace",
+			Template: cts.New(
+				cts.OptName("SomeName"),
+				cts.OptCRDNames("Horse"),
+				cts.OptCRDScope(templates.ScopeNamespaced),
+			),
+			Handler:       createTestTargetHandler(),
+			CR:            createCR(crName("mycr"), crNamespace("Not.A.Namespace"), kind("Horse")),
+			ErrorExpected: true,
+		},
 		{
 			Name: "unknown fields",
 			Template: cts.New(
@@ -482,3 +660,57 @@ func TestCRValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateCRD_Scope(t *testing.T) {
+	tcs := []struct {
+		name    string
+		scope   string
+		want    apiextensions.ResourceScope
+		wantErr error
+	}{
+		{
+			name:  "default",
+			scope: "",
+			want:  apiextensions.ClusterScoped,
+		},
+		{
+			name:  "cluster",
+			scope: templates.ScopeCluster,
+			want:  apiextensions.ClusterScoped,
+		},
+		{
+			name:  "namespaced",
+			scope: templates.ScopeNamespaced,
+			want:  apiextensions.NamespaceScoped,
+		},
+		{
+			name:    "unsupported",
+			scope:   "Galaxy",
+			wantErr: clienterrors.ErrInvalidConstraintTemplate,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			templ := cts.New(cts.OptCRDScope(tc.scope))
+			schema := crds.CreateSchema(templ, createTestTargetHandler())
+
+			crd, err := crds.CreateCRD(templ, schema)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+
+			if crd.Spec.Scope != tc.want {
+				t.Errorf("got scope %q, want %q", crd.Spec.Scope, tc.want)
+			}
+
+			err = crds.ValidateCRD(context.Background(), crd)
+			if err != nil {
+				t.Errorf("got invalid CRD: %v", err)
+			}
+		})
+	}
+}
@@ -1,9 +1,75 @@
 package crds
 
-import "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+)
 
 // MatchSchemaProvider defines an interface for providing match schema.
 type MatchSchemaProvider interface {
 	// MatchSchema returns the JSON Schema for the `match` field of a constraint
 	MatchSchema() apiextensions.JSONSchemaProps
 }
+
+// mergedMatchSchema is a MatchSchemaProvider for a precomputed schema.
+type mergedMatchSchema apiextensions.JSONSchemaProps
+
+func (m mergedMatchSchema) MatchSchema() apiextensions.JSONSchemaProps {
+	return apiextensions.JSONSchemaProps(m)
+}
+
+// MergeMatchSchemas combines the match schemas of the targets of a multi-target
+// ConstraintTemplate, so Constraints may specify match criteria for any of the
+// targets. Every schema must be an object or untyped. Properties defined by
+// more than one target must have identical schemas. Only fields required by
+// every target are required, so Constraints need not specify match criteria
+// for targets they don't match on.
+func MergeMatchSchemas(providers ...MatchSchemaProvider) (MatchSchemaProvider, error) {
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	merged := apiextensions.JSONSchemaProps{
+		Type:       "object",
+		Properties: make(map[string]apiextensions.JSONSchemaProps),
+	}
+	// required counts how many targets require each field.
+	required := make(map[string]int)
+
+	for i, provider := range providers {
+		schema := provider.MatchSchema()
+		if schema.Type != "" && schema.Type != "object" {
+			return nil, fmt.Errorf("%w: match schema %d has type %q, want %q",
+				clienterrors.ErrInvalidConstraintTemplate, i, schema.Type, "object")
+		}
+
+		if schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields {
+			merged.XPreserveUnknownFields = schema.XPreserveUnknownFields
+		}
+
+		for name, prop := range schema.Properties {
+			if existing, found := merged.Properties[name]; found && !reflect.DeepEqual(existing, prop) {
+				return nil, fmt.Errorf("%w: conflicting match schemas for field %q",
+					clienterrors.ErrInvalidConstraintTemplate, name)
+			}
+			merged.Properties[name] = prop
+		}
+
+		for _, name := range schema.Required {
+			required[name]++
+		}
+	}
+
+	for name, n := range required {
+		if n == len(providers) {
+			merged.Required = append(merged.Required, name)
+		}
+	}
+	sort.Strings(merged.Required)
+
+	return mergedMatchSchema(merged), nil
+}
@@ -13,6 +13,8 @@ func CreateSchema(templ *templates.ConstraintTemplate, target MatchSchemaProvide
 	props := map[string]apiextensions.JSONSchemaProps{
 		"match":             target.MatchSchema(),
 		"enforcementAction": {Type: "string", Default: &defaultEnforcementAction},
+		"activeFrom":        {Type: "string", Format: "date-time"},
+		"activeUntil":       {Type: "string", Format: "date-time"},
 		"scopedEnforcementActions": {
 			Type:    "array",
 			Default: nil,
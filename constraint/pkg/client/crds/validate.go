@@ -33,16 +33,26 @@ func ValidateTargets(templ *templates.ConstraintTemplate) error {
 			clienterrors.ErrInvalidConstraintTemplate)
 	}
 
-	switch len(targets) {
-	case 0:
-		return fmt.Errorf("%w: no targets specified: ConstraintTemplate must specify one target",
-			clienterrors.ErrInvalidConstraintTemplate)
-	case 1:
-		return nil
-	default:
-		return fmt.Errorf("%w: multi-target templates are not currently supported",
+	if len(targets) == 0 {
+		return fmt.Errorf("%w: no targets specified: ConstraintTemplate must specify at least one target",
 			clienterrors.ErrInvalidConstraintTemplate)
 	}
+
+	seen := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		if target.Target == "" {
+			return fmt.Errorf("%w: target name must not be empty",
+				clienterrors.ErrInvalidConstraintTemplate)
+		}
+
+		if seen[target.Target] {
+			return fmt.Errorf("%w: target %q specified more than once",
+				clienterrors.ErrInvalidConstraintTemplate, target.Target)
+		}
+		seen[target.Target] = true
+	}
+
+	return nil
 }
 
 // ValidateCRD calls the CRD package's validation on an internal representation of the CRD.
@@ -61,6 +71,10 @@ func ValidateCR(cr *unstructured.Unstructured, crd *apiextensions.CustomResource
 			constraints.ErrInvalidConstraint, strings.Join(errs, "\n"))
 	}
 
+	if err := validateNamespace(cr, crd); err != nil {
+		return err
+	}
+
 	if cr.GetKind() != crd.Spec.Names.Kind {
 		return fmt.Errorf("%w: wrong kind %q for constraint %q; want %q",
 			constraints.ErrInvalidConstraint, cr.GetName(), cr.GetKind(), crd.Spec.Names.Kind)
@@ -103,3 +117,29 @@ func ValidateCR(cr *unstructured.Unstructured, crd *apiextensions.CustomResource
 
 	return nil
 }
+
+// validateNamespace ensures cr specifies a namespace if and only if crd is
+// namespace-scoped.
+func validateNamespace(cr *unstructured.Unstructured, crd *apiextensions.CustomResourceDefinition) error {
+	namespace := cr.GetNamespace()
+
+	if crd.Spec.Scope != apiextensions.NamespaceScoped {
+		if namespace != "" {
+			return fmt.Errorf("%w: constraint %q of cluster-scoped kind %q must not specify a namespace",
+				constraints.ErrInvalidConstraint, cr.GetName(), crd.Spec.Names.Kind)
+		}
+		return nil
+	}
+
+	if namespace == "" {
+		return fmt.Errorf("%w: constraint %q of namespaced kind %q must specify a namespace",
+			constraints.ErrInvalidConstraint, cr.GetName(), crd.Spec.Names.Kind)
+	}
+
+	if errs := apivalidation.IsDNS1123Label(namespace); len(errs) != 0 {
+		return fmt.Errorf("%w: invalid namespace: %q",
+			constraints.ErrInvalidConstraint, strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
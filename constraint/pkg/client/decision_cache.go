@@ -0,0 +1,163 @@
+package client
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/reviews"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/instrumentation"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// CachedStatName is the name of the review-scoped Stat added to Responses
+// which Review returned from the decision cache rather than by evaluating
+// Constraints.
+const CachedStatName = "cached"
+
+// decisionCache is a fixed-size, least-recently-used cache of the Responses
+// returned by Review. Threadsafe.
+type decisionCache struct {
+	mtx sync.Mutex
+
+	size int
+
+	// entries maps each key to its element in lru.
+	entries map[string]*list.Element
+
+	// lru orders entries from most to least recently used.
+	lru *list.List
+}
+
+type decisionEntry struct {
+	key       string
+	responses *types.Responses
+}
+
+// entryOf returns the decisionEntry stored in elem. Every element of lru holds
+// a *decisionEntry.
+func entryOf(elem *list.Element) *decisionEntry {
+	entry, _ := elem.Value.(*decisionEntry)
+	return entry
+}
+
+func newDecisionCache(size int) *decisionCache {
+	return &decisionCache{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+// get returns a copy of the Responses cached for key, if any.
+func (c *decisionCache) get(key string) (*types.Responses, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elem, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+
+	return entryOf(elem).responses.DeepCopy(), true
+}
+
+// add caches a copy of responses for key, evicting the least recently used
+// entry if the cache is full.
+func (c *decisionCache) add(key string, responses *types.Responses) {
+	responses = responses.DeepCopy()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, found := c.entries[key]; found {
+		entryOf(elem).responses = responses
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&decisionEntry{key: key, responses: responses})
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, entryOf(oldest).key)
+	}
+}
+
+// decisionKey returns the key identifying a Review of targetReviews with cfg
+// while Client is at generation. Reviews are identified by the keys their
+// targets return as handler.ReviewKeyers. Returns false if the review must not
+// be cached.
+func decisionKey(generation uint64, cfg *reviews.ReviewCfg, targets map[string]handler.TargetHandler, targetReviews map[string]interface{}) (string, bool) {
+	// Traces describe a single evaluation, so there is no point caching them.
+	if cfg.TracingEnabled || cfg.TraceEventsEnabled {
+		return "", false
+	}
+
+	// Encoding reviews directly would miss their unexported fields, so reviews
+	// which differ only in those would share decisions.
+	reviewKeys := make(map[string]string, len(targetReviews))
+	for target, review := range targetReviews {
+		keyer, ok := targets[target].(handler.ReviewKeyer)
+		if !ok {
+			return "", false
+		}
+
+		reviewKey, ok := keyer.ReviewKey(review)
+		if !ok {
+			return "", false
+		}
+		reviewKeys[target] = reviewKey
+	}
+
+	// encoding/json writes map keys in sorted order, so equal reviews always
+	// produce equal keys. The key covers every option passed to drivers other
+	// than the tracing options ruled out above.
+	key := struct {
+		Generation       uint64                 `json:"generation"`
+		EnforcementPoint string                 `json:"enforcementPoint"`
+		StatsEnabled     bool                   `json:"statsEnabled"`
+		ExplainMatching  bool                   `json:"explainMatching"`
+		Timeout          time.Duration          `json:"timeout"`
+		Namespace        map[string]interface{} `json:"namespace"`
+		Reviews          map[string]string      `json:"reviews"`
+	}{
+		Generation:       generation,
+		EnforcementPoint: cfg.EnforcementPoint,
+		StatsEnabled:     cfg.StatsEnabled,
+		ExplainMatching:  cfg.ExplainMatching,
+		Timeout:          cfg.Timeout,
+		Namespace:        cfg.Namespace,
+		Reviews:          reviewKeys,
+	}
+
+	h := sha256.New()
+	err := json.NewEncoder(h).Encode(key)
+	if err != nil {
+		return "", false
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// markCached records in responses that they were returned from the decision
+// cache.
+func markCached(responses *types.Responses) {
+	responses.StatsEntries = append(responses.StatsEntries, &instrumentation.StatsEntry{
+		Scope: instrumentation.ReviewScope,
+		Stats: []*instrumentation.Stat{{
+			Name:  CachedStatName,
+			Value: true,
+			Source: instrumentation.Source{
+				Type:  instrumentation.ClientSourceType,
+				Value: "decisioncache",
+			},
+		}},
+	})
+}
@@ -174,7 +174,7 @@ func (d *Driver) AddConstraint(_ context.Context, constraint *unstructured.Unstr
 		d.constraints[kind] = make(map[string]*unstructured.Unstructured)
 	}
 
-	d.constraints[kind][constraint.GetName()] = constraint.DeepCopy()
+	d.constraints[kind][constraintName(constraint)] = constraint.DeepCopy()
 
 	return nil
 }
@@ -193,7 +193,7 @@ func (d *Driver) RemoveConstraint(_ context.Context, constraint *unstructured.Un
 		return nil
 	}
 
-	delete(d.constraints[kind], constraint.GetName())
+	delete(d.constraints[kind], constraintName(constraint))
 
 	return nil
 }
@@ -235,3 +235,12 @@ func (d *Driver) Dump(_ context.Context) (string, error) {
 func (d *Driver) GetDescriptionForStat(_ string) (string, error) {
 	return "", fmt.Errorf("unknown stat name")
 }
+
+// constraintName returns the name constraint is stored under: its name, prefixed
+// with its namespace if it is namespaced.
+func constraintName(constraint *unstructured.Unstructured) string {
+	if ns := constraint.GetNamespace(); ns != "" {
+		return ns + "/" + constraint.GetName()
+	}
+	return constraint.GetName()
+}
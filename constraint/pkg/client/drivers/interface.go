@@ -57,23 +57,137 @@ type Driver interface {
 	GetDescriptionForStat(statName string) (string, error)
 }
 
+// BatchQuerier is an optional interface for Drivers which can evaluate many
+// reviews against a target in a single call. Client uses it to amortize
+// per-query setup, such as preparing queries, across a batch of reviews.
+type BatchQuerier interface {
+	// QueryBatch runs each query's Constraints against the query's review.
+	// Returns one BatchResult per query, in the same order as queries. Each
+	// BatchResult must hold what Query would have returned for that query.
+	QueryBatch(ctx context.Context, target string, queries []BatchQuery, opts ...reviews.ReviewOpt) []BatchResult
+}
+
+// DataBatcher is an optional interface for Drivers which can cache many objects
+// for a target at once. Client uses it to amortize per-write overhead, such as
+// opening storage transactions, when syncing large amounts of data.
+type DataBatcher interface {
+	// AddDataBatch caches each item's Data at its Path for target, as AddData
+	// would. Returns one error per item, in the same order as items, which is
+	// nil if the item was cached. An item which fails does not prevent the
+	// other items from being cached.
+	AddDataBatch(ctx context.Context, target string, items []DataItem) []error
+}
+
+// DataReader is an optional interface for Drivers which can return the data
+// they have cached. Client requires the "Rego" driver to implement it to
+// include cached data in snapshots.
+type DataReader interface {
+	// ReadData returns the data cached at path for target, or nil if nothing is
+	// cached there.
+	ReadData(ctx context.Context, target string, path storage.Path) (interface{}, error)
+}
+
+// DataTransactor is an optional interface for Drivers which can stage changes
+// to cached data and apply them all at once. Client requires the "Rego" driver
+// to implement it to make AddData and RemoveData atomic across targets.
+type DataTransactor interface {
+	// NewDataTransaction begins staging changes to cached data. Staged changes
+	// are not visible to Query until the DataTransaction is committed.
+	NewDataTransaction(ctx context.Context) (DataTransaction, error)
+}
+
+// DataTransaction is a set of staged changes to a Driver's cached data.
+// Exactly one of Commit or Abort must be called to release the transaction,
+// as pending transactions may block queries and other writes.
+//
+// Staging changes for a target may block until other transactions for that
+// target complete, so callers which stage changes for several targets should
+// do so in a consistent order to avoid deadlocks.
+//
+// Not threadsafe.
+type DataTransaction interface {
+	// AddData stages caching data at path for target. Replaces data if it
+	// already exists at the specified path.
+	AddData(ctx context.Context, target string, path storage.Path, data interface{}) error
+	// RemoveData stages removing cached data at path for target.
+	RemoveData(ctx context.Context, target string, path storage.Path) error
+
+	// Commit applies all staged changes. If Commit returns an error, none of
+	// the changes are applied.
+	Commit(ctx context.Context) error
+	// Abort discards all staged changes.
+	Abort(ctx context.Context)
+}
+
+// TemplateValidator is an optional interface for Drivers which can check that a
+// Template's code compiles without adding the Template. Client uses it to
+// report compilation problems from ValidateTemplate.
+type TemplateValidator interface {
+	// ValidateTemplate compiles the Template's code without modifying the
+	// Driver. Returns every problem found in the Template's code, or an error if
+	// the Driver was unable to check the code at all.
+	ValidateTemplate(ctx context.Context, ct *templates.ConstraintTemplate) ([]CompileError, error)
+}
+
+// ShadowQuerier is an optional interface for Drivers which can compile a
+// candidate version of a Template alongside the active version. Client requires
+// it to shadow the Templates a Driver enforces.
+type ShadowQuerier interface {
+	// AddShadowTemplate compiles a candidate version of the active Template of
+	// the same kind. Replaces the existing candidate if there is one. Does not
+	// change the results of Query.
+	AddShadowTemplate(ctx context.Context, ct *templates.ConstraintTemplate) error
+	// RemoveShadowTemplate discards the candidate version of the Template.
+	// Does not return an error if there is no candidate.
+	RemoveShadowTemplate(ctx context.Context, ct *templates.ConstraintTemplate) error
+
+	// QueryShadow is Query, but evaluates each Constraint with the candidate
+	// version of its Template. Every Constraint must be of a kind with a
+	// candidate.
+	QueryShadow(ctx context.Context, target string, constraints []*unstructured.Unstructured, review interface{}, opts ...reviews.ReviewOpt) (*QueryResponse, error)
+}
+
 // ConstraintKey uniquely identifies a Constraint.
 type ConstraintKey struct {
 	Kind string `json:"kind"`
-	Name string `json:"name"`
+	// Namespace is the namespace of Constraints of namespaced kinds, and empty
+	// for cluster-scoped Constraints.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
 }
 
 // ConstraintKeyFrom returns a unique identifier corresponding to Constraint.
 func ConstraintKeyFrom(constraint *unstructured.Unstructured) ConstraintKey {
 	return ConstraintKey{
-		Kind: constraint.GetKind(),
-		Name: constraint.GetName(),
+		Kind:      constraint.GetKind(),
+		Namespace: constraint.GetNamespace(),
+		Name:      constraint.GetName(),
 	}
 }
 
 // StoragePath returns a unique path in Rego storage for Constraint's parameters.
 // Constraints have a single set of parameters shared among all targets, so a
 // target-specific path is not required.
+//
+// Parameters of namespaced Constraints are stored one level deeper, under their
+// namespace. Every Constraint of a kind has the same scope, so the two layouts
+// never share a kind.
 func (k ConstraintKey) StoragePath() storage.Path {
+	if k.Namespace != "" {
+		return storage.Path{"constraints", k.Kind, k.Namespace, k.Name}
+	}
 	return storage.Path{"constraints", k.Kind, k.Name}
 }
+
+// ToMap returns the untyped JSON form of the key, as passed to and returned
+// from Rego.
+func (k ConstraintKey) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"kind": k.Kind,
+		"name": k.Name,
+	}
+	if k.Namespace != "" {
+		m["namespace"] = k.Namespace
+	}
+	return m
+}
@@ -3,6 +3,7 @@ package rego
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/storage"
@@ -38,6 +39,10 @@ func Defaults() Arg {
 			d.targets = make(map[string][]string)
 		}
 
+		if d.partialEvaluationTimeout == 0 {
+			d.partialEvaluationTimeout = defaultPartialEvaluationTimeout
+		}
+
 		// adding external_data builtin otherwise capabilities get overridden
 		// if a capability, like http.send, is disabled
 		if d.providerCache != nil {
@@ -188,6 +193,116 @@ func GatherStats() Arg {
 	}
 }
 
+// GatherConstraintStats starts collecting the time taken to evaluate each
+// Constraint, and the number of violations and errors it returned. Each
+// Constraint is then evaluated on its own, which is slower than evaluating the
+// Constraints of a Template together, but keeps an error in one Constraint from
+// being reported for the rest.
+func GatherConstraintStats() Arg {
+	return func(driver *Driver) error {
+		driver.gatherConstraintStats = true
+
+		return nil
+	}
+}
+
+// EvaluationTimeout sets the longest the Constraints of each Template may be
+// evaluated for in a single query, unless the query specifies reviews.Timeout.
+// Zero, the default, means evaluation is not limited.
+func EvaluationTimeout(timeout time.Duration) Arg {
+	return func(driver *Driver) error {
+		if timeout < 0 {
+			return fmt.Errorf("%w: evaluation timeout must not be negative, got %v",
+				errors.ErrCreatingDriver, timeout)
+		}
+		driver.evaluationTimeout = timeout
+
+		return nil
+	}
+}
+
+// MaxEvaluationSteps limits how many steps, such as evaluating an expression or
+// trying the next element of a collection, evaluating the Constraints of each
+// Template may take in a single query. Evaluation of Templates which exceed
+// the limit is stopped, and each of their Constraints gets a result wrapping
+// errors.ErrStepLimit instead of its violations. Counting steps slows
+// evaluation down. Zero, the default, means steps are not limited.
+func MaxEvaluationSteps(steps int) Arg {
+	return func(driver *Driver) error {
+		if steps < 0 {
+			return fmt.Errorf("%w: maximum evaluation steps must not be negative, got %d",
+				errors.ErrCreatingDriver, steps)
+		}
+		driver.maxEvaluationSteps = steps
+
+		return nil
+	}
+}
+
+// MaxResults limits how many results each Constraint may return for a single
+// review. Constraints which exceed the limit get a single result wrapping
+// errors.ErrResultLimit instead of their violations. Zero, the default, means
+// results are not limited.
+func MaxResults(results int) Arg {
+	return func(driver *Driver) error {
+		if results < 0 {
+			return fmt.Errorf("%w: maximum results must not be negative, got %d",
+				errors.ErrCreatingDriver, results)
+		}
+		driver.maxResults = results
+
+		return nil
+	}
+}
+
+// MaxReviewSize limits the size in bytes of the JSON encoding of reviews. No
+// Constraints are evaluated for larger reviews; instead each gets a result
+// wrapping errors.ErrReviewSizeLimit. Zero, the default, means reviews of any
+// size are evaluated.
+func MaxReviewSize(bytes int) Arg {
+	return func(driver *Driver) error {
+		if bytes < 0 {
+			return fmt.Errorf("%w: maximum review size must not be negative, got %d",
+				errors.ErrCreatingDriver, bytes)
+		}
+		driver.maxReviewSize = bytes
+
+		return nil
+	}
+}
+
+// PartialEvaluation enables or disables partially evaluating Templates for the
+// parameters of each Constraint when the Constraint is added. Queries then
+// evaluate only what remains of each Template for the review. Partial
+// evaluation does not block concurrent queries, which evaluate the Constraint
+// in full until it completes. Templates which can't be partially evaluated or
+// which call external_data, and queries with tracing enabled, are evaluated as
+// if it were disabled.
+// Disabled by default.
+func PartialEvaluation(enabled bool) Arg {
+	return func(driver *Driver) error {
+		driver.partialEvaluation = enabled
+
+		return nil
+	}
+}
+
+// PartialEvaluationTimeout sets the longest AddConstraint spends partially
+// evaluating a Template for a Constraint in each target before giving up and
+// evaluating the Constraint in full for each review instead. Only applies if
+// PartialEvaluation is enabled. Defaults to 100 milliseconds.
+func PartialEvaluationTimeout(timeout time.Duration) Arg {
+	return func(driver *Driver) error {
+		if timeout <= 0 {
+			return fmt.Errorf("%w: partial evaluation timeout must be positive, got %v",
+				errors.ErrCreatingDriver, timeout)
+		}
+		driver.partialEvaluationTimeout = timeout
+
+		return nil
+	}
+}
+
 // Currently rules should only access data.inventory.
 var validDataFields = map[string]bool{
 	"inventory": true,
@@ -1,7 +1,9 @@
 package rego
 
 import (
+	"context"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -15,6 +17,31 @@ const (
 	providerResponseKind       = "ProviderResponse"
 )
 
+// idempotenceKey is the context key for the idempotence of a query.
+type idempotenceKey struct{}
+
+// idempotence records whether every external_data response used while
+// evaluating a query was idempotent. Queries whose results depend on responses
+// which were not idempotent, or on failed requests, must not be reused.
+type idempotence struct {
+	violated atomic.Bool
+}
+
+// withIdempotence returns a context which records the idempotence of the
+// external_data responses used by queries evaluated with it.
+func withIdempotence(ctx context.Context) (context.Context, *idempotence) {
+	i := &idempotence{}
+	return context.WithValue(ctx, idempotenceKey{}, i), i
+}
+
+// markNotIdempotent records that a query evaluated with ctx used an
+// external_data response which was not idempotent.
+func markNotIdempotent(ctx context.Context) {
+	if i, ok := ctx.Value(idempotenceKey{}).(*idempotence); ok {
+		i.violated.Store(true)
+	}
+}
+
 func externalDataBuiltin(d *Driver) func(bctx rego.BuiltinContext, regorequest *ast.Term) (*ast.Term, error) {
 	return func(bctx rego.BuiltinContext, regorequest *ast.Term) (*ast.Term, error) {
 		var regoReq externaldata.RegoRequest
@@ -64,16 +91,19 @@ func externalDataBuiltin(d *Driver) func(bctx rego.BuiltinContext, regorequest *
 		if len(providerRequestKeys) > 0 {
 			provider, err := d.providerCache.Get(regoReq.ProviderName)
 			if err != nil {
+				markNotIdempotent(bctx.Context)
 				return externaldata.HandleError(http.StatusBadRequest, err)
 			}
 
 			clientCert, err := d.getTLSCertificate()
 			if err != nil {
+				markNotIdempotent(bctx.Context)
 				return externaldata.HandleError(http.StatusBadRequest, err)
 			}
 
 			externaldataResponse, statusCode, err := d.sendRequestToProvider(bctx.Context, &provider, providerRequestKeys, clientCert)
 			if err != nil {
+				markNotIdempotent(bctx.Context)
 				return externaldata.HandleError(statusCode, err)
 			}
 
@@ -106,6 +136,10 @@ func externalDataBuiltin(d *Driver) func(bctx rego.BuiltinContext, regorequest *
 			providerResponseStatusCode = statusCode
 		}
 
+		if !prepareResponse.Idempotent {
+			markNotIdempotent(bctx.Context)
+		}
+
 		providerResponse := &externaldata.ProviderResponse{
 			APIVersion: providerResponseAPIVersion,
 			Kind:       providerResponseKind,
@@ -6,7 +6,9 @@ import (
 	"sync"
 
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
 
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego/schema"
 	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
@@ -24,6 +26,11 @@ type Compilers struct {
 	// compiler for the corresponding ConstraintTemplate.
 	compilers map[string]map[string]*ast.Compiler
 
+	// queries is a map from target name to a map from Constraint kind to the
+	// violation query prepared against the corresponding compiler, so queries
+	// need only bind their input.
+	queries map[string]map[string]*rego.PreparedEvalQuery
+
 	// externs are the subpaths of "data" which ConstraintTemplates are allowed to
 	// reference without being defined. For example, "inventory" for "data.inventory".
 	externs []string
@@ -31,8 +38,12 @@ type Compilers struct {
 	capabilities *ast.Capabilities
 }
 
-func (d *Compilers) addTemplate(templ *templates.ConstraintTemplate, printEnabled bool) error {
+// prepareFunc prepares the violation query of compiler for target.
+type prepareFunc func(compiler *ast.Compiler, target string) (rego.PreparedEvalQuery, error)
+
+func (d *Compilers) addTemplate(templ *templates.ConstraintTemplate, printEnabled bool, prepare prepareFunc) error {
 	compilers := make(map[string]*ast.Compiler)
+	queries := make(map[string]*rego.PreparedEvalQuery)
 
 	modules, err := parseConstraintTemplate(templ, d.externs)
 	if err != nil {
@@ -45,7 +56,13 @@ func (d *Compilers) addTemplate(templ *templates.ConstraintTemplate, printEnable
 			return err
 		}
 
+		query, err := prepare(compiler, target)
+		if err != nil {
+			return err
+		}
+
 		compilers[target] = compiler
+		queries[target] = &query
 	}
 
 	// Don't lock the mutex until after compilation is done. Compilation is
@@ -55,14 +72,14 @@ func (d *Compilers) addTemplate(templ *templates.ConstraintTemplate, printEnable
 	defer d.mtx.Unlock()
 
 	kind := templ.Spec.CRD.Spec.Names.Kind
-	for target, targetCompilers := range d.compilers {
-		delete(targetCompilers, kind)
-		d.compilers[target] = targetCompilers
-	}
+	d.removeTemplateLocked(kind)
 
 	if d.compilers == nil {
 		d.compilers = make(map[string]map[string]*ast.Compiler)
 	}
+	if d.queries == nil {
+		d.queries = make(map[string]map[string]*rego.PreparedEvalQuery)
+	}
 
 	for target, compiler := range compilers {
 		targetCompilers := d.compilers[target]
@@ -71,6 +88,13 @@ func (d *Compilers) addTemplate(templ *templates.ConstraintTemplate, printEnable
 		}
 		targetCompilers[kind] = compiler
 		d.compilers[target] = targetCompilers
+
+		targetQueries := d.queries[target]
+		if targetQueries == nil {
+			targetQueries = make(map[string]*rego.PreparedEvalQuery)
+		}
+		targetQueries[kind] = queries[target]
+		d.queries[target] = targetQueries
 	}
 
 	return nil
@@ -80,26 +104,36 @@ func (d *Compilers) getCompiler(target, kind string) *ast.Compiler {
 	d.mtx.RLock()
 	defer d.mtx.RUnlock()
 
-	if len(d.compilers) == 0 {
-		return nil
-	}
+	return d.compilers[target][kind]
+}
 
-	targetCompilers := d.compilers[target]
-	if len(targetCompilers) == 0 {
-		return nil
-	}
+// getQuery returns the prepared violation query for kind's Template in target,
+// or nil if there is none.
+func (d *Compilers) getQuery(target, kind string) *rego.PreparedEvalQuery {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
 
-	return targetCompilers[kind]
+	return d.queries[target][kind]
 }
 
 func (d *Compilers) removeTemplate(kind string) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
+	d.removeTemplateLocked(kind)
+}
+
+// removeTemplateLocked removes the compilers and queries for kind. Callers must
+// hold mtx.
+func (d *Compilers) removeTemplateLocked(kind string) {
 	for target, templateCompilers := range d.compilers {
 		delete(templateCompilers, kind)
 		d.compilers[target] = templateCompilers
 	}
+
+	for _, templateQueries := range d.queries {
+		delete(templateQueries, kind)
+	}
 }
 
 // list returns a shallow copy of the map of Compilers.
@@ -125,15 +159,10 @@ func (d *Compilers) list() map[string]map[string]*ast.Compiler {
 // parseConstraintTemplate validates the rego in template target by parsing
 // rego modules.
 func parseConstraintTemplate(templ *templates.ConstraintTemplate, externs []string) (map[string][]*ast.Module, error) {
-	rr, err := regorewriter.New(regorewriter.NewPackagePrefixer(templateLibPrefix), []string{libRoot}, externs)
-	if err != nil {
-		return nil, fmt.Errorf("creating rego rewriter: %w", err)
-	}
-
 	mods := make(map[string][]*ast.Module)
 	for i := range templ.Spec.Targets {
-		target := templ.Spec.Targets[i]
-		targetMods, err := parseConstraintTemplateTarget(rr, &target)
+		target := &templ.Spec.Targets[i]
+		targetMods, err := parseTarget(target, externs)
 		if err != nil {
 			return nil, err
 		}
@@ -144,6 +173,18 @@ func parseConstraintTemplate(templ *templates.ConstraintTemplate, externs []stri
 	return mods, nil
 }
 
+// parseTarget parses the rego modules of a single target of a template.
+func parseTarget(target *templates.Target, externs []string) ([]*ast.Module, error) {
+	// Each target is compiled separately, so each needs its own rewriter to
+	// avoid mixing in the modules of other targets.
+	rr, err := regorewriter.New(regorewriter.NewPackagePrefixer(templateLibPrefix), []string{libRoot}, externs)
+	if err != nil {
+		return nil, fmt.Errorf("creating rego rewriter: %w", err)
+	}
+
+	return parseConstraintTemplateTarget(rr, target)
+}
+
 func parseConstraintTemplateTarget(rr *regorewriter.RegoRewriter, targetSpec *templates.Target) ([]*ast.Module, error) {
 	var regoCode templates.Code
 	found := false
@@ -173,7 +214,7 @@ func parseConstraintTemplateTarget(rr *regorewriter.RegoRewriter, targetSpec *te
 
 	entryPoint, err := parseModule(templatePath, version, regoSrc.Rego)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", clienterrors.ErrInvalidConstraintTemplate, err)
+		return nil, fmt.Errorf("%w: %w", clienterrors.ErrInvalidConstraintTemplate, err)
 	}
 
 	if entryPoint == nil {
@@ -198,7 +239,7 @@ func parseConstraintTemplateTarget(rr *regorewriter.RegoRewriter, targetSpec *te
 
 		m, err := parseModule(libPath, version, libSrc)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v",
+			return nil, fmt.Errorf("%w: %w",
 				clienterrors.ErrInvalidConstraintTemplate, err)
 		}
 
@@ -240,7 +281,7 @@ func compileTemplateTarget(module []*ast.Module, capabilities *ast.Capabilities,
 
 	compiler.Compile(modules)
 	if compiler.Failed() {
-		return nil, fmt.Errorf("%w: %v", clienterrors.ErrCompile, compiler.Errors)
+		return nil, fmt.Errorf("%w: %w", clienterrors.ErrCompile, compiler.Errors)
 	}
 
 	return compiler, nil
@@ -262,3 +303,37 @@ func parseModule(path string, version ast.RegoVersion, rego string) (*ast.Module
 
 	return module, nil
 }
+
+// toCompileErrors converts err, returned while parsing or compiling target's
+// code, to the problems it describes. Returns false if err does not describe
+// a problem with the code.
+func toCompileErrors(target string, err error) ([]drivers.CompileError, bool) {
+	var astErrs ast.Errors
+	if errors.As(err, &astErrs) {
+		result := make([]drivers.CompileError, len(astErrs))
+		for i, astErr := range astErrs {
+			result[i] = drivers.CompileError{
+				Target:  target,
+				Code:    astErr.Code,
+				Message: astErr.Message,
+			}
+			if astErr.Location != nil {
+				result[i].Location = &drivers.Location{
+					File: astErr.Location.File,
+					Row:  astErr.Location.Row,
+					Col:  astErr.Location.Col,
+				}
+			}
+		}
+		return result, true
+	}
+
+	if errors.Is(err, clienterrors.ErrInvalidConstraintTemplate) ||
+		errors.Is(err, clienterrors.ErrCompile) ||
+		errors.Is(err, clienterrors.ErrInvalidModule) ||
+		errors.Is(err, ErrNoRego) {
+		return []drivers.CompileError{{Target: target, Message: err.Error()}}, true
+	}
+
+	return nil, false
+}
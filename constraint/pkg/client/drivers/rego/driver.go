@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -41,11 +42,38 @@ const (
 	constraintCountName        = "constraintCount"
 	constraintCountDescription = "the number of constraints that were evaluated for the given constraint kind"
 
+	templateTimeoutName        = "templateEvaluationTimeout"
+	templateTimeoutDescription = "whether evaluating the constraints for a template was stopped for exceeding its evaluation timeout"
+
+	templateLimitName        = "templateEvaluationLimitExceeded"
+	templateLimitDescription = "the number of constraints for a template whose evaluation exceeded a resource limit"
+
+	constraintRunTimeNS     = "constraintRunTimeNS"
+	constraintRunTimeNsDesc = "the number of nanoseconds it took to evaluate a constraint"
+
+	constraintViolationCountName        = "constraintViolationCount"
+	constraintViolationCountDescription = "the number of violations a constraint returned"
+
+	constraintErrorCountName        = "constraintErrorCount"
+	constraintErrorCountDescription = "the number of errors encountered evaluating a constraint"
+
 	tracingEnabledLabelName = "TracingEnabled"
 	printEnabledLabelName   = "PrintEnabled"
 )
 
-var _ drivers.Driver = &Driver{}
+// violationPath is the path of the query which evaluates Constraints.
+var violationPath = []string{"hooks", "violation[result]"}
+
+var (
+	_ drivers.Driver         = &Driver{}
+	_ drivers.BatchQuerier   = &Driver{}
+	_ drivers.DataTransactor = &Driver{}
+	_ drivers.DataBatcher    = &Driver{}
+	_ drivers.DataReader     = &Driver{}
+
+	_ drivers.TemplateValidator = &Driver{}
+	_ drivers.ShadowQuerier     = &Driver{}
+)
 
 // Driver is a threadsafe Rego environment for compiling Rego in ConstraintTemplates,
 // registering Constraints, and executing queries.
@@ -53,11 +81,27 @@ type Driver struct {
 	// compilers is a store of Rego Compilers for each Template.
 	compilers Compilers
 
-	// mtx guards access to the storage and target maps.
+	// shadows is a store of Rego Compilers for the candidate version of each
+	// Template added with AddShadowTemplate.
+	shadows Compilers
+
+	// mtx guards access to the storage, target and partials maps.
 	mtx sync.RWMutex
 
 	storage storages
 
+	// partials holds the residual violation queries of Constraints whose
+	// Templates have been partially evaluated for their parameters.
+	partials partials
+
+	// partialEvaluation is whether Templates are partially evaluated for the
+	// parameters of each Constraint when it is added.
+	partialEvaluation bool
+
+	// partialEvaluationTimeout is the longest a Template is partially evaluated
+	// for a Constraint in each target.
+	partialEvaluationTimeout time.Duration
+
 	// targets is a map from each Template's kind to the targets for that Template.
 	targets map[string][]string
 
@@ -90,6 +134,26 @@ type Driver struct {
 
 	// gatherStats controls whether the driver gathers any stats around its API calls.
 	gatherStats bool
+
+	// gatherConstraintStats controls whether the driver gathers stats for each
+	// Constraint it evaluates.
+	gatherConstraintStats bool
+
+	// evaluationTimeout is the default limit on how long the Constraints of each
+	// Template may be evaluated for in a single query. Zero means no limit.
+	evaluationTimeout time.Duration
+
+	// maxEvaluationSteps is the most steps evaluating the Constraints of each
+	// Template may take in a single query. Zero means no limit.
+	maxEvaluationSteps int
+
+	// maxResults is the most results each Constraint may return for a review.
+	// Zero means no limit.
+	maxResults int
+
+	// maxReviewSize is the largest JSON encoding of a review which is evaluated.
+	// Zero means no limit.
+	maxReviewSize int
 }
 
 // Name returns the name of the driver.
@@ -111,13 +175,57 @@ func (d *Driver) AddTemplate(ctx context.Context, templ *templates.ConstraintTem
 		targets = append(targets, target.Target)
 	}
 
+	pending, err := d.addTemplate(ctx, templ, targets)
+	if err != nil {
+		return err
+	}
+
+	d.addResiduals(ctx, targets, pending)
+	return nil
+}
+
+// addTemplate compiles templ for targets and replaces any previous version of
+// it. Returns the parameters of the Template's existing Constraints, to
+// partially evaluate the new version for.
+func (d *Driver) addTemplate(ctx context.Context, templ *templates.ConstraintTemplate, targets []string) (map[drivers.ConstraintKey]*ast.Term, error) {
 	kind := templ.Spec.CRD.Spec.Names.Kind
 
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
 	d.targets[kind] = targets
-	return d.compilers.addTemplate(templ, d.printEnabled)
+	err := d.compilers.addTemplate(templ, d.printEnabled, d.preparer(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Residuals of the previous version of the Template are no longer valid.
+	return d.resetPartials(kind), nil
+}
+
+// ValidateTemplate parses and compiles the Rego of each of templ's targets as
+// AddTemplate would, without adding templ to the Driver.
+func (d *Driver) ValidateTemplate(_ context.Context, templ *templates.ConstraintTemplate) ([]drivers.CompileError, error) {
+	var result []drivers.CompileError
+	for i := range templ.Spec.Targets {
+		target := &templ.Spec.Targets[i]
+
+		modules, err := parseTarget(target, d.compilers.externs)
+		if err == nil {
+			_, err = compileTemplateTarget(modules, d.compilers.capabilities, d.printEnabled)
+		}
+		if err == nil {
+			continue
+		}
+
+		compileErrs, ok := toCompileErrors(target.Target, err)
+		if !ok {
+			return nil, err
+		}
+		result = append(result, compileErrs...)
+	}
+
+	return result, nil
 }
 
 // RemoveTemplate removes all Compilers and Constraints for templ.
@@ -135,10 +243,25 @@ func (d *Driver) RemoveTemplate(ctx context.Context, templ *templates.Constraint
 	}
 
 	d.compilers.removeTemplate(kind)
+	d.shadows.removeTemplate(kind)
+	d.removeKindPartials(kind)
 	delete(d.targets, kind)
 	return nil
 }
 
+// AddShadowTemplate compiles templ as the candidate version of the active
+// Template of the same kind. Replaces any existing candidate.
+func (d *Driver) AddShadowTemplate(ctx context.Context, templ *templates.ConstraintTemplate) error {
+	return d.shadows.addTemplate(templ, d.printEnabled, d.preparer(ctx))
+}
+
+// RemoveShadowTemplate discards the candidate version of templ's kind.
+// Returns nil if there is no candidate.
+func (d *Driver) RemoveShadowTemplate(_ context.Context, templ *templates.ConstraintTemplate) error {
+	d.shadows.removeTemplate(templ.Spec.CRD.Spec.Names.Kind)
+	return nil
+}
+
 // AddConstraint adds Constraint to Rego storage. Future calls to Query will
 // be evaluated against Constraint if the Constraint's key is passed.
 func (d *Driver) AddConstraint(ctx context.Context, constraint *unstructured.Unstructured) error {
@@ -154,6 +277,20 @@ func (d *Driver) AddConstraint(ctx context.Context, constraint *unstructured.Uns
 	}
 
 	key := drivers.ConstraintKeyFrom(constraint)
+
+	targets, pending, err := d.addConstraint(ctx, key, params)
+	if err != nil {
+		return err
+	}
+
+	d.addResiduals(ctx, targets, pending)
+	return nil
+}
+
+// addConstraint writes the parameters of the Constraint with key to the
+// storage of each of its Template's targets. Returns the targets, and the
+// parameters to partially evaluate the Template for.
+func (d *Driver) addConstraint(ctx context.Context, key drivers.ConstraintKey, params interface{}) ([]string, map[drivers.ConstraintKey]*ast.Term, error) {
 	path := key.StoragePath()
 
 	d.mtx.Lock()
@@ -163,23 +300,24 @@ func (d *Driver) AddConstraint(ctx context.Context, constraint *unstructured.Uns
 	for _, target := range targets {
 		err := d.storage.addData(ctx, target, path, params)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
-	return nil
+	return targets, d.setPartials(key, params), nil
 }
 
 // RemoveConstraint removes Constraint from Rego storage. Future calls to Query
 // will not be evaluated against the constraint. Queries which specify the
 // constraint's key will silently not evaluate the Constraint.
 func (d *Driver) RemoveConstraint(ctx context.Context, constraint *unstructured.Unstructured) error {
-	path := drivers.ConstraintKeyFrom(constraint).StoragePath()
+	key := drivers.ConstraintKeyFrom(constraint)
 
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
-	return d.storage.removeDataEach(ctx, path)
+	d.removePartials(key)
+	return d.storage.removeDataEach(ctx, key.StoragePath())
 }
 
 // AddData adds data to Rego storage at data.inventory.path.
@@ -188,6 +326,22 @@ func (d *Driver) AddData(ctx context.Context, target string, path storage.Path,
 	return d.storage.addData(ctx, target, path, data)
 }
 
+// AddDataBatch writes each item's data to Rego storage at data.inventory.path,
+// using a single transaction for the whole batch.
+func (d *Driver) AddDataBatch(ctx context.Context, target string, items []drivers.DataItem) []error {
+	inventoryItems := make([]drivers.DataItem, len(items))
+	for i, item := range items {
+		inventoryItems[i] = drivers.DataItem{Path: inventoryPath(item.Path), Data: item.Data}
+	}
+
+	return d.storage.addDataBatch(ctx, target, inventoryItems)
+}
+
+// ReadData returns the data in Rego storage at data.inventory.path.
+func (d *Driver) ReadData(ctx context.Context, target string, path storage.Path) (interface{}, error) {
+	return d.storage.readData(ctx, target, inventoryPath(path))
+}
+
 // RemoveData deletes data from Rego storage at data.inventory.path.
 func (d *Driver) RemoveData(ctx context.Context, target string, path storage.Path) error {
 	path = inventoryPath(path)
@@ -205,13 +359,6 @@ func (d *Driver) eval(ctx context.Context, compiler *ast.Compiler, target string
 		opt(cfg)
 	}
 
-	queryPath := strings.Builder{}
-	queryPath.WriteString("data")
-	for _, p := range path {
-		queryPath.WriteString(".")
-		queryPath.WriteString(p)
-	}
-
 	store, err := d.storage.getStorage(ctx, target)
 	if err != nil {
 		return nil, nil, err
@@ -221,7 +368,7 @@ func (d *Driver) eval(ctx context.Context, compiler *ast.Compiler, target string
 		rego.Compiler(compiler),
 		rego.Store(store),
 		rego.ParsedInput(input),
-		rego.Query(queryPath.String()),
+		rego.Query(queryPath(path)),
 		rego.EnablePrintStatements(d.printEnabled),
 		rego.PrintHook(d.printHook),
 		rego.SetRegoVersion(ast.RegoV0),
@@ -245,8 +392,201 @@ func (d *Driver) eval(ctx context.Context, compiler *ast.Compiler, target string
 	return res, t, err
 }
 
+// prepare prepares the query at path against compiler and target's storage, so
+// it may be evaluated against many inputs.
+func (d *Driver) prepare(ctx context.Context, compiler *ast.Compiler, target string, path []string) (rego.PreparedEvalQuery, error) {
+	store, err := d.storage.getStorage(ctx, target)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, err
+	}
+
+	r := rego.New(
+		rego.Compiler(compiler),
+		rego.Store(store),
+		rego.Query(queryPath(path)),
+		rego.EnablePrintStatements(d.printEnabled),
+		rego.PrintHook(d.printHook),
+		rego.SetRegoVersion(ast.RegoV0),
+	)
+
+	return r.PrepareForEval(ctx)
+}
+
+// preparer returns a prepareFunc which prepares the violation query of
+// Templates against the storage of each of their targets.
+func (d *Driver) preparer(ctx context.Context) prepareFunc {
+	return func(compiler *ast.Compiler, target string) (rego.PreparedEvalQuery, error) {
+		return d.prepare(ctx, compiler, target, violationPath)
+	}
+}
+
+// evalPrepared evaluates a prepared query against input with opts, recording
+// each step in tracer if it is not nil.
+// Returns the Rego results, or an error if there was a problem executing the
+// query.
+func (d *Driver) evalPrepared(ctx context.Context, query rego.PreparedEvalQuery, input ast.Value, tracer *topdown.BufferTracer, opts ...rego.EvalOption) (rego.ResultSet, error) {
+	evalOpts := append([]rego.EvalOption{rego.EvalParsedInput(input)}, opts...)
+	if tracer != nil {
+		evalOpts = append(evalOpts, rego.EvalQueryTracer(tracer))
+	}
+
+	return query.Eval(ctx, evalOpts...)
+}
+
+// evalKind evaluates constraints, which are all of one kind, against review.
+// Constraints with residuals in partials are evaluated with them, and the rest
+// with query. Residuals are not used when tracing into tracer, so that traces
+// show the Template's full evaluation. Steps taken by both count towards
+// limiter.
+func (d *Driver) evalKind(ctx context.Context, query *rego.PreparedEvalQuery, partials *partials, target string, constraints []*unstructured.Unstructured, review map[string]interface{}, tracer *topdown.BufferTracer, limiter *stepLimiter) (rego.ResultSet, error) {
+	if tracer != nil {
+		partials = nil
+	}
+	residuals, hooked := partials.split(target, constraints)
+
+	resultSet, err := d.evalResiduals(ctx, target, residuals, review, limiter.evalOpts()...)
+	if err != nil || len(hooked) == 0 {
+		return resultSet, err
+	}
+
+	// Parse input into an ast.Value to avoid round-tripping through JSON when
+	// possible.
+	parsedInput, err := toParsedInput(target, hooked, review)
+	if err != nil {
+		return nil, err
+	}
+
+	hookedSet, err := d.evalPrepared(ctx, *query, parsedInput, tracer, limiter.evalOpts()...)
+	return append(resultSet, hookedSet...), err
+}
+
+// templateEval is the outcome of evaluating the Constraints of a Template
+// against a review.
+type templateEval struct {
+	// resultSet has the violations of each Constraint, or the error evaluating
+	// it.
+	resultSet rego.ResultSet
+
+	// trace is the human-readable trace, if requested.
+	trace string
+
+	// traceEvents are the steps evaluating each traced Constraint, if requested.
+	traceEvents []*types.TraceEvent
+
+	// stats are the stats gathered for the Template and its Constraints.
+	stats []*instrumentation.StatsEntry
+
+	// timedOut is whether evaluation exceeded the Template's timeout.
+	timedOut bool
+
+	// failed is whether evaluating any Constraint returned an error, including
+	// the query's context being cancelled or exceeding a limit.
+	failed bool
+}
+
+// evalTemplate evaluates constraints, which are all of kind, against review
+// within the Driver's limits. Errors evaluating Constraints, including
+// exceeding a limit, are returned as results of the Constraints they affect.
+// If reviewErr is set, it is returned for every Constraint instead of
+// evaluating them.
+//
+// If the Driver gathers Constraint stats, or the query requests trace events
+// or traces particular Constraints by name, each Constraint is evaluated on its
+// own so its cost and trace can be told apart from the rest. Otherwise an error
+// evaluating one Constraint is returned for every Constraint of kind.
+func (d *Driver) evalTemplate(ctx context.Context, kind string, query *rego.PreparedEvalQuery, partials *partials, target string, constraints []*unstructured.Unstructured, review map[string]interface{}, reviewErr error, cfg *reviews.ReviewCfg) *templateEval {
+	evalStartTime := time.Now()
+	tracing := d.traceEnabled || cfg.TracingEnabled || cfg.TraceEventsEnabled
+
+	evalCtx, cancel := d.withEvaluationTimeout(ctx, cfg)
+	defer cancel()
+	stepCtx, cancelSteps, limiter := d.withStepLimit(evalCtx)
+	defer cancelSteps()
+
+	groups := [][]*unstructured.Unstructured{constraints}
+	if d.gatherConstraintStats || (tracing && (cfg.TraceEventsEnabled || len(cfg.TraceNames) != 0)) {
+		groups = make([][]*unstructured.Unstructured, len(constraints))
+		for i, constraint := range constraints {
+			groups[i] = []*unstructured.Unstructured{constraint}
+		}
+	}
+
+	result := &templateEval{}
+	traceBuilder := strings.Builder{}
+	limited := 0
+
+	for _, group := range groups {
+		groupStartTime := time.Now()
+
+		// Groups either share kind or have a single Constraint, so the first
+		// Constraint decides whether the group is traced.
+		var tracer *topdown.BufferTracer
+		if tracing && cfg.TracesConstraint(kind, group[0].GetName()) {
+			tracer = topdown.NewBufferTracer()
+		}
+
+		var groupSet rego.ResultSet
+		err := reviewErr
+		if err == nil {
+			groupSet, err = d.evalKind(stepCtx, query, partials, target, group, review, tracer, limiter)
+			err = limiter.err(kind, err)
+			err = d.timeoutError(ctx, evalCtx, kind, cfg, err)
+		}
+
+		groupLimited := 0
+		if err != nil {
+			groupSet = errorResultSet(err, group)
+			result.failed = true
+			result.timedOut = result.timedOut || errors.Is(err, clienterrors.ErrEvaluationTimeout)
+			if errors.Is(err, clienterrors.ErrEvaluationLimit) {
+				groupLimited = len(group)
+			}
+		} else {
+			groupSet, groupLimited = d.limitResults(groupSet, group)
+		}
+		limited += groupLimited
+
+		if tracer != nil && (d.traceEnabled || cfg.TracingEnabled) {
+			topdown.PrettyTrace(&traceBuilder, *tracer)
+		}
+		if tracer != nil && cfg.TraceEventsEnabled {
+			result.traceEvents = append(result.traceEvents, toTraceEvents(group[0], *tracer)...)
+		}
+		result.resultSet = append(result.resultSet, groupSet...)
+
+		if d.gatherConstraintStats {
+			// Constraints which fail have a single result describing the error.
+			violations, errCount := len(groupSet), 0
+			if err != nil || groupLimited > 0 {
+				violations, errCount = 0, 1
+			}
+			result.stats = append(result.stats, d.constraintStats(group[0], time.Since(groupStartTime), violations, errCount, cfg))
+		}
+	}
+
+	if d.gatherStats || cfg.StatsEnabled || result.timedOut || limited > 0 {
+		templateEntry := d.templateStats(kind, time.Since(evalStartTime), len(constraints), result.timedOut, limited, cfg)
+		result.stats = append([]*instrumentation.StatsEntry{templateEntry}, result.stats...)
+	}
+
+	result.trace = traceBuilder.String()
+	return result
+}
+
 // Query evaluates constraints against the given review object and returns the results.
 func (d *Driver) Query(ctx context.Context, target string, constraints []*unstructured.Unstructured, review interface{}, opts ...reviews.ReviewOpt) (*drivers.QueryResponse, error) {
+	return d.query(ctx, &d.compilers, &d.partials, target, constraints, review, opts...)
+}
+
+// QueryShadow evaluates constraints against the given review object with the
+// candidate versions of their Templates.
+func (d *Driver) QueryShadow(ctx context.Context, target string, constraints []*unstructured.Unstructured, review interface{}, opts ...reviews.ReviewOpt) (*drivers.QueryResponse, error) {
+	return d.query(ctx, &d.shadows, nil, target, constraints, review, opts...)
+}
+
+// query evaluates constraints against review with the Compilers in compilers,
+// using residuals from partials where possible.
+func (d *Driver) query(ctx context.Context, compilers *Compilers, partials *partials, target string, constraints []*unstructured.Unstructured, review interface{}, opts ...reviews.ReviewOpt) (*drivers.QueryResponse, error) {
 	if len(constraints) == 0 {
 		return nil, nil
 	}
@@ -255,16 +595,17 @@ func (d *Driver) Query(ctx context.Context, target string, constraints []*unstru
 
 	traceBuilder := strings.Builder{}
 	constraintsMap := drivers.KeyMap(constraints)
-	path := []string{"hooks", "violation[result]"}
 
 	var results []*types.Result
+	var traceEvents []*types.TraceEvent
 
 	// Round-trip review through JSON so that the review object is round-tripped
 	// once per call to Query instead of once per compiler.
-	reviewMap, err := toInterfaceMap(review)
+	reviewMap, reviewSize, err := toInterfaceMap(review)
 	if err != nil {
 		return nil, err
 	}
+	reviewErr := d.checkReviewSize(reviewSize)
 
 	d.mtx.RLock()
 	defer d.mtx.RUnlock()
@@ -281,95 +622,288 @@ func (d *Driver) Query(ctx context.Context, target string, constraints []*unstru
 
 	var statsEntries []*instrumentation.StatsEntry
 
+	ctx, idem := withIdempotence(ctx)
+	failed := false
+
 	for kind, kindConstraints := range constraintsByKind {
-		evalStartTime := time.Now()
-		compiler := d.compilers.getCompiler(target, kind)
-		if compiler == nil {
+		query := compilers.getQuery(target, kind)
+		if query == nil {
 			// The Template was just removed, so the Driver is in an inconsistent
 			// state with Client. Raise this as an error rather than attempting to
 			// continue.
 			return nil, fmt.Errorf("missing Template %q for target %q", kind, target)
 		}
 
-		// Parse input into an ast.Value to avoid round-tripping through JSON when
-		// possible.
-		parsedInput, err := toParsedInput(target, kindConstraints, reviewMap)
+		kindEval := d.evalTemplate(ctx, kind, query, partials, target, kindConstraints, reviewMap, reviewErr, cfg)
+		failed = failed || kindEval.failed
+		traceBuilder.WriteString(kindEval.trace)
+		traceEvents = append(traceEvents, kindEval.traceEvents...)
+
+		kindResults, err := drivers.ToResults(constraintsMap, kindEval.resultSet)
 		if err != nil {
 			return nil, err
 		}
 
-		resultSet, trace, err := d.eval(ctx, compiler, target, path, parsedInput, opts...)
-		evalEndTime := time.Since(evalStartTime)
+		results = append(results, kindResults...)
+		statsEntries = append(statsEntries, kindEval.stats...)
+	}
+
+	// Errors such as timeouts and cancellation depend on the load on the
+	// system and on the caller, so results which include errors must not be
+	// reused.
+	resp := &drivers.QueryResponse{
+		Results:      results,
+		TraceEvents:  traceEvents,
+		StatsEntries: statsEntries,
+		Uncacheable:  idem.violated.Load() || failed,
+	}
+
+	traceString := traceBuilder.String()
+	if len(traceString) != 0 {
+		resp.Trace = &traceString
+	}
+
+	return resp, nil
+}
+
+// QueryBatch evaluates many reviews against target. The prepared query for each
+// Constraint kind is evaluated against every review in the batch which
+// references that kind.
+func (d *Driver) QueryBatch(ctx context.Context, target string, queries []drivers.BatchQuery, opts ...reviews.ReviewOpt) []drivers.BatchResult {
+	results := make([]drivers.BatchResult, len(queries))
+
+	cfg := &reviews.ReviewCfg{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// kindQuery is the subset of a query's Constraints which are of a single kind.
+	type kindQuery struct {
+		index       int
+		constraints []*unstructured.Unstructured
+	}
+
+	reviewMaps := make([]map[string]interface{}, len(queries))
+	reviewErrs := make([]error, len(queries))
+	queriesByKind := make(map[string][]kindQuery)
+	for i, query := range queries {
+		if len(query.Constraints) == 0 {
+			continue
+		}
+
+		reviewMap, reviewSize, err := toInterfaceMap(query.Review)
 		if err != nil {
-			resultSet = make(rego.ResultSet, 0, len(kindConstraints))
-			for _, constraint := range kindConstraints {
-				resultSet = append(resultSet, rego.Result{
-					Bindings: map[string]interface{}{
-						"result": map[string]interface{}{
-							"msg": err.Error(),
-							"key": map[string]interface{}{
-								"kind": constraint.GetKind(),
-								"name": constraint.GetName(),
-							},
-						},
-					},
-				})
-			}
+			results[i].Err = err
+			continue
 		}
-		if trace != nil {
-			traceBuilder.WriteString(*trace)
+		reviewMap["namespaceObject"] = cfg.Namespace
+		reviewMaps[i] = reviewMap
+		reviewErrs[i] = d.checkReviewSize(reviewSize)
+
+		results[i].Response = &drivers.QueryResponse{}
+		for kind, kindConstraints := range toConstraintsByKind(query.Constraints) {
+			queriesByKind[kind] = append(queriesByKind[kind], kindQuery{index: i, constraints: kindConstraints})
 		}
+	}
 
-		kindResults, err := drivers.ToResults(constraintsMap, resultSet)
-		if err != nil {
-			return nil, err
+	traceBuilders := make([]strings.Builder, len(queries))
+
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+
+	for kind, kindQueries := range queriesByKind {
+		query := d.compilers.getQuery(target, kind)
+		if query == nil {
+			// See Query for why this is an error rather than a skipped kind.
+			err := fmt.Errorf("missing Template %q for target %q", kind, target)
+			for _, q := range kindQueries {
+				results[q.index].Err = err
+			}
+			continue
 		}
 
-		results = append(results, kindResults...)
+		for _, q := range kindQueries {
+			if results[q.index].Err != nil {
+				continue
+			}
+
+			idemCtx, idem := withIdempotence(ctx)
+			kindEval := d.evalTemplate(idemCtx, kind, query, &d.partials, target, q.constraints, reviewMaps[q.index], reviewErrs[q.index], cfg)
+			if idem.violated.Load() || kindEval.failed {
+				results[q.index].Response.Uncacheable = true
+			}
+			traceBuilders[q.index].WriteString(kindEval.trace)
+
+			kindResults, err := drivers.ToResults(drivers.KeyMap(q.constraints), kindEval.resultSet)
+			if err != nil {
+				results[q.index].Err = err
+				continue
+			}
 
-		if d.gatherStats || (cfg != nil && cfg.StatsEnabled) {
-			statsEntries = append(statsEntries,
-				&instrumentation.StatsEntry{
-					Scope:    instrumentation.TemplateScope,
-					StatsFor: kind,
-					Stats: []*instrumentation.Stat{
-						{
-							Name:  templateRunTimeNS,
-							Value: uint64(evalEndTime.Nanoseconds()), // nolint: gosec
-							Source: instrumentation.Source{
-								Type:  instrumentation.EngineSourceType,
-								Value: schema.Name,
-							},
-						},
-						{
-							Name:  constraintCountName,
-							Value: len(kindConstraints),
-							Source: instrumentation.Source{
-								Type:  instrumentation.EngineSourceType,
-								Value: schema.Name,
-							},
-						},
-					},
-					Labels: []*instrumentation.Label{
-						{
-							Name:  tracingEnabledLabelName,
-							Value: d.traceEnabled || cfg.TracingEnabled,
-						},
-						{
-							Name:  printEnabledLabelName,
-							Value: d.printEnabled,
-						},
-					},
-				})
+			resp := results[q.index].Response
+			resp.Results = append(resp.Results, kindResults...)
+			resp.TraceEvents = append(resp.TraceEvents, kindEval.traceEvents...)
+			resp.StatsEntries = append(resp.StatsEntries, kindEval.stats...)
 		}
 	}
 
-	traceString := traceBuilder.String()
-	if len(traceString) != 0 {
-		return &drivers.QueryResponse{Results: results, Trace: &traceString, StatsEntries: statsEntries}, nil
+	for i := range results {
+		if results[i].Err != nil {
+			results[i].Response = nil
+			continue
+		}
+
+		if traceBuilders[i].Len() != 0 {
+			traceString := traceBuilders[i].String()
+			results[i].Response.Trace = &traceString
+		}
+	}
+
+	return results
+}
+
+// withEvaluationTimeout returns a context which expires once the Constraints of
+// a Template have been evaluated for as long as cfg allows.
+func (d *Driver) withEvaluationTimeout(ctx context.Context, cfg *reviews.ReviewCfg) (context.Context, context.CancelFunc) {
+	timeout := d.timeout(cfg)
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeout returns how long the Constraints of each Template may be evaluated
+// for, or zero if there is no limit.
+func (d *Driver) timeout(cfg *reviews.ReviewCfg) time.Duration {
+	if cfg.Timeout > 0 {
+		return cfg.Timeout
+	}
+	return d.evaluationTimeout
+}
+
+// timeoutError returns an error wrapping ErrEvaluationTimeout if evaluating
+// kind with evalCtx failed because kind exceeded its timeout rather than
+// because ctx expired. Otherwise returns err unchanged.
+func (d *Driver) timeoutError(ctx, evalCtx context.Context, kind string, cfg *reviews.ReviewCfg, err error) error {
+	if err == nil || ctx.Err() != nil || !errors.Is(evalCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	return fmt.Errorf("%w: evaluating template %q took longer than %v",
+		clienterrors.ErrEvaluationTimeout, kind, d.timeout(cfg))
+}
+
+// templateStats returns the stats for evaluating count Constraints of kind, of
+// which limited exceeded a resource limit.
+func (d *Driver) templateStats(kind string, evalTime time.Duration, count int, timedOut bool, limited int, cfg *reviews.ReviewCfg) *instrumentation.StatsEntry {
+	entry := &instrumentation.StatsEntry{
+		Scope:    instrumentation.TemplateScope,
+		StatsFor: kind,
+		Stats: []*instrumentation.Stat{
+			{
+				Name:  templateRunTimeNS,
+				Value: uint64(evalTime.Nanoseconds()), // nolint: gosec
+				Source: instrumentation.Source{
+					Type:  instrumentation.EngineSourceType,
+					Value: schema.Name,
+				},
+			},
+			{
+				Name:  constraintCountName,
+				Value: count,
+				Source: instrumentation.Source{
+					Type:  instrumentation.EngineSourceType,
+					Value: schema.Name,
+				},
+			},
+		},
+		Labels: []*instrumentation.Label{
+			{
+				Name:  tracingEnabledLabelName,
+				Value: d.traceEnabled || cfg.TracingEnabled,
+			},
+			{
+				Name:  printEnabledLabelName,
+				Value: d.printEnabled,
+			},
+		},
 	}
 
-	return &drivers.QueryResponse{Results: results, StatsEntries: statsEntries}, nil
+	if timedOut {
+		entry.Stats = append(entry.Stats, &instrumentation.Stat{
+			Name:  templateTimeoutName,
+			Value: true,
+			Source: instrumentation.Source{
+				Type:  instrumentation.EngineSourceType,
+				Value: schema.Name,
+			},
+		})
+	}
+
+	if limited > 0 {
+		entry.Stats = append(entry.Stats, &instrumentation.Stat{
+			Name:  templateLimitName,
+			Value: limited,
+			Source: instrumentation.Source{
+				Type:  instrumentation.EngineSourceType,
+				Value: schema.Name,
+			},
+		})
+	}
+
+	return entry
+}
+
+// constraintStats returns the stats for evaluating constraint, which returned
+// violations violations and errCount errors. Entries are for the Constraint's
+// kind, namespace if any, and name joined with "/".
+func (d *Driver) constraintStats(constraint *unstructured.Unstructured, evalTime time.Duration, violations, errCount int, cfg *reviews.ReviewCfg) *instrumentation.StatsEntry {
+	key := drivers.ConstraintKeyFrom(constraint)
+	statsFor := key.Kind + "/" + key.Name
+	if key.Namespace != "" {
+		statsFor = key.Kind + "/" + key.Namespace + "/" + key.Name
+	}
+
+	return &instrumentation.StatsEntry{
+		Scope:    instrumentation.ConstraintScope,
+		StatsFor: statsFor,
+		Stats: []*instrumentation.Stat{
+			{
+				Name:  constraintRunTimeNS,
+				Value: uint64(evalTime.Nanoseconds()), // nolint: gosec
+				Source: instrumentation.Source{
+					Type:  instrumentation.EngineSourceType,
+					Value: schema.Name,
+				},
+			},
+			{
+				Name:  constraintViolationCountName,
+				Value: violations,
+				Source: instrumentation.Source{
+					Type:  instrumentation.EngineSourceType,
+					Value: schema.Name,
+				},
+			},
+			{
+				Name:  constraintErrorCountName,
+				Value: errCount,
+				Source: instrumentation.Source{
+					Type:  instrumentation.EngineSourceType,
+					Value: schema.Name,
+				},
+			},
+		},
+		Labels: []*instrumentation.Label{
+			{
+				Name:  tracingEnabledLabelName,
+				Value: d.traceEnabled || cfg.TracingEnabled,
+			},
+			{
+				Name:  printEnabledLabelName,
+				Value: d.printEnabled,
+			},
+		},
+	}
 }
 
 // Dump returns a string representation of the driver's internal state for debugging.
@@ -420,6 +954,16 @@ func (d *Driver) GetDescriptionForStat(statName string) (string, error) {
 		return templateRunTimeNsDesc, nil
 	case constraintCountName:
 		return constraintCountDescription, nil
+	case templateTimeoutName:
+		return templateTimeoutDescription, nil
+	case templateLimitName:
+		return templateLimitDescription, nil
+	case constraintRunTimeNS:
+		return constraintRunTimeNsDesc, nil
+	case constraintViolationCountName:
+		return constraintViolationCountDescription, nil
+	case constraintErrorCountName:
+		return constraintErrorCountDescription, nil
 	default:
 		return "", fmt.Errorf("unknown stat name")
 	}
@@ -473,28 +1017,56 @@ func requireModuleRules(module *ast.Module, requiredRules map[string]struct{}) e
 	return nil
 }
 
-func toInterfaceMap(obj interface{}) (map[string]interface{}, error) {
+// errorResultSet returns a result for each of constraints with err as the
+// message. Used to report evaluation errors as violations.
+func errorResultSet(err error, constraints []*unstructured.Unstructured) rego.ResultSet {
+	resultSet := make(rego.ResultSet, 0, len(constraints))
+	for _, constraint := range constraints {
+		resultSet = append(resultSet, rego.Result{
+			Bindings: map[string]interface{}{
+				"result": map[string]interface{}{
+					"msg": err.Error(),
+					"key": drivers.ConstraintKeyFrom(constraint).ToMap(),
+				},
+			},
+		})
+	}
+
+	return resultSet
+}
+
+// queryPath returns the Rego query for the data at path.
+func queryPath(path []string) string {
+	b := strings.Builder{}
+	b.WriteString("data")
+	for _, p := range path {
+		b.WriteString(".")
+		b.WriteString(p)
+	}
+
+	return b.String()
+}
+
+// toInterfaceMap round-trips obj through JSON. Also returns the size of obj's
+// JSON encoding.
+func toInterfaceMap(obj interface{}) (map[string]interface{}, int, error) {
 	jsn, err := json.Marshal(obj)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	result := make(map[string]interface{})
 	err = json.Unmarshal(jsn, &result)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return result, nil
+	return result, len(jsn), nil
 }
 
 func toKeySlice(constraints []*unstructured.Unstructured) []interface{} {
 	var keys []interface{}
 	for _, constraint := range constraints {
-		key := drivers.ConstraintKeyFrom(constraint)
-		keys = append(keys, map[string]interface{}{
-			"kind": key.Kind,
-			"name": key.Name,
-		})
+		keys = append(keys, drivers.ConstraintKeyFrom(constraint).ToMap())
 	}
 
 	return keys
@@ -3,11 +3,13 @@ package rego
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/storage"
 	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/topdown/print"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
@@ -23,12 +26,15 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/externaldata/unversioned"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest/cts"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego/schema"
 	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/reviews"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler/handlertest"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/instrumentation"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
 )
 
 const (
@@ -248,6 +254,973 @@ func TestDriver_Query(t *testing.T) {
 	}
 }
 
+// TestDriver_Query_NamespacedConstraints tests that namespaced Constraints which
+// share a name are stored and reported separately.
+func TestDriver_Query_NamespacedConstraints(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	module := `package foo
+
+violation[{"msg": msg}] {
+  msg := input.parameters.msg
+}
+`
+	tmpl := cts.New(cts.OptCRDScope(templates.ScopeNamespaced),
+		cts.OptTargets(cts.Target(cts.MockTargetHandler, module)))
+	if err := d.AddTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("got AddTemplate() error = %v, want nil", err)
+	}
+
+	var constraints []*unstructured.Unstructured
+	for _, namespace := range []string{"bar", "foo"} {
+		constraint := cts.MakeConstraint(t, "Fakes", "constraint", cts.Namespace(namespace),
+			cts.Set(namespace, "spec", "parameters", "msg"))
+		if err := d.AddConstraint(ctx, constraint); err != nil {
+			t.Fatalf("got AddConstraint() error = %v, want nil", err)
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	qr, err := d.Query(ctx, cts.MockTargetHandler, constraints, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("got Query() error = %v, want nil", err)
+	}
+
+	got := make(map[string]string)
+	for _, result := range qr.Results {
+		got[result.Constraint.GetNamespace()] = result.Msg
+	}
+
+	want := map[string]string{"bar": "bar", "foo": "foo"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if err := d.RemoveConstraint(ctx, constraints[1]); err != nil {
+		t.Fatalf("got RemoveConstraint() error = %v, want nil", err)
+	}
+
+	qr, err = d.Query(ctx, cts.MockTargetHandler, constraints, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("got Query() error = %v, want nil", err)
+	}
+
+	if len(qr.Results) != 1 || qr.Results[0].Constraint.GetNamespace() != "bar" {
+		t.Errorf("got results %v after RemoveConstraint, want only namespace %q", qr.Results, "bar")
+	}
+}
+
+// slowModule takes far longer to evaluate than any timeout used in tests.
+const slowModule = `package foo
+
+violation[{"msg": msg}] {
+  n := count([i | numbers.range(1, 10000)[i]; numbers.range(1, 10000)[_]])
+  n < 0
+  msg := "unreachable"
+}
+`
+
+func TestDriver_Query_EvaluationTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New(EvaluationTimeout(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := cts.New(cts.OptName("slows"), cts.OptCRDNames("Slows"),
+		cts.OptTargets(cts.Target(cts.MockTargetHandler, slowModule)))
+	violate := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, AlwaysViolate, ast.RegoV0)))
+
+	for _, tmpl := range []*templates.ConstraintTemplate{slow, violate} {
+		if err := d.AddTemplate(ctx, tmpl); err != nil {
+			t.Fatalf("got AddTemplate() error = %v, want nil", err)
+		}
+	}
+
+	constraints := []*unstructured.Unstructured{
+		cts.MakeConstraint(t, "Slows", "slow"),
+		cts.MakeConstraint(t, "Fakes", "fast"),
+	}
+	for _, constraint := range constraints {
+		if err := d.AddConstraint(ctx, constraint); err != nil {
+			t.Fatalf("got AddConstraint() error = %v, want nil", err)
+		}
+	}
+
+	// The per-query timeout overrides the Driver's default.
+	qr, err := d.Query(ctx, cts.MockTargetHandler, constraints, map[string]interface{}{},
+		reviews.Timeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("got Query() error = %v, want nil", err)
+	}
+
+	got := make(map[string]string)
+	for _, result := range qr.Results {
+		got[result.Constraint.GetName()] = result.Msg
+	}
+
+	if got["fast"] != "always violate" {
+		t.Errorf("got message %q for fast constraint, want %q", got["fast"], "always violate")
+	}
+	if !strings.Contains(got["slow"], clienterrors.ErrEvaluationTimeout.Error()) {
+		t.Errorf("got message %q for slow constraint, want %q", got["slow"], clienterrors.ErrEvaluationTimeout)
+	}
+
+	if !qr.Uncacheable {
+		t.Error("got cacheable response with timeout, want uncacheable")
+	}
+
+	var timeouts []string
+	for _, entry := range qr.StatsEntries {
+		for _, stat := range entry.Stats {
+			if stat.Name == templateTimeoutName {
+				timeouts = append(timeouts, entry.StatsFor)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"Slows"}, timeouts); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// manyViolationsModule returns three violations for every review.
+const manyViolationsModule = `package foo
+
+violation[{"msg": msg}] {
+  x := [1, 2, 3][_]
+  msg := sprintf("violation %v", [x])
+}
+`
+
+func TestDriver_Query_EvaluationLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		arg    Arg
+		module string
+		review map[string]interface{}
+		want   error
+	}{
+		{
+			name:   "within limits",
+			arg:    MaxResults(3),
+			module: manyViolationsModule,
+			review: map[string]interface{}{},
+			want:   nil,
+		},
+		{
+			name:   "too many steps",
+			arg:    MaxEvaluationSteps(1000),
+			module: slowModule,
+			review: map[string]interface{}{},
+			want:   clienterrors.ErrStepLimit,
+		},
+		{
+			name:   "too many results",
+			arg:    MaxResults(2),
+			module: manyViolationsModule,
+			review: map[string]interface{}{},
+			want:   clienterrors.ErrResultLimit,
+		},
+		{
+			name:   "review too large",
+			arg:    MaxReviewSize(10),
+			module: manyViolationsModule,
+			review: map[string]interface{}{"name": "much-longer-than-ten-bytes"},
+			want:   clienterrors.ErrReviewSizeLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			d, err := New(tt.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			limited := cts.New(cts.OptName("limiteds"), cts.OptCRDNames("Limiteds"),
+				cts.OptTargets(cts.Target(cts.MockTargetHandler, tt.module)))
+			violate := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, AlwaysViolate, ast.RegoV0)))
+
+			for _, tmpl := range []*templates.ConstraintTemplate{limited, violate} {
+				if err := d.AddTemplate(ctx, tmpl); err != nil {
+					t.Fatalf("got AddTemplate() error = %v, want nil", err)
+				}
+			}
+
+			constraints := []*unstructured.Unstructured{
+				cts.MakeConstraint(t, "Limiteds", "limited"),
+				cts.MakeConstraint(t, "Fakes", "fast"),
+			}
+			for _, constraint := range constraints {
+				if err := d.AddConstraint(ctx, constraint); err != nil {
+					t.Fatalf("got AddConstraint() error = %v, want nil", err)
+				}
+			}
+
+			qr, err := d.Query(ctx, cts.MockTargetHandler, constraints, tt.review)
+			if err != nil {
+				t.Fatalf("got Query() error = %v, want nil", err)
+			}
+
+			got := make(map[string][]string)
+			for _, result := range qr.Results {
+				got[result.Constraint.GetName()] = append(got[result.Constraint.GetName()], result.Msg)
+			}
+
+			var limitedKinds []string
+			for _, entry := range qr.StatsEntries {
+				for _, stat := range entry.Stats {
+					if stat.Name == templateLimitName {
+						limitedKinds = append(limitedKinds, entry.StatsFor)
+					}
+				}
+			}
+
+			if tt.want == nil {
+				if len(got["limited"]) != 3 {
+					t.Errorf("got messages %v for limited constraint, want 3 violations", got["limited"])
+				}
+				if len(limitedKinds) != 0 {
+					t.Errorf("got limit stats for %v, want none", limitedKinds)
+				}
+				return
+			}
+
+			if len(got["limited"]) != 1 || !strings.Contains(got["limited"][0], tt.want.Error()) {
+				t.Errorf("got messages %v for limited constraint, want one containing %q", got["limited"], tt.want)
+			}
+
+			// Only the review size limit applies to every Template.
+			if errors.Is(tt.want, clienterrors.ErrReviewSizeLimit) {
+				if len(got["fast"]) != 1 || !strings.Contains(got["fast"][0], tt.want.Error()) {
+					t.Errorf("got messages %v for fast constraint, want one containing %q", got["fast"], tt.want)
+				}
+				if diff := cmp.Diff([]string{"Fakes", "Limiteds"}, limitedKinds, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Error(diff)
+				}
+				return
+			}
+
+			if diff := cmp.Diff([]string{"always violate"}, got["fast"]); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff([]string{"Limiteds"}, limitedKinds); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// constraintStatsModule returns as many violations as each Constraint's
+// "violations" parameter, and fails to evaluate for Constraints with "fail"
+// set.
+const constraintStatsModule = `package foo
+
+conflict := 1 { input.parameters.fail }
+conflict := 2 { input.parameters.fail }
+
+violation[{"msg": msg}] {
+  i := [1, 2, 3][_]
+  i <= input.parameters.violations
+  msg := sprintf("violation %v", [i])
+}
+
+violation[{"msg": "unreachable"}] {
+  conflict == 3
+}
+`
+
+func TestDriver_Query_ConstraintStats(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New(GatherConstraintStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := cts.New(cts.OptTargets(cts.Target(cts.MockTargetHandler, constraintStatsModule)))
+	if err := d.AddTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("got AddTemplate() error = %v, want nil", err)
+	}
+
+	constraints := []*unstructured.Unstructured{
+		cts.MakeConstraint(t, "Fakes", "none", cts.Set(int64(0), "spec", "parameters", "violations")),
+		cts.MakeConstraint(t, "Fakes", "two", cts.Set(int64(2), "spec", "parameters", "violations")),
+		cts.MakeConstraint(t, "Fakes", "failing", cts.Set(true, "spec", "parameters", "fail")),
+	}
+	for _, constraint := range constraints {
+		if err := d.AddConstraint(ctx, constraint); err != nil {
+			t.Fatalf("got AddConstraint() error = %v, want nil", err)
+		}
+	}
+
+	qr, err := d.Query(ctx, cts.MockTargetHandler, constraints, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("got Query() error = %v, want nil", err)
+	}
+
+	// Evaluating each Constraint on its own keeps the failing Constraint's error
+	// from being reported for the others.
+	gotResults := make(map[string]int)
+	for _, result := range qr.Results {
+		gotResults[result.Constraint.GetName()]++
+	}
+	if diff := cmp.Diff(map[string]int{"two": 2, "failing": 1}, gotResults); diff != "" {
+		t.Error(diff)
+	}
+
+	type counts struct {
+		Violations, Errors int
+	}
+	gotStats := make(map[string]counts)
+	for _, entry := range qr.StatsEntries {
+		if entry.Scope != instrumentation.ConstraintScope {
+			continue
+		}
+
+		var c counts
+		for _, stat := range entry.Stats {
+			switch stat.Name {
+			case constraintViolationCountName:
+				c.Violations, _ = stat.Value.(int)
+			case constraintErrorCountName:
+				c.Errors, _ = stat.Value.(int)
+			case constraintRunTimeNS:
+			default:
+				t.Errorf("got unexpected stat %q for %q", stat.Name, entry.StatsFor)
+			}
+
+			if _, err := d.GetDescriptionForStat(stat.Name); err != nil {
+				t.Errorf("got GetDescriptionForStat(%q) error = %v, want nil", stat.Name, err)
+			}
+		}
+		gotStats[entry.StatsFor] = c
+	}
+
+	wantStats := map[string]counts{
+		"Fakes/none":    {Violations: 0, Errors: 0},
+		"Fakes/two":     {Violations: 2, Errors: 0},
+		"Fakes/failing": {Violations: 0, Errors: 1},
+	}
+	if diff := cmp.Diff(wantStats, gotStats); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDriver_Query_TraceEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []reviews.ReviewOpt
+		wantEvents []string
+		wantTrace  bool
+	}{
+		{
+			name:       "events for every constraint",
+			opts:       []reviews.ReviewOpt{reviews.TraceEvents(true)},
+			wantEvents: []string{"one", "two"},
+			wantTrace:  false,
+		},
+		{
+			name:       "events and trace string",
+			opts:       []reviews.ReviewOpt{reviews.TraceEvents(true), reviews.Tracing(true)},
+			wantEvents: []string{"one", "two"},
+			wantTrace:  true,
+		},
+		{
+			name:       "limited to names",
+			opts:       []reviews.ReviewOpt{reviews.TraceEvents(true), reviews.TraceNames("two")},
+			wantEvents: []string{"two"},
+			wantTrace:  false,
+		},
+		{
+			name:       "limited to kinds",
+			opts:       []reviews.ReviewOpt{reviews.TraceEvents(true), reviews.Tracing(true), reviews.TraceKinds("fakes")},
+			wantEvents: []string{"one", "two"},
+			wantTrace:  true,
+		},
+		{
+			name:       "no matching kinds",
+			opts:       []reviews.ReviewOpt{reviews.TraceEvents(true), reviews.Tracing(true), reviews.TraceKinds("others")},
+			wantEvents: nil,
+			wantTrace:  false,
+		},
+		{
+			name:       "disabled",
+			opts:       nil,
+			wantEvents: nil,
+			wantTrace:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			d, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tmpl := cts.New(cts.OptTargets(cts.Target(cts.MockTargetHandler, constraintStatsModule)))
+			if err := d.AddTemplate(ctx, tmpl); err != nil {
+				t.Fatalf("got AddTemplate() error = %v, want nil", err)
+			}
+
+			constraints := []*unstructured.Unstructured{
+				cts.MakeConstraint(t, "Fakes", "one", cts.Set(int64(1), "spec", "parameters", "violations")),
+				cts.MakeConstraint(t, "Fakes", "two", cts.Set(int64(2), "spec", "parameters", "violations")),
+			}
+			for _, constraint := range constraints {
+				if err := d.AddConstraint(ctx, constraint); err != nil {
+					t.Fatalf("got AddConstraint() error = %v, want nil", err)
+				}
+			}
+
+			qr, err := d.Query(ctx, cts.MockTargetHandler, constraints, map[string]interface{}{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("got Query() error = %v, want nil", err)
+			}
+
+			if len(qr.Results) != 3 {
+				t.Errorf("got %d results, want 3", len(qr.Results))
+			}
+
+			if gotTrace := qr.Trace != nil; gotTrace != tt.wantTrace {
+				t.Errorf("got trace %t, want %t", gotTrace, tt.wantTrace)
+			}
+
+			// Each Constraint's violations bind msg, so its events record its
+			// values.
+			tracedNames := make(map[string]bool)
+			msgs := make(map[string]bool)
+			for _, event := range qr.TraceEvents {
+				tracedNames[event.Constraint.Name] = true
+				if msg, ok := event.Locals["msg"].(string); ok {
+					msgs[event.Constraint.Name+": "+msg] = true
+				}
+			}
+
+			var gotEvents []string
+			for name := range tracedNames {
+				gotEvents = append(gotEvents, name)
+			}
+			sort.Strings(gotEvents)
+			if diff := cmp.Diff(tt.wantEvents, gotEvents); diff != "" {
+				t.Error(diff)
+			}
+
+			for _, name := range tt.wantEvents {
+				if want := name + ": violation 1"; !msgs[want] {
+					t.Errorf("got no event with local msg for %q", want)
+				}
+			}
+
+			if _, err := json.Marshal(qr.TraceEvents); err != nil {
+				t.Errorf("got json.Marshal() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// TestDriver_Query_PreparedQueries tests that queries prepared when Templates
+// are added are traced per call, and are replaced and dropped along with their
+// Templates.
+func TestDriver_Query_PreparedQueries(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, AlwaysViolate, ast.RegoV0)))
+	if err := d.AddTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("got AddTemplate() error = %v, want nil", err)
+	}
+
+	constraint := cts.MakeConstraint(t, "Fakes", "foo")
+	if err := d.AddConstraint(ctx, constraint); err != nil {
+		t.Fatalf("got AddConstraint() error = %v, want nil", err)
+	}
+
+	query := func(opts ...reviews.ReviewOpt) *drivers.QueryResponse {
+		t.Helper()
+
+		qr, err := d.Query(ctx, cts.MockTargetHandler, []*unstructured.Unstructured{constraint},
+			map[string]interface{}{}, opts...)
+		if err != nil {
+			t.Fatalf("got Query() error = %v, want nil", err)
+		}
+		return qr
+	}
+
+	if qr := query(reviews.Tracing(true)); qr.Trace == nil {
+		t.Error("got no trace with tracing enabled, want trace")
+	}
+	if qr := query(); qr.Trace != nil {
+		t.Errorf("got trace %q with tracing disabled, want none", *qr.Trace)
+	}
+
+	replaced := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, `package foobar
+
+violation[{"msg": "replaced"}] {
+	true
+}`, ast.RegoV0)))
+	if err := d.AddTemplate(ctx, replaced); err != nil {
+		t.Fatalf("got AddTemplate() error = %v, want nil", err)
+	}
+
+	qr := query()
+	if len(qr.Results) != 1 || qr.Results[0].Msg != "replaced" {
+		t.Errorf("got results %v, want the replaced Template's violation", qr.Results)
+	}
+
+	if err := d.RemoveTemplate(ctx, replaced); err != nil {
+		t.Fatalf("got RemoveTemplate() error = %v, want nil", err)
+	}
+
+	if got := d.compilers.getQuery(cts.MockTargetHandler, "Fakes"); got != nil {
+		t.Error("got prepared query for removed Template, want none")
+	}
+
+	_, err = d.Query(ctx, cts.MockTargetHandler, []*unstructured.Unstructured{constraint}, map[string]interface{}{})
+	if err == nil {
+		t.Error("got Query() error = nil for removed Template, want error")
+	}
+}
+
+// TestDriver_PartialEvaluation tests that Constraints evaluated with residuals
+// get the same results, traces and print output as those evaluated through the
+// hook module.
+func TestDriver_PartialEvaluation(t *testing.T) {
+	testCases := []struct {
+		name         string
+		printEnabled bool
+		externalData bool
+		module       string
+		inventory    map[string]interface{}
+		reviews      []map[string]interface{}
+		wantResidual bool
+	}{
+		{
+			name: "parameters",
+			module: `package foo
+
+violation[{"msg": msg, "details": {"want": input.parameters.wantData}}] {
+  input.review.data != input.parameters.wantData
+  msg := sprintf("got %v", [input.review.data])
+}`,
+			reviews:      []map[string]interface{}{{"data": "bar"}, {"data": "foo"}},
+			wantResidual: true,
+		},
+		{
+			name: "functions",
+			module: `package foo
+
+wanted(value) {
+  value == input.parameters.wantData
+}
+
+violation[{"msg": msg}] {
+  not wanted(input.review.data)
+  msg := sprintf("got %v", [input.review.data])
+}`,
+			reviews:      []map[string]interface{}{{"data": "bar"}, {"data": "foo"}},
+			wantResidual: true,
+		},
+		{
+			name: "conflicting function outputs",
+			module: `package foo
+
+message(arg) = output {
+  output := 7
+}
+
+message(arg) = output {
+  output := 5
+}
+
+violation[{"msg": msg}] {
+  result := message(input.parameters.wantData)
+  msg := sprintf("result is %v", [result])
+}`,
+			reviews:      []map[string]interface{}{{"data": "foo"}},
+			wantResidual: true,
+		},
+		{
+			name: "conflicting complete rules",
+			module: `package foo
+
+wanted = input.parameters.wantData
+
+wanted = input.review.data
+
+violation[{"msg": msg}] {
+  msg := sprintf("want %v", [wanted])
+}`,
+			reviews:      []map[string]interface{}{{"data": "bar"}, {"data": "foo"}},
+			wantResidual: true,
+		},
+		{
+			name: "referential data",
+			module: `package foo
+
+violation[{"msg": msg}] {
+  data.inventory[input.parameters.wantData][input.review.data]
+  msg := "duplicate"
+}`,
+			inventory:    map[string]interface{}{"bar": map[string]interface{}{"foo": true}},
+			reviews:      []map[string]interface{}{{"data": "foo"}, {"data": "qux"}},
+			wantResidual: true,
+		},
+		{
+			name:         "external data",
+			externalData: true,
+			module: `package foo
+
+violation[{"msg": msg}] {
+  response := external_data({"provider": "dummy-provider", "keys": [input.parameters.wantData, input.review.data]})
+  item := response.responses[_]
+  item[1] == "denied"
+  msg := sprintf("%v is denied", [item[0]])
+}`,
+			reviews: []map[string]interface{}{{"data": "foo"}, {"data": "qux"}},
+			// Providers must be called for each review, so their responses are
+			// refreshed and their failures reported.
+			wantResidual: false,
+		},
+		{
+			name: "duplicate violations",
+			module: `package foo
+
+violation[{"msg": "found"}] {
+  input.review.items[_] == input.parameters.wantData
+}`,
+			reviews:      []map[string]interface{}{{"items": []interface{}{"bar", "bar", "foo"}}},
+			wantResidual: true,
+		},
+		{
+			name:         "print statements",
+			printEnabled: true,
+			module: `package foo
+
+violation[{"msg": "denied"}] {
+  print(input.parameters.wantData)
+  input.review.data != input.parameters.wantData
+}`,
+			reviews:      []map[string]interface{}{{"data": "foo"}},
+			wantResidual: false,
+		},
+	}
+
+	// observed is everything a Driver returns for a test case's reviews.
+	type observed struct {
+		Results     [][]*types.Result
+		Traces      []string
+		TraceEvents [][]*types.TraceEvent
+		Printed     []string
+		// Requests are the keys sent to the external data provider.
+		Requests [][]string
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			query := func(partialEvaluation bool) *observed {
+				t.Helper()
+
+				got := &observed{}
+
+				args := []Arg{
+					PrintEnabled(tc.printEnabled),
+					PrintHook(appendingPrintHook{printed: &got.Printed}),
+					PartialEvaluation(partialEvaluation),
+				}
+				if tc.externalData {
+					args = append(args, AddExternalDataProviderCache(externaldata.NewCache()))
+				}
+
+				d, err := New(args...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if tc.externalData {
+					err = d.providerCache.Upsert(&unversioned.Provider{
+						ObjectMeta: metav1.ObjectMeta{Name: "dummy-provider"},
+						Spec:       unversioned.ProviderSpec{URL: "https://example.com", Timeout: 1, CABundle: caBundle},
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					d.sendRequestToProvider = func(_ context.Context, _ *unversioned.Provider, keys []string, _ *tls.Certificate) (*externaldata.ProviderResponse, int, error) {
+						got.Requests = append(got.Requests, keys)
+
+						resp := &externaldata.ProviderResponse{Response: externaldata.Response{Idempotent: true}}
+						for _, key := range keys {
+							value := "allowed"
+							if key == "foo" {
+								value = "denied"
+							}
+							resp.Response.Items = append(resp.Response.Items, externaldata.Item{Key: key, Value: value})
+						}
+						return resp, http.StatusOK, nil
+					}
+				}
+
+				tmpl := cts.New(cts.OptTargets(cts.Target(cts.MockTargetHandler, tc.module)))
+				if err := d.AddTemplate(ctx, tmpl); err != nil {
+					t.Fatalf("got AddTemplate() error = %v, want nil", err)
+				}
+
+				constraint := cts.MakeConstraint(t, "Fakes", "foo", cts.WantData("bar"))
+				if err := d.AddConstraint(ctx, constraint); err != nil {
+					t.Fatalf("got AddConstraint() error = %v, want nil", err)
+				}
+
+				// Referential data is written after Constraints, so residuals must
+				// not depend on it.
+				for key, value := range tc.inventory {
+					if err := d.AddData(ctx, cts.MockTargetHandler, []string{key}, value); err != nil {
+						t.Fatalf("got AddData() error = %v, want nil", err)
+					}
+				}
+
+				_, gotResidual := d.partials.residuals[cts.MockTargetHandler][drivers.ConstraintKeyFrom(constraint)]
+				if partialEvaluation && gotResidual != tc.wantResidual {
+					t.Errorf("got residual %t, want %t", gotResidual, tc.wantResidual)
+				}
+
+				for _, review := range tc.reviews {
+					qr, err := d.Query(ctx, cts.MockTargetHandler, []*unstructured.Unstructured{constraint}, review)
+					if err != nil {
+						t.Fatalf("got Query() error = %v, want nil", err)
+					}
+					got.Results = append(got.Results, qr.Results)
+
+					qr, err = d.Query(ctx, cts.MockTargetHandler, []*unstructured.Unstructured{constraint}, review,
+						reviews.Tracing(true), reviews.TraceEvents(true))
+					if err != nil {
+						t.Fatalf("got traced Query() error = %v, want nil", err)
+					}
+					if qr.Trace == nil {
+						t.Fatal("got nil trace, want trace")
+					}
+					got.Traces = append(got.Traces, *qr.Trace)
+					got.TraceEvents = append(got.TraceEvents, qr.TraceEvents)
+				}
+
+				return got
+			}
+
+			want := query(false)
+			got := query(true)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDriver_PartialEvaluationTimeout(t *testing.T) {
+	// Counting the pairs makes partially evaluating the Template take several
+	// milliseconds.
+	module := `package foo
+
+violation[{"msg": "denied"}] {
+  count([i | numbers.range(1, 300)[i]; numbers.range(1, 300)[_]]) > 0
+  input.review.data != input.parameters.wantData
+}`
+
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantErr      error
+		wantResidual bool
+	}{
+		{
+			name:    "zero",
+			timeout: 0,
+			wantErr: clienterrors.ErrCreatingDriver,
+		},
+		{
+			name:         "exceeded",
+			timeout:      time.Nanosecond,
+			wantResidual: false,
+		},
+		{
+			name:         "not exceeded",
+			timeout:      time.Minute,
+			wantResidual: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			d, err := New(PartialEvaluation(true), PartialEvaluationTimeout(tt.timeout))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got New() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			tmpl := cts.New(cts.OptTargets(cts.Target(cts.MockTargetHandler, module)))
+			if err := d.AddTemplate(ctx, tmpl); err != nil {
+				t.Fatalf("got AddTemplate() error = %v, want nil", err)
+			}
+
+			constraint := cts.MakeConstraint(t, "Fakes", "foo", cts.WantData("bar"))
+			if err := d.AddConstraint(ctx, constraint); err != nil {
+				t.Fatalf("got AddConstraint() error = %v, want nil", err)
+			}
+
+			_, gotResidual := d.partials.residuals[cts.MockTargetHandler][drivers.ConstraintKeyFrom(constraint)]
+			if gotResidual != tt.wantResidual {
+				t.Errorf("got residual %t, want %t", gotResidual, tt.wantResidual)
+			}
+
+			// Constraints without residuals are evaluated in full instead.
+			qr, err := d.Query(ctx, cts.MockTargetHandler, []*unstructured.Unstructured{constraint},
+				map[string]interface{}{"data": "foo"})
+			if err != nil {
+				t.Fatalf("got Query() error = %v, want nil", err)
+			}
+			if len(qr.Results) != 1 {
+				t.Errorf("got %d results, want 1", len(qr.Results))
+			}
+		})
+	}
+}
+
+// TestDriver_PartialEvaluation_Stale tests that residuals computed for
+// parameters which have since changed are discarded.
+func TestDriver_PartialEvaluation_Stale(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New(PartialEvaluation(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	module := `package foo
+
+violation[{"msg": "denied"}] {
+  input.review.data != input.parameters.wantData
+}`
+	tmpl := cts.New(cts.OptTargets(cts.Target(cts.MockTargetHandler, module)))
+	if err := d.AddTemplate(ctx, tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	constraint := cts.MakeConstraint(t, "Fakes", "foo", cts.WantData("bar"))
+	key := drivers.ConstraintKeyFrom(constraint)
+	targets := []string{cts.MockTargetHandler}
+
+	// Parameters recorded before the Constraint was updated.
+	d.mtx.Lock()
+	stale := d.setPartials(key, map[string]interface{}{"wantData": "bar"})
+	d.mtx.Unlock()
+
+	if err := d.AddConstraint(ctx, cts.MakeConstraint(t, "Fakes", "foo", cts.WantData("qux"))); err != nil {
+		t.Fatal(err)
+	}
+	current := d.partials.residuals[cts.MockTargetHandler][key]
+	if current == nil {
+		t.Fatal("got no residual, want residual")
+	}
+
+	d.addResiduals(ctx, targets, stale)
+	if got := d.partials.residuals[cts.MockTargetHandler][key]; got != current {
+		t.Error("got residual for stale parameters, want residual for current parameters")
+	}
+}
+
+// appendingPrintHook records everything Rego prints.
+type appendingPrintHook struct {
+	printed *[]string
+}
+
+func (a appendingPrintHook) Print(_ print.Context, s string) error {
+	*a.printed = append(*a.printed, s)
+	return nil
+}
+
+// TestDriver_QueryBatch tests that QueryBatch returns the same results as
+// calling Query for each query in the batch.
+func TestDriver_QueryBatch(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violate := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, AlwaysViolate, ast.RegoV0)))
+	never := cts.New(cts.OptName("nevers"), cts.OptCRDNames("Nevers"),
+		cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, NeverViolate, ast.RegoV0)))
+	for _, tmpl := range []*templates.ConstraintTemplate{violate, never} {
+		if err := d.AddTemplate(ctx, tmpl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakes1 := cts.MakeConstraint(t, "Fakes", "foo-1")
+	fakes2 := cts.MakeConstraint(t, "Fakes", "foo-2")
+	nevers := cts.MakeConstraint(t, "Nevers", "foo-1")
+	missing := cts.MakeConstraint(t, "Missing", "foo-1")
+	for _, constraint := range []*unstructured.Unstructured{fakes1, fakes2, nevers} {
+		if err := d.AddConstraint(ctx, constraint); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := []drivers.BatchQuery{
+		{Constraints: []*unstructured.Unstructured{fakes1, fakes2, nevers}, Review: map[string]interface{}{"hi": "there"}},
+		{Constraints: nil, Review: map[string]interface{}{"hi": "there"}},
+		{Constraints: []*unstructured.Unstructured{nevers}, Review: map[string]interface{}{"foo": "bar"}},
+		{Constraints: []*unstructured.Unstructured{fakes1, missing}, Review: map[string]interface{}{"foo": "bar"}},
+		{Constraints: []*unstructured.Unstructured{fakes2}, Review: map[string]interface{}{"foo": "qux"}},
+	}
+
+	opts := []reviews.ReviewOpt{reviews.Stats(true)}
+	got := d.QueryBatch(ctx, cts.MockTargetHandler, queries, opts...)
+	if len(got) != len(queries) {
+		t.Fatalf("got %d results, want %d", len(got), len(queries))
+	}
+
+	sortResults := cmpopts.SortSlices(func(a, b *types.Result) bool {
+		return a.Constraint.GetKind()+a.Constraint.GetName() < b.Constraint.GetKind()+b.Constraint.GetName()
+	})
+	ignoreStatValues := cmpopts.IgnoreFields(instrumentation.Stat{}, "Value")
+	sortStats := cmpopts.SortSlices(func(a, b *instrumentation.StatsEntry) bool {
+		return a.StatsFor < b.StatsFor
+	})
+
+	for i, query := range queries {
+		want, wantErr := d.Query(ctx, cts.MockTargetHandler, query.Constraints, query.Review, opts...)
+		if (wantErr == nil) != (got[i].Err == nil) {
+			t.Errorf("query %d: got error %v, want %v", i, got[i].Err, wantErr)
+		}
+
+		if diff := cmp.Diff(want, got[i].Response, sortResults, sortStats, ignoreStatValues); diff != "" {
+			t.Errorf("query %d: %s", i, diff)
+		}
+	}
+}
+
 // TestDriver_Query_Stats tests that StatsEntries are returned for both
 // violating and non violating constraints. It tests both the QueryOpt.Stats()
 // and the GatherStats() modes.
@@ -733,12 +1706,14 @@ func TestDriver_ExternalData(t *testing.T) {
 		clientKeyContent      string
 		sendRequestToProvider externaldata.SendRequestToProvider
 		errorExpected         bool
+		wantUncacheable       bool
 	}{
 		{
 			name:              "provider not found",
 			clientCertContent: clientCert,
 			clientKeyContent:  clientKey,
 			errorExpected:     true,
+			wantUncacheable:   true,
 		},
 		{
 			name: "error from SendRequestToProvider",
@@ -757,7 +1732,8 @@ func TestDriver_ExternalData(t *testing.T) {
 			sendRequestToProvider: func(_ context.Context, _ *unversioned.Provider, _ []string, _ *tls.Certificate) (*externaldata.ProviderResponse, int, error) {
 				return nil, http.StatusBadRequest, errors.New("error from SendRequestToProvider")
 			},
-			errorExpected: true,
+			errorExpected:   true,
+			wantUncacheable: true,
 		},
 		{
 			name: "valid response",
@@ -789,93 +1765,131 @@ func TestDriver_ExternalData(t *testing.T) {
 				}, http.StatusOK, nil
 			},
 		},
+		{
+			name: "non-idempotent response",
+			provider: &unversioned.Provider{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "dummy-provider",
+				},
+				Spec: unversioned.ProviderSpec{
+					URL:      "https://example.com",
+					Timeout:  1,
+					CABundle: caBundle,
+				},
+			},
+			clientCertContent: clientCert,
+			clientKeyContent:  clientKey,
+			sendRequestToProvider: func(_ context.Context, _ *unversioned.Provider, _ []string, _ *tls.Certificate) (*externaldata.ProviderResponse, int, error) {
+				return &externaldata.ProviderResponse{
+					APIVersion: "v1beta1",
+					Kind:       "Provider",
+					Response: externaldata.Response{
+						Items: []externaldata.Item{
+							{
+								Key:   "key",
+								Value: "key_valid",
+							},
+						},
+					},
+				}, http.StatusOK, nil
+			},
+			wantUncacheable: true,
+		},
 	} {
-		t.Run(tt.name, func(t *testing.T) {
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-
-			clientCertFile, err := os.CreateTemp("", "client-cert")
-			if err != nil {
-				t.Fatal(err)
-			}
-			defer func() { _ = os.Remove(clientCertFile.Name()) }()
-
-			_, _ = clientCertFile.WriteString(tt.clientCertContent)
-			_ = clientCertFile.Close()
-
-			clientKeyFile, err := os.CreateTemp("", "client-key")
-			if err != nil {
-				t.Fatal(err)
-			}
-			defer func() { _ = os.Remove(clientKeyFile.Name()) }()
+		// external_data must be called for each review, not when Constraints are
+		// partially evaluated.
+		for _, partialEvaluation := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s partial evaluation %t", tt.name, partialEvaluation), func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				clientCertFile, err := os.CreateTemp("", "client-cert")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() { _ = os.Remove(clientCertFile.Name()) }()
 
-			_, _ = clientKeyFile.WriteString(tt.clientKeyContent)
-			_ = clientKeyFile.Close()
+				_, _ = clientCertFile.WriteString(tt.clientCertContent)
+				_ = clientCertFile.Close()
 
-			clientCertWatcher, err := certwatcher.New(clientCertFile.Name(), clientKeyFile.Name())
-			if err != nil {
-				t.Fatal(err)
-			}
+				clientKeyFile, err := os.CreateTemp("", "client-key")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() { _ = os.Remove(clientKeyFile.Name()) }()
 
-			go func() {
-				_ = clientCertWatcher.Start(ctx)
-			}()
+				_, _ = clientKeyFile.WriteString(tt.clientKeyContent)
+				_ = clientKeyFile.Close()
 
-			d, err := New(
-				AddExternalDataProviderCache(externaldata.NewCache()),
-				AddExternalDataProviderResponseCache(externaldata.NewProviderResponseCache(context.Background(), 1*time.Minute)),
-				EnableExternalDataClientAuth(),
-				AddExternalDataClientCertWatcher(clientCertWatcher),
-			)
-			if err != nil {
-				t.Fatal(err)
-			}
+				clientCertWatcher, err := certwatcher.New(clientCertFile.Name(), clientKeyFile.Name())
+				if err != nil {
+					t.Fatal(err)
+				}
 
-			if tt.provider != nil {
-				if err := d.providerCache.Upsert(tt.provider); err != nil {
+				go func() {
+					_ = clientCertWatcher.Start(ctx)
+				}()
+
+				d, err := New(
+					AddExternalDataProviderCache(externaldata.NewCache()),
+					AddExternalDataProviderResponseCache(externaldata.NewProviderResponseCache(context.Background(), 1*time.Minute)),
+					EnableExternalDataClientAuth(),
+					AddExternalDataClientCertWatcher(clientCertWatcher),
+					PartialEvaluation(partialEvaluation),
+				)
+				if err != nil {
 					t.Fatal(err)
 				}
-			}
 
-			if tt.sendRequestToProvider != nil {
-				d.sendRequestToProvider = tt.sendRequestToProvider
-			}
+				if tt.provider != nil {
+					if err := d.providerCache.Upsert(tt.provider); err != nil {
+						t.Fatal(err)
+					}
+				}
 
-			regoModules := map[ast.RegoVersion]string{
-				ast.RegoV0: ExternalData,
-				ast.RegoV1: ExternalDataV1,
-			}
+				if tt.sendRequestToProvider != nil {
+					d.sendRequestToProvider = tt.sendRequestToProvider
+				}
 
-			for version, module := range regoModules {
-				t.Run(version.String(), func(t *testing.T) {
-					tmpl := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, module, version)))
+				regoModules := map[ast.RegoVersion]string{
+					ast.RegoV0: ExternalData,
+					ast.RegoV1: ExternalDataV1,
+				}
 
-					if err := d.AddTemplate(ctx, tmpl); err != nil {
-						t.Fatalf("got AddTemplate() error = %v, want %v", err, nil)
-					}
+				for version, module := range regoModules {
+					t.Run(version.String(), func(t *testing.T) {
+						tmpl := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, module, version)))
 
-					if err := d.AddConstraint(ctx, cts.MakeConstraint(t, "Fakes", "foo-1")); err != nil {
-						t.Fatalf("got AddConstraint() error = %v, want %v", err, nil)
-					}
+						if err := d.AddTemplate(ctx, tmpl); err != nil {
+							t.Fatalf("got AddTemplate() error = %v, want %v", err, nil)
+						}
 
-					qr, err := d.Query(
-						ctx,
-						cts.MockTargetHandler,
-						[]*unstructured.Unstructured{cts.MakeConstraint(t, "Fakes", "foo-1")},
-						map[string]interface{}{"hi": "there"},
-					)
-					if err != nil {
-						t.Fatalf("got Query() error = %v, want %v", err, nil)
-					}
-					if tt.errorExpected && len(qr.Results) == 0 {
-						t.Fatalf("got 0 errors on normal query; want 1")
-					}
-					if !tt.errorExpected && len(qr.Results) > 0 {
-						t.Fatalf("got %d errors on normal query; want 0", len(qr.Results))
-					}
-				})
-			}
-		})
+						if err := d.AddConstraint(ctx, cts.MakeConstraint(t, "Fakes", "foo-1")); err != nil {
+							t.Fatalf("got AddConstraint() error = %v, want %v", err, nil)
+						}
+
+						qr, err := d.Query(
+							ctx,
+							cts.MockTargetHandler,
+							[]*unstructured.Unstructured{cts.MakeConstraint(t, "Fakes", "foo-1")},
+							map[string]interface{}{"hi": "there"},
+						)
+						if err != nil {
+							t.Fatalf("got Query() error = %v, want %v", err, nil)
+						}
+						if tt.errorExpected && len(qr.Results) == 0 {
+							t.Fatalf("got 0 errors on normal query; want 1")
+						}
+						if !tt.errorExpected && len(qr.Results) > 0 {
+							t.Fatalf("got %d errors on normal query; want 0", len(qr.Results))
+						}
+						if qr.Uncacheable != tt.wantUncacheable {
+							t.Errorf("got Uncacheable = %t, want %t", qr.Uncacheable, tt.wantUncacheable)
+						}
+					})
+				}
+			})
+		}
 	}
 }
 
@@ -1437,6 +2451,170 @@ func TestDriver_RemoveData_StorageErrors(t *testing.T) {
 	}
 }
 
+func TestDriver_AddDataBatch(t *testing.T) {
+	ctx := context.Background()
+
+	s := inmem.New()
+	d, err := New(Storage(map[string]storage.Store{handlertest.TargetName: s}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []drivers.DataItem{
+		{Path: []string{"foo"}, Data: "bar"},
+		{Path: []string{"foo"}, Data: "qux"},
+		{Path: []string{"a", "b"}, Data: map[string]interface{}{"c": "d"}},
+	}
+
+	errs := d.AddDataBatch(ctx, handlertest.TargetName, items)
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("got AddDataBatch() error for item %d = %v, want nil", i, err)
+		}
+	}
+
+	txn, err := s.NewTransaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Abort(ctx, txn)
+
+	got, err := s.Read(ctx, txn, inventoryPath(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"foo": "qux",
+		"a":   map[string]interface{}{"b": map[string]interface{}{"c": "d"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDriver_AddDataBatch_StorageErrors(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New(Storage(map[string]storage.Store{
+		handlertest.TargetName: &transactionErrorStorage{},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []drivers.DataItem{
+		{Path: []string{"foo"}, Data: "bar"},
+		{Path: []string{"qux"}, Data: "bar"},
+	}
+
+	errs := d.AddDataBatch(ctx, handlertest.TargetName, items)
+	for i, err := range errs {
+		if !errors.Is(err, clienterrors.ErrTransaction) {
+			t.Errorf("got AddDataBatch() error for item %d = %v, want %v",
+				i, err, clienterrors.ErrTransaction)
+		}
+	}
+}
+
+func TestDriver_DataTransaction(t *testing.T) {
+	tcs := []struct {
+		name   string
+		commit bool
+		// failCommit makes committing target "c" fail, after "a" and "b" have
+		// committed.
+		failCommit bool
+		want       map[string]interface{}
+	}{
+		{
+			name:   "commit",
+			commit: true,
+			want:   map[string]interface{}{"a": "added", "b": nil, "c": "added"},
+		},
+		{
+			name:   "abort",
+			commit: false,
+			want:   map[string]interface{}{"a": nil, "b": "before", "c": "before"},
+		},
+		{
+			name:       "commit error reverts committed targets",
+			commit:     true,
+			failCommit: true,
+			want:       map[string]interface{}{"a": nil, "b": "before", "c": "before"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := []string{"foo"}
+
+			c := &commitFailingStore{Store: inmem.New()}
+			stores := map[string]storage.Store{"a": inmem.New(), "b": inmem.New(), "c": c}
+			d, err := New(Storage(stores))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, target := range []string{"b", "c"} {
+				err = d.AddData(ctx, target, path, "before")
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			c.fail = tc.failCommit
+
+			txn, err := d.NewDataTransaction(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = txn.AddData(ctx, "a", path, "added")
+			if err != nil {
+				t.Fatalf("got AddData() error = %v, want nil", err)
+			}
+			err = txn.RemoveData(ctx, "b", path)
+			if err != nil {
+				t.Fatalf("got RemoveData() error = %v, want nil", err)
+			}
+			err = txn.AddData(ctx, "c", path, "added")
+			if err != nil {
+				t.Fatalf("got AddData() error = %v, want nil", err)
+			}
+
+			if tc.commit {
+				err = txn.Commit(ctx)
+				if tc.failCommit != errors.Is(err, clienterrors.ErrTransaction) {
+					t.Fatalf("got Commit() error = %v, want failure %t", err, tc.failCommit)
+				}
+			} else {
+				txn.Abort(ctx)
+			}
+
+			for target, want := range tc.want {
+				store := stores[target]
+				readTxn, err := store.NewTransaction(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var got interface{}
+				got, err = store.Read(ctx, readTxn, inventoryPath(path))
+				store.Abort(ctx, readTxn)
+				if storage.IsNotFound(err) {
+					got = nil
+				} else if err != nil {
+					t.Fatal(err)
+				}
+
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("unexpected data for target %q (-want +got):\n%s", target, diff)
+				}
+			}
+		})
+	}
+}
+
 func TestDriver_Externs_Intersection(t *testing.T) {
 	tcs := []struct {
 		name      string
@@ -1558,6 +2736,22 @@ func (s *commitErrorStorage) Commit(_ context.Context, _ storage.Transaction) er
 	return errors.New("error committing changes")
 }
 
+// commitFailingStore is a Store which fails to commit write transactions if
+// fail is set.
+type commitFailingStore struct {
+	storage.Store
+	fail bool
+}
+
+func (s *commitFailingStore) Commit(ctx context.Context, txn storage.Transaction) error {
+	if !s.fail {
+		return s.Store.Commit(ctx, txn)
+	}
+
+	s.Store.Abort(ctx, txn)
+	return errors.New("error committing changes")
+}
+
 type writeErrorStorage struct {
 	fakeStorage
 }
@@ -1695,3 +2889,45 @@ func TestDriver_AddConstraint(t *testing.T) {
 		})
 	}
 }
+
+func TestDriver_ValidateTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	d, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := cts.New()
+	compileErrs, err := d.ValidateTemplate(ctx, valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compileErrs) != 0 {
+		t.Errorf("got compile errors %v for valid template, want none", compileErrs)
+	}
+
+	invalidLib := cts.New(cts.OptTargets(cts.TargetWithVersion(cts.MockTargetHandler, cts.ModuleDeny, ast.RegoV1,
+		`package lib.foo
+
+bar contains x if {
+  x == y
+}`)))
+	compileErrs, err = d.ValidateTemplate(ctx, invalidLib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compileErrs) == 0 {
+		t.Fatal("got no compile errors for invalid library, want errors")
+	}
+	for _, compileErr := range compileErrs {
+		if compileErr.Target != cts.MockTargetHandler || compileErr.Location == nil {
+			t.Errorf("got compile error %+v, want error for target %q with location", compileErr, cts.MockTargetHandler)
+		}
+	}
+
+	// Validating must not add Templates.
+	if len(d.compilers.list()) != 0 {
+		t.Errorf("got compilers %v, want none", d.compilers.list())
+	}
+}
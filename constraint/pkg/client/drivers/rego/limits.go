@@ -0,0 +1,146 @@
+package rego
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
+)
+
+// stepLimiter is a QueryTracer which counts the steps taken by the queries it
+// traces, and stops evaluation once there have been more than max. Every trace
+// event is a step, so iterating over a collection counts each element rather
+// than only the expression doing the iterating.
+//
+// Not threadsafe.
+type stepLimiter struct {
+	max    int
+	steps  int
+	cancel context.CancelFunc
+}
+
+var _ topdown.QueryTracer = &stepLimiter{}
+
+// withStepLimit returns a stepLimiter enforcing the Driver's step limit, and a
+// context which it cancels once evaluation exceeds the limit. Returns a nil
+// stepLimiter if steps are not limited.
+func (d *Driver) withStepLimit(ctx context.Context) (context.Context, context.CancelFunc, *stepLimiter) {
+	if d.maxEvaluationSteps == 0 {
+		return ctx, func() {}, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, &stepLimiter{max: d.maxEvaluationSteps, cancel: cancel}
+}
+
+// Enabled implements topdown.QueryTracer.
+func (l *stepLimiter) Enabled() bool {
+	return true
+}
+
+// Config implements topdown.QueryTracer.
+func (l *stepLimiter) Config() topdown.TraceConfig {
+	return topdown.TraceConfig{}
+}
+
+// TraceEvent implements topdown.QueryTracer.
+func (l *stepLimiter) TraceEvent(topdown.Event) {
+	l.steps++
+	if l.steps > l.max {
+		l.cancel()
+	}
+}
+
+// evalOpts returns the options which make queries count towards l. A nil
+// stepLimiter counts nothing.
+func (l *stepLimiter) evalOpts() []rego.EvalOption {
+	if l == nil {
+		return nil
+	}
+
+	return []rego.EvalOption{rego.EvalQueryTracer(l)}
+}
+
+// err returns an error wrapping ErrStepLimit if evaluating kind was stopped
+// for exceeding l. Otherwise returns err unchanged.
+func (l *stepLimiter) err(kind string, err error) error {
+	if err == nil || l == nil || l.steps <= l.max {
+		return err
+	}
+
+	return fmt.Errorf("%w: evaluating template %q took more than %d steps",
+		clienterrors.ErrStepLimit, kind, l.max)
+}
+
+// checkReviewSize returns an error wrapping ErrReviewSizeLimit if a review
+// whose JSON encoding is size bytes is too large to evaluate.
+func (d *Driver) checkReviewSize(size int) error {
+	if d.maxReviewSize == 0 || size <= d.maxReviewSize {
+		return nil
+	}
+
+	return fmt.Errorf("%w: review is %d bytes, more than the maximum of %d",
+		clienterrors.ErrReviewSizeLimit, size, d.maxReviewSize)
+}
+
+// limitResults replaces the results in resultSet of each of constraints which
+// returned more than the Driver's maximum with a single result wrapping
+// ErrResultLimit. Returns the new ResultSet and the number of Constraints whose
+// results were replaced.
+func (d *Driver) limitResults(resultSet rego.ResultSet, constraints []*unstructured.Unstructured) (rego.ResultSet, int) {
+	if d.maxResults == 0 || len(resultSet) <= d.maxResults {
+		return resultSet, 0
+	}
+
+	counts := make(map[drivers.ConstraintKey]int)
+	for _, r := range resultSet {
+		if key, found := resultKey(r); found {
+			counts[key]++
+		}
+	}
+
+	var limited []*unstructured.Unstructured
+	for _, constraint := range constraints {
+		if counts[drivers.ConstraintKeyFrom(constraint)] > d.maxResults {
+			limited = append(limited, constraint)
+		}
+	}
+	if len(limited) == 0 {
+		return resultSet, 0
+	}
+
+	kept := make(rego.ResultSet, 0, len(resultSet))
+	for _, r := range resultSet {
+		if key, found := resultKey(r); found && counts[key] > d.maxResults {
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	for _, constraint := range limited {
+		err := fmt.Errorf("%w: constraint %q returned more than %d results",
+			clienterrors.ErrResultLimit, constraint.GetName(), d.maxResults)
+		kept = append(kept, errorResultSet(err, []*unstructured.Unstructured{constraint})...)
+	}
+
+	return kept, len(limited)
+}
+
+// resultKey returns the key of the Constraint r is a result for.
+func resultKey(r rego.Result) (drivers.ConstraintKey, bool) {
+	keyMap, found, err := unstructured.NestedStringMap(r.Bindings, "result", "key")
+	if err != nil || !found {
+		return drivers.ConstraintKey{}, false
+	}
+
+	return drivers.ConstraintKey{
+		Kind:      keyMap["kind"],
+		Namespace: keyMap["namespace"],
+		Name:      keyMap["name"],
+	}, true
+}
@@ -21,6 +21,10 @@ func New(args ...Arg) (*Driver, error) {
 		return nil, err
 	}
 
+	// Shadow Templates must compile in the same environment as active ones.
+	d.shadows.externs = d.compilers.externs
+	d.shadows.capabilities = d.compilers.capabilities
+
 	if d.providerCache != nil {
 		rego.RegisterBuiltin1(
 			&rego.Function{
@@ -0,0 +1,435 @@
+package rego
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
+)
+
+// defaultPartialEvaluationTimeout is the longest AddConstraint spends partially
+// evaluating a Template for a Constraint in each target unless the Driver is
+// constructed with PartialEvaluationTimeout.
+const defaultPartialEvaluationTimeout = 100 * time.Millisecond
+
+// partialNamespace is the package under which partial evaluation copies the
+// rules it doesn't inline.
+const partialNamespace = "partial"
+
+// residual is what remains of a Template's violation query once the parameters
+// of one of its Constraints are known.
+type residual struct {
+	// compiler is the Template's compiler the residual was computed with.
+	compiler *ast.Compiler
+
+	// queries are prepared against compiler. Each binds "result" to a
+	// violation of the Constraint in the form hookModule returns.
+	queries []rego.PreparedEvalQuery
+
+	// parameters are the Constraint's parameters. queries may still reference
+	// them, for example from functions which could not be inlined.
+	parameters *ast.Term
+}
+
+// partials stores the parameters of each Constraint along with the residuals
+// of those whose Templates could be partially evaluated for them.
+//
+// Not threadsafe.
+type partials struct {
+	// parameters is a map from each Constraint's key to its parameters.
+	parameters map[drivers.ConstraintKey]*ast.Term
+
+	// residuals is a map from target name to the residual of each Constraint
+	// partially evaluated for that target. Constraints without residuals are
+	// evaluated through hookModule.
+	residuals map[string]map[drivers.ConstraintKey]*residual
+}
+
+// split returns the residuals of the Constraints which have them in target,
+// and the Constraints which do not. A nil partials has no residuals.
+func (p *partials) split(target string, constraints []*unstructured.Unstructured) ([]*residual, []*unstructured.Unstructured) {
+	if p == nil || len(p.residuals[target]) == 0 {
+		return nil, constraints
+	}
+
+	var residuals []*residual
+	var rest []*unstructured.Unstructured
+	for _, constraint := range constraints {
+		if r, found := p.residuals[target][drivers.ConstraintKeyFrom(constraint)]; found {
+			residuals = append(residuals, r)
+		} else {
+			rest = append(rest, constraint)
+		}
+	}
+
+	return residuals, rest
+}
+
+// removeKind removes the residuals of Constraints of kind from every target.
+func (p *partials) removeKind(kind string) {
+	for _, targetResiduals := range p.residuals {
+		for key := range targetResiduals {
+			if key.Kind == kind {
+				delete(targetResiduals, key)
+			}
+		}
+	}
+}
+
+// setPartials records the parameters of the Constraint with key, discarding
+// any residuals of its previous parameters. Returns the parameters to
+// partially evaluate its Template for, keyed by key, or nil if there are none.
+// Callers must hold mtx.
+func (d *Driver) setPartials(key drivers.ConstraintKey, params interface{}) map[drivers.ConstraintKey]*ast.Term {
+	if !d.partialEvaluation {
+		return nil
+	}
+
+	d.removePartials(key)
+
+	value, err := ast.InterfaceToValue(params)
+	if err != nil {
+		// The Constraint is still evaluated through hookModule, which reports
+		// any problem with its parameters.
+		return nil
+	}
+
+	if d.partials.parameters == nil {
+		d.partials.parameters = make(map[drivers.ConstraintKey]*ast.Term)
+		d.partials.residuals = make(map[string]map[drivers.ConstraintKey]*residual)
+	}
+	parameters := ast.NewTerm(value)
+	d.partials.parameters[key] = parameters
+
+	return map[drivers.ConstraintKey]*ast.Term{key: parameters}
+}
+
+// resetPartials discards the residuals of the Constraints of kind, as they
+// were partially evaluated for a previous version of its Template. Returns the
+// parameters of each of the Constraints, to partially evaluate the current
+// version for. Callers must hold mtx.
+func (d *Driver) resetPartials(kind string) map[drivers.ConstraintKey]*ast.Term {
+	d.partials.removeKind(kind)
+
+	var pending map[drivers.ConstraintKey]*ast.Term
+	for key, parameters := range d.partials.parameters {
+		if key.Kind != kind {
+			continue
+		}
+
+		if pending == nil {
+			pending = make(map[drivers.ConstraintKey]*ast.Term)
+		}
+		pending[key] = parameters
+	}
+
+	return pending
+}
+
+// removePartials forgets the Constraint with key. Callers must hold mtx.
+func (d *Driver) removePartials(key drivers.ConstraintKey) {
+	delete(d.partials.parameters, key)
+	for _, targetResiduals := range d.partials.residuals {
+		delete(targetResiduals, key)
+	}
+}
+
+// removeKindPartials forgets every Constraint of kind. Callers must hold mtx.
+func (d *Driver) removeKindPartials(kind string) {
+	for key := range d.partials.parameters {
+		if key.Kind == kind {
+			delete(d.partials.parameters, key)
+		}
+	}
+	d.partials.removeKind(kind)
+}
+
+// addResiduals partially evaluates the Template of each Constraint in pending
+// for its parameters in each of targets, then stores the residuals.
+//
+// Partial evaluation may take up to partialEvaluationTimeout per Constraint and
+// target, so it runs without holding mtx and queries evaluate the Constraints
+// through hookModule until their residuals are stored. Residuals are only
+// stored if the Constraint's parameters and Template have not changed since, so
+// concurrent changes never leave a stale residual. Callers must not hold mtx.
+func (d *Driver) addResiduals(ctx context.Context, targets []string, pending map[drivers.ConstraintKey]*ast.Term) {
+	if len(pending) == 0 {
+		return
+	}
+
+	computed := make(map[string]map[drivers.ConstraintKey]*residual)
+	for _, target := range targets {
+		for key, parameters := range pending {
+			r := d.partiallyEvaluate(ctx, target, key, parameters)
+			if r == nil {
+				continue
+			}
+
+			if computed[target] == nil {
+				computed[target] = make(map[drivers.ConstraintKey]*residual)
+			}
+			computed[target][key] = r
+		}
+	}
+	if len(computed) == 0 {
+		return
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for target, targetComputed := range computed {
+		for key, r := range targetComputed {
+			if d.partials.parameters[key] != r.parameters || d.compilers.getCompiler(target, key.Kind) != r.compiler {
+				continue
+			}
+
+			targetResiduals := d.partials.residuals[target]
+			if targetResiduals == nil {
+				targetResiduals = make(map[drivers.ConstraintKey]*residual)
+				d.partials.residuals[target] = targetResiduals
+			}
+			targetResiduals[key] = r
+		}
+	}
+}
+
+// partiallyEvaluate evaluates the violation query of the Template for key in
+// target as far as possible with parameters, but without the review or
+// referential data. Returns nil if the Template can't be partially evaluated,
+// in which case the Constraint is evaluated through hookModule. Does not
+// require mtx.
+func (d *Driver) partiallyEvaluate(ctx context.Context, target string, key drivers.ConstraintKey, parameters *ast.Term) *residual {
+	compiler := d.compilers.getCompiler(target, key.Kind)
+	if compiler == nil {
+		return nil
+	}
+
+	// Partial evaluation would run print statements once rather than for each
+	// review. It would likewise call external data providers with keys known
+	// from the parameters when the Constraint is added, so their responses would
+	// never be refreshed and failures would not be reported by reviews.
+	if d.printEnabled && calls(compiler, ast.Print.Ref(), ast.InternalPrint.Ref()) {
+		return nil
+	}
+	if d.providerCache != nil && calls(compiler, externalDataRef) {
+		return nil
+	}
+
+	store, err := d.storage.getStorage(ctx, target)
+	if err != nil {
+		return nil
+	}
+
+	query, err := residualQuery(key)
+	if err != nil {
+		return nil
+	}
+
+	input := ast.NewObject(ast.Item(ast.StringTerm("parameters"), parameters))
+
+	// Referential data changes independently of Constraints, so it is left for
+	// each review to read, along with the review itself.
+	unknowns, functions := singleValueRules(compiler)
+	unknowns = append(unknowns, "input.review")
+	unknowns = append(unknowns, d.compilers.externs...)
+
+	peCtx, cancel := context.WithTimeout(ctx, d.partialEvaluationTimeout)
+	defer cancel()
+
+	pq, err := rego.New(
+		rego.Compiler(compiler),
+		rego.Store(store),
+		rego.Query(query),
+		rego.ParsedInput(input),
+		rego.Unknowns(unknowns),
+		rego.DisableInlining(functions),
+		rego.PartialNamespace(partialNamespace),
+		rego.SetRegoVersion(ast.RegoV0),
+	).Partial(peCtx)
+	if err != nil {
+		return nil
+	}
+
+	r := &residual{compiler: compiler, parameters: parameters}
+	for _, body := range pq.Queries {
+		body, ok := withoutSupport(compiler, body)
+		if !ok {
+			return nil
+		}
+
+		prepared, err := rego.New(
+			rego.Compiler(compiler),
+			rego.Store(store),
+			rego.ParsedQuery(body),
+			rego.SetRegoVersion(ast.RegoV0),
+		).PrepareForEval(ctx)
+		if err != nil {
+			return nil
+		}
+
+		r.queries = append(r.queries, prepared)
+	}
+
+	return r
+}
+
+// residualQuery returns the query partially evaluated for the Constraint with
+// key. It binds "result" to each violation as hookModule does.
+func residualQuery(key drivers.ConstraintKey) (string, error) {
+	keyValue, err := ast.InterfaceToValue(key.ToMap())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`data.%s.%s[r]; result = {"key": %v, "details": object.get(r, "details", {}), "msg": r.msg}`,
+		templatePath, violation, keyValue), nil
+}
+
+// singleValueRules returns the paths of the complete rules and of the functions
+// in compiler. Partial evaluation inlines the outputs of these without checking
+// that they agree, so complete rules are left unknown and functions are not
+// inlined. Evaluating the residual then reports conflicting outputs as
+// evaluating the Template directly would.
+func singleValueRules(compiler *ast.Compiler) (complete, functions []string) {
+	seen := make(map[string]bool)
+	for _, module := range compiler.Modules {
+		for _, rule := range module.Rules {
+			if rule.Head.RuleKind() != ast.SingleValue {
+				continue
+			}
+
+			path := rule.Path().GroundPrefix().String()
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			if len(rule.Head.Args) != 0 {
+				functions = append(functions, path)
+			} else {
+				complete = append(complete, path)
+			}
+		}
+	}
+
+	return complete, functions
+}
+
+// withoutSupport replaces references in body to the copies of rules partial
+// evaluation made in its support modules with references to the original rules
+// in compiler. The copies only differ from the originals by having known values
+// substituted, and residuals are evaluated with the same input, so the results
+// are the same. Returns false if body references a support rule which is not a
+// copy, such as those partial evaluation generates for negated expressions.
+func withoutSupport(compiler *ast.Compiler, body ast.Body) (ast.Body, bool) {
+	namespace := ast.DefaultRootRef.Append(ast.StringTerm(partialNamespace))
+
+	ok := true
+	result, err := ast.TransformRefs(body, func(ref ast.Ref) (ast.Value, error) {
+		if !ref.HasPrefix(namespace) {
+			return ref, nil
+		}
+
+		original := ast.DefaultRootRef.Concat(ref[len(namespace):])
+		if len(compiler.GetRules(original)) == 0 {
+			ok = false
+		}
+		return original, nil
+	})
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	body, isBody := result.(ast.Body)
+	return body, isBody
+}
+
+// externalDataRef is the name of the external_data builtin.
+var externalDataRef = ast.Ref{ast.VarTerm("external_data")}
+
+// calls returns true if any of compiler's modules call any of functions.
+func calls(compiler *ast.Compiler, functions ...ast.Ref) bool {
+	found := false
+	for _, module := range compiler.Modules {
+		ast.WalkExprs(module, func(expr *ast.Expr) bool {
+			if expr.IsCall() {
+				operator := expr.Operator()
+				for _, function := range functions {
+					found = found || operator.Equal(function)
+				}
+			}
+			return found
+		})
+	}
+
+	return found
+}
+
+// evalResiduals evaluates residuals against review for target with opts. As
+// with hookModule, each distinct violation of a Constraint is returned once.
+func (d *Driver) evalResiduals(ctx context.Context, target string, residuals []*residual, review map[string]interface{}, opts ...rego.EvalOption) (rego.ResultSet, error) {
+	if len(residuals) == 0 {
+		return nil, nil
+	}
+
+	reviewValue, err := ast.InterfaceToValue(review)
+	if err != nil {
+		return nil, err
+	}
+	reviewTerm := ast.NewTerm(reviewValue)
+
+	store, err := d.storage.getStorage(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Share a single read transaction between residuals, as evaluating them
+	// together through hookModule would.
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
+	}
+	defer store.Abort(ctx, txn)
+
+	var resultSet rego.ResultSet
+	for _, r := range residuals {
+		input := ast.NewObject(
+			ast.Item(ast.StringTerm("review"), reviewTerm),
+			ast.Item(ast.StringTerm("parameters"), r.parameters),
+		)
+
+		seen := make(map[string]bool)
+		for _, query := range r.queries {
+			evalOpts := append([]rego.EvalOption{rego.EvalParsedInput(input), rego.EvalTransaction(txn)}, opts...)
+			rs, err := query.Eval(ctx, evalOpts...)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, result := range rs {
+				b, err := json.Marshal(result.Bindings["result"])
+				if err != nil {
+					return nil, err
+				}
+				if seen[string(b)] {
+					continue
+				}
+				seen[string(b)] = true
+
+				resultSet = append(resultSet, rego.Result{
+					Bindings: map[string]interface{}{"result": result.Bindings["result"]},
+				})
+			}
+		}
+	}
+
+	return resultSet, nil
+}
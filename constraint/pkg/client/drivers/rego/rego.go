@@ -29,7 +29,7 @@ violation[response] {
   # Note: input.review already contains namespaceObject if available (set by driver).
   inp := {
     "review": input.review,
-    "parameters": data.constraints[key.kind][key.name],
+    "parameters": constraint_parameters(key),
   }
   # Run the Template with Constraint.
   data.template.violation[r] with input as inp
@@ -41,6 +41,17 @@ violation[response] {
     "msg": r.msg,
   }
 }
+
+# Parameters of cluster-scoped Constraints.
+constraint_parameters(key) = parameters {
+  not key.namespace
+  parameters := data.constraints[key.kind][key.name]
+}
+
+# Parameters of namespaced Constraints are stored under their namespace.
+constraint_parameters(key) = parameters {
+  parameters := data.constraints[key.kind][key.namespace][key.name]
+}
 `
 )
 
@@ -8,6 +8,7 @@ import (
 	"github.com/open-policy-agent/opa/v1/storage"
 	"github.com/open-policy-agent/opa/v1/storage/inmem"
 
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
 	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
 )
 
@@ -31,6 +32,67 @@ func (d *storages) addData(ctx context.Context, target string, path storage.Path
 	return addData(ctx, store, path, data)
 }
 
+// addDataBatch writes each item to target's store in a single transaction.
+// Returns one error per item. If the transaction cannot be opened or committed,
+// every item is reported as failed.
+func (d *storages) addDataBatch(ctx context.Context, target string, items []drivers.DataItem) []error {
+	errs := make([]error, len(items))
+	if len(items) == 0 {
+		return errs
+	}
+
+	fail := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	store, err := d.getStorage(ctx, target)
+	if err != nil {
+		return fail(err)
+	}
+
+	txn, err := store.NewTransaction(ctx, storage.WriteParams)
+	if err != nil {
+		return fail(fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err))
+	}
+
+	for i, item := range items {
+		errs[i] = writeData(ctx, store, txn, item.Path, item.Data)
+	}
+
+	err = store.Commit(ctx, txn)
+	if err != nil {
+		// inmem.Store automatically aborts the transaction for us.
+		return fail(fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err))
+	}
+
+	return errs
+}
+
+func (d *storages) readData(ctx context.Context, target string, path storage.Path) (interface{}, error) {
+	store, err := d.getStorage(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
+	}
+	defer store.Abort(ctx, txn)
+
+	data, err := store.Read(ctx, txn, path)
+	if storage.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", clienterrors.ErrRead, err)
+	}
+
+	return data, nil
+}
+
 func (d *storages) removeData(ctx context.Context, target string, path storage.Path) error {
 	store, err := d.getStorage(ctx, target)
 	if err != nil {
@@ -108,12 +170,6 @@ func inventoryPath(path []string) storage.Path {
 }
 
 func addData(ctx context.Context, store storage.Store, path storage.Path, data interface{}) error {
-	if len(path) == 0 {
-		// Sanity-check path.
-		// This would overwrite "data", erasing all Constraints and stored objects.
-		return fmt.Errorf("%w: path must contain at least one path element: %+v", clienterrors.ErrPathInvalid, path)
-	}
-
 	// Initiate a new transaction. Since this is a write-transaction, it blocks
 	// all other reads and writes, which includes running queries. If a transaction
 	// is successfully created, all code paths must either Abort or Commit the
@@ -123,35 +179,50 @@ func addData(ctx context.Context, store storage.Store, path storage.Path, data i
 		return fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
 	}
 
+	err = writeData(ctx, store, txn, path, data)
+	if err != nil {
+		store.Abort(ctx, txn)
+		return err
+	}
+
+	err = store.Commit(ctx, txn)
+	if err != nil {
+		return fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
+	}
+
+	return nil
+}
+
+// writeData writes data to path within txn, creating path's parents if they do
+// not already exist. Does not Commit or Abort txn.
+func writeData(ctx context.Context, store storage.Store, txn storage.Transaction, path storage.Path, data interface{}) error {
+	if len(path) == 0 {
+		// Sanity-check path.
+		// This would overwrite "data", erasing all Constraints and stored objects.
+		return fmt.Errorf("%w: path must contain at least one path element: %+v", clienterrors.ErrPathInvalid, path)
+	}
+
 	// We can't write to a location if its parent doesn't exist.
 	// Thus, we check to see if anything already exists at the path.
-	_, err = store.Read(ctx, txn, path)
+	_, err := store.Read(ctx, txn, path)
 	if storage.IsNotFound(err) {
 		// Insert an empty object at the path's parent so its parents are
 		// recursively created.
 		parent := path[:len(path)-1]
 		err = storage.MakeDir(ctx, store, txn, parent)
 		if err != nil {
-			store.Abort(ctx, txn)
 			return fmt.Errorf("%w: unable to make directory: %v", clienterrors.ErrWrite, err)
 		}
 	} else if err != nil {
 		// We weren't able to read from storage - something serious is likely wrong.
-		store.Abort(ctx, txn)
 		return fmt.Errorf("%w: %v", clienterrors.ErrRead, err)
 	}
 
 	err = store.Write(ctx, txn, storage.AddOp, path, data)
 	if err != nil {
-		store.Abort(ctx, txn)
 		return fmt.Errorf("%w: unable to write data: %v", clienterrors.ErrWrite, err)
 	}
 
-	err = store.Commit(ctx, txn)
-	if err != nil {
-		return fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
-	}
-
 	return nil
 }
 
@@ -161,13 +232,10 @@ func removeData(ctx context.Context, store storage.Store, path storage.Path) err
 		return fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
 	}
 
-	err = store.Write(ctx, txn, storage.RemoveOp, path, interface{}(nil))
+	err = deleteData(ctx, store, txn, path)
 	if err != nil {
 		store.Abort(ctx, txn)
-		if storage.IsNotFound(err) {
-			return nil
-		}
-		return fmt.Errorf("%w: unable to remove data: %v", clienterrors.ErrWrite, err)
+		return err
 	}
 
 	err = store.Commit(ctx, txn)
@@ -177,3 +245,14 @@ func removeData(ctx context.Context, store storage.Store, path storage.Path) err
 
 	return nil
 }
+
+// deleteData removes the data at path within txn. Succeeds if nothing exists
+// at path. Does not Commit or Abort txn.
+func deleteData(ctx context.Context, store storage.Store, txn storage.Transaction, path storage.Path) error {
+	err := store.Write(ctx, txn, storage.RemoveOp, path, interface{}(nil))
+	if err != nil && !storage.IsNotFound(err) {
+		return fmt.Errorf("%w: unable to remove data: %v", clienterrors.ErrWrite, err)
+	}
+
+	return nil
+}
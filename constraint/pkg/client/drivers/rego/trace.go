@@ -0,0 +1,89 @@
+package rego
+
+import (
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// toTraceEvents converts the events traced while evaluating constraint.
+func toTraceEvents(constraint *unstructured.Unstructured, events []*topdown.Event) []*types.TraceEvent {
+	traced := types.TracedConstraint{
+		Kind:      constraint.GetKind(),
+		Namespace: constraint.GetNamespace(),
+		Name:      constraint.GetName(),
+	}
+
+	result := make([]*types.TraceEvent, 0, len(events))
+	for _, event := range events {
+		traceEvent := &types.TraceEvent{
+			Constraint: traced,
+			Op:         string(event.Op),
+			QueryID:    event.QueryID,
+			ParentID:   event.ParentID,
+			Locals:     traceLocals(event),
+			Message:    event.Message,
+		}
+
+		if event.Node != nil {
+			traceEvent.Node = event.Node.String()
+		}
+
+		if event.Location != nil {
+			traceEvent.Location = &types.TraceLocation{
+				File: event.Location.File,
+				Row:  event.Location.Row,
+				Col:  event.Location.Col,
+			}
+		}
+
+		result = append(result, traceEvent)
+	}
+
+	return result
+}
+
+// traceLocals returns the values of the variables in event's node, keyed by
+// their names in the Template's code. Variables the compiler generated are
+// omitted. As with PrettyTrace, only the head of rules is considered.
+func traceLocals(event *topdown.Event) map[string]interface{} {
+	if event.Locals == nil || event.Node == nil {
+		return nil
+	}
+
+	var node interface{} = event.Node
+	if rule, isRule := event.Node.(*ast.Rule); isRule {
+		node = rule.Head
+	}
+
+	locals := make(map[string]interface{})
+	ast.WalkVars(node, func(v ast.Var) bool {
+		meta, found := event.LocalMetadata[v]
+		if !found || meta.Name.IsGenerated() || meta.Name.IsWildcard() {
+			return false
+		}
+
+		value := event.Locals.Get(v)
+		if value == nil {
+			return false
+		}
+
+		// Values are converted to JSON so events can be serialized.
+		jsonValue, err := ast.JSON(value)
+		if err != nil {
+			locals[string(meta.Name)] = value.String()
+		} else {
+			locals[string(meta.Name)] = jsonValue
+		}
+
+		return false
+	})
+
+	if len(locals) == 0 {
+		return nil
+	}
+
+	return locals
+}
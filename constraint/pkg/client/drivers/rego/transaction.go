@@ -0,0 +1,218 @@
+package rego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/storage"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
+)
+
+// dataTransaction stages changes to data.inventory in one write transaction
+// per target. Transactions are opened the first time a target is written to.
+type dataTransaction struct {
+	storage *storages
+
+	// targets are the targets with open transactions, in the order the
+	// transactions were opened.
+	targets []string
+	stores  map[string]storage.Store
+	txns    map[string]storage.Transaction
+
+	// undo records how to revert each staged change, in the order the changes
+	// were staged.
+	undo []dataUndo
+}
+
+// dataUndo restores the data at path in target to what it was before a change
+// was staged.
+type dataUndo struct {
+	target string
+	path   storage.Path
+
+	// previous is the data at path before the change, if existed is true.
+	// Stores copy the data written to them, so the change does not modify it.
+	previous interface{}
+	existed  bool
+}
+
+var _ drivers.DataTransaction = &dataTransaction{}
+
+// NewDataTransaction begins staging changes to data.inventory across targets.
+func (d *Driver) NewDataTransaction(_ context.Context) (drivers.DataTransaction, error) {
+	return &dataTransaction{
+		storage: &d.storage,
+		stores:  make(map[string]storage.Store),
+		txns:    make(map[string]storage.Transaction),
+	}, nil
+}
+
+// AddData stages writing data to data.inventory.path for target.
+func (t *dataTransaction) AddData(ctx context.Context, target string, path storage.Path, data interface{}) error {
+	store, txn, err := t.transaction(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	path = inventoryPath(path)
+	err = t.recordUndo(ctx, store, txn, target, path)
+	if err != nil {
+		return err
+	}
+
+	return writeData(ctx, store, txn, path, data)
+}
+
+// RemoveData stages deleting data.inventory.path for target.
+func (t *dataTransaction) RemoveData(ctx context.Context, target string, path storage.Path) error {
+	store, txn, err := t.transaction(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	path = inventoryPath(path)
+	err = t.recordUndo(ctx, store, txn, target, path)
+	if err != nil {
+		return err
+	}
+
+	return deleteData(ctx, store, txn, path)
+}
+
+// recordUndo records the data at path in target, before a change to it is
+// staged in txn.
+func (t *dataTransaction) recordUndo(ctx context.Context, store storage.Store, txn storage.Transaction, target string, path storage.Path) error {
+	u := dataUndo{target: target, path: path}
+
+	previous, err := store.Read(ctx, txn, path)
+	switch {
+	case storage.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("%w: %v", clienterrors.ErrRead, err)
+	default:
+		u.previous, u.existed = previous, true
+	}
+
+	t.undo = append(t.undo, u)
+	return nil
+}
+
+// Commit commits the transaction of each target in the order they were opened.
+//
+// Stores can only commit one target at a time, so if a target fails to commit,
+// the changes staged for the remaining targets are discarded and the changes
+// already committed are reverted. Queries may observe those changes before
+// they are reverted, and writes to the same paths made by others in the meantime
+// are overwritten. If reverting fails too, the returned error says so and
+// the targets which could not be reverted keep their changes.
+func (t *dataTransaction) Commit(ctx context.Context) error {
+	for i, target := range t.targets {
+		err := t.stores[target].Commit(ctx, t.txns[target])
+		if err != nil {
+			// inmem.Store automatically aborts the failed transaction for us.
+			t.abort(ctx, t.targets[i+1:])
+			err = fmt.Errorf("%w: unable to commit data for target %q: %v",
+				clienterrors.ErrTransaction, target, err)
+
+			revertErr := t.revert(ctx, t.targets[:i])
+			t.reset()
+			if revertErr != nil {
+				return fmt.Errorf("%w; unable to revert committed data: %v", err, revertErr)
+			}
+			return err
+		}
+	}
+
+	t.reset()
+	return nil
+}
+
+// revert restores the data changed in each of targets, whose transactions
+// have been committed, in a new transaction per target. Attempts to revert
+// every target even if some fail.
+func (t *dataTransaction) revert(ctx context.Context, targets []string) error {
+	var errs []error
+	for _, target := range targets {
+		err := t.revertTarget(ctx, target)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("target %q: %w", target, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *dataTransaction) revertTarget(ctx context.Context, target string) error {
+	store := t.stores[target]
+	txn, err := store.NewTransaction(ctx, storage.WriteParams)
+	if err != nil {
+		return fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
+	}
+
+	// Reverting the changes in reverse order restores the data from before the
+	// first change to each path.
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		u := t.undo[i]
+		if u.target != target {
+			continue
+		}
+
+		if u.existed {
+			err = writeData(ctx, store, txn, u.path, u.previous)
+		} else {
+			err = deleteData(ctx, store, txn, u.path)
+		}
+		if err != nil {
+			store.Abort(ctx, txn)
+			return err
+		}
+	}
+
+	return store.Commit(ctx, txn)
+}
+
+// Abort discards the changes staged for every target.
+func (t *dataTransaction) Abort(ctx context.Context) {
+	t.abort(ctx, t.targets)
+	t.reset()
+}
+
+func (t *dataTransaction) abort(ctx context.Context, targets []string) {
+	for _, target := range targets {
+		t.stores[target].Abort(ctx, t.txns[target])
+	}
+}
+
+func (t *dataTransaction) reset() {
+	t.targets = nil
+	t.stores = make(map[string]storage.Store)
+	t.txns = make(map[string]storage.Transaction)
+	t.undo = nil
+}
+
+// transaction returns the write transaction for target, opening it if this is
+// the first change staged for target.
+func (t *dataTransaction) transaction(ctx context.Context, target string) (storage.Store, storage.Transaction, error) {
+	if txn, found := t.txns[target]; found {
+		return t.stores[target], txn, nil
+	}
+
+	store, err := t.storage.getStorage(ctx, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txn, err := store.NewTransaction(ctx, storage.WriteParams)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", clienterrors.ErrTransaction, err)
+	}
+
+	t.targets = append(t.targets, target)
+	t.stores[target] = store
+	t.txns[target] = txn
+
+	return store, txn, nil
+}
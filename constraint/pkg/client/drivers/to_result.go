@@ -74,8 +74,9 @@ func ToResult(constraints map[ConstraintKey]*unstructured.Unstructured, r rego.R
 	}
 
 	key := ConstraintKey{
-		Kind: keyMap["kind"],
-		Name: keyMap["name"],
+		Kind:      keyMap["kind"],
+		Namespace: keyMap["namespace"],
+		Name:      keyMap["name"],
 	}
 	constraint := constraints[key]
 
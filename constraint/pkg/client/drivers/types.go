@@ -1,6 +1,11 @@
 package drivers
 
 import (
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/storage"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	"github.com/open-policy-agent/frameworks/constraint/pkg/instrumentation"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
 )
@@ -8,9 +13,74 @@ import (
 // QueryResponse encapsulates the values returned on Query:
 // - Results includes a Result for each violated Constraint.
 // - Trace is the evaluation trace on Query if specified in query options or enabled at Driver creation.
+// - TraceEvents are the steps of the evaluation trace, if requested with reviews.TraceEvents.
 // - StatsEntries include any Stats that the engine gathered on Query.
+// - Uncacheable is true if Results may differ for an identical Query, for example
+// because they depend on external data responses which were not idempotent.
 type QueryResponse struct {
 	Results      []*types.Result
 	Trace        *string
+	TraceEvents  []*types.TraceEvent
 	StatsEntries []*instrumentation.StatsEntry
+	Uncacheable  bool
+}
+
+// BatchQuery is a single review, and the Constraints to run against it, passed
+// to BatchQuerier.QueryBatch.
+type BatchQuery struct {
+	Constraints []*unstructured.Unstructured
+	Review      interface{}
+}
+
+// BatchResult holds the values Query would have returned for a single
+// BatchQuery.
+type BatchResult struct {
+	Response *QueryResponse
+	Err      error
+}
+
+// DataItem is a single object to cache, and the path to cache it at, passed to
+// DataBatcher.AddDataBatch.
+type DataItem struct {
+	Path storage.Path
+	Data interface{}
+}
+
+// CompileError is a single problem found in a Template's code by
+// TemplateValidator.ValidateTemplate.
+type CompileError struct {
+	// Target is the name of the target whose code has the problem.
+	Target string `json:"target"`
+	// Code identifies the kind of problem, if the Driver categorizes problems.
+	// For example, "rego_parse_error".
+	Code string `json:"code,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Location is where the problem is in the Target's code, if known.
+	Location *Location `json:"location,omitempty"`
+}
+
+// Error implements error.
+func (e CompileError) Error() string {
+	if e.Location == nil {
+		return fmt.Sprintf("target %q: %s", e.Target, e.Message)
+	}
+	return fmt.Sprintf("target %q: %s: %s", e.Target, e.Location, e.Message)
+}
+
+// Location is a position in a Template's code.
+type Location struct {
+	// File identifies the module containing the position, such as the Template's
+	// entrypoint or one of its libraries.
+	File string `json:"file,omitempty"`
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+}
+
+// String implements fmt.Stringer.
+func (l *Location) String() string {
+	if l.File == "" {
+		return fmt.Sprintf("%d:%d", l.Row, l.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Row, l.Col)
 }
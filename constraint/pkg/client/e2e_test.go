@@ -3,12 +3,17 @@ package client_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/open-policy-agent/opa/v1/topdown/print"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/ptr"
 
@@ -16,7 +21,11 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/clienttest/cts"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/fake"
+	fakeschema "github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/fake/schema"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego/schema"
 	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/reviews"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
@@ -26,6 +35,17 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
 )
 
+// evaluationModes are the Rego driver configurations which tests of Template
+// evaluation run with, as partially evaluating Templates must not change any
+// results.
+var evaluationModes = []struct {
+	name string
+	args []rego.Arg
+}{
+	{name: "full evaluation"},
+	{name: "partial evaluation", args: []rego.Arg{rego.PartialEvaluation(true)}},
+}
+
 func TestClient_Review(t *testing.T) {
 	tests := []struct {
 		name                              string
@@ -591,102 +611,116 @@ func TestClient_Review(t *testing.T) {
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
-
-			opts := []client.Opt{client.Targets(tt.targets...)}
-			if tt.enforcementPointSupportedByClient != nil {
-				opts = append(opts, client.EnforcementPoints(tt.enforcementPointSupportedByClient...))
-			}
-
-			c := clienttest.New(t, opts...)
+	for _, mode := range evaluationModes {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", mode.name, tt.name), func(t *testing.T) {
+				ctx := context.Background()
 
-			for _, ns := range tt.namespaces {
-				_, err := c.AddData(ctx, &handlertest.Object{Namespace: ns})
+				d, err := rego.New(mode.args...)
 				if err != nil {
 					t.Fatal(err)
 				}
-			}
 
-			for _, ct := range tt.templates {
-				_, err := c.AddTemplate(ctx, ct)
-				if err != nil {
-					t.Fatal(err)
+				opts := []client.Opt{client.Driver(d), client.Targets(tt.targets...), client.EnforcementPoints("audit.gatekeeper.sh")}
+				if tt.enforcementPointSupportedByClient != nil {
+					opts = append(opts, client.EnforcementPoints(tt.enforcementPointSupportedByClient...))
 				}
-			}
 
-			for _, constraint := range tt.constraints {
-				_, err := c.AddConstraint(ctx, constraint)
+				c, err := client.NewClient(opts...)
 				if err != nil {
 					t.Fatal(err)
 				}
-			}
 
-			for _, obj := range tt.inventory {
-				_, err := c.AddData(ctx, obj)
-				if err != nil {
-					t.Fatal(err)
+				for _, ns := range tt.namespaces {
+					_, err := c.AddData(ctx, &handlertest.Object{Namespace: ns})
+					if err != nil {
+						t.Fatal(err)
+					}
 				}
-			}
 
-			responses, err := c.Review(ctx, tt.toReview, reviews.EnforcementPoint(tt.enforcementPointFromReview))
-			if !errors.Is(err, tt.wantErr) {
-				t.Fatalf("got error %v, want %v", err, tt.wantErr)
-			}
+				for _, ct := range tt.templates {
+					_, err := c.AddTemplate(ctx, ct)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
 
-			results := responses.Results()
+				for _, constraint := range tt.constraints {
+					_, err := c.AddConstraint(ctx, constraint)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
 
-			diffOpt := cmpopts.IgnoreFields(types.Result{}, "Metadata")
-			if diff := cmp.Diff(tt.wantResults, results, diffOpt); diff != "" {
-				t.Error(diff)
-			}
-		})
+				for _, obj := range tt.inventory {
+					_, err := c.AddData(ctx, obj)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				responses, err := c.Review(ctx, tt.toReview, reviews.EnforcementPoint(tt.enforcementPointFromReview))
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+
+				results := responses.Results()
+
+				diffOpt := cmpopts.IgnoreFields(types.Result{}, "Metadata")
+				if diff := cmp.Diff(tt.wantResults, results, diffOpt); diff != "" {
+					t.Error(diff)
+				}
+			})
+		}
 	}
 }
 
 func TestClient_Review_Details(t *testing.T) {
-	ctx := context.Background()
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
 
-	c := clienttest.New(t)
+			c := clienttest.NewWithRegoArgs(t, mode.args)
 
-	ct := clienttest.TemplateCheckData()
-	_, err := c.AddTemplate(ctx, ct)
-	if err != nil {
-		t.Fatal(err)
-	}
+			ct := clienttest.TemplateCheckData()
+			_, err := c.AddTemplate(ctx, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	constraint := cts.MakeConstraint(t, clienttest.KindCheckData, "constraint", cts.WantData("bar"))
-	_, err = c.AddConstraint(ctx, constraint)
-	if err != nil {
-		t.Fatal(err)
-	}
+			constraint := cts.MakeConstraint(t, clienttest.KindCheckData, "constraint", cts.WantData("bar"))
+			_, err = c.AddConstraint(ctx, constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	review := handlertest.Review{
-		Object: handlertest.Object{
-			Name: "foo",
-			Data: "qux",
-		},
-	}
+			review := handlertest.Review{
+				Object: handlertest.Object{
+					Name: "foo",
+					Data: "qux",
+				},
+			}
 
-	responses, err := c.Review(ctx, review)
-	if err != nil {
-		t.Fatal(err)
-	}
+			responses, err := c.Review(ctx, review)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	want := []*types.Result{{
-		Target:            handlertest.TargetName,
-		Msg:               "got qux but want bar for data",
-		EnforcementAction: string(constraints.Deny),
-		Constraint: cts.MakeConstraint(t, clienttest.KindCheckData, "constraint",
-			cts.WantData("bar")),
-		Metadata: map[string]interface{}{"details": map[string]interface{}{"got": "qux"}},
-	}}
+			want := []*types.Result{{
+				Target:            handlertest.TargetName,
+				Msg:               "got qux but want bar for data",
+				EnforcementAction: string(constraints.Deny),
+				Constraint: cts.MakeConstraint(t, clienttest.KindCheckData, "constraint",
+					cts.WantData("bar")),
+				Metadata: map[string]interface{}{"details": map[string]interface{}{"got": "qux"}},
+			}}
 
-	results := responses.Results()
+			results := responses.Results()
 
-	if diff := cmp.Diff(want, results); diff != "" {
-		t.Error(diff)
+			if diff := cmp.Diff(want, results); diff != "" {
+				t.Error(diff)
+			}
+		})
 	}
 }
 
@@ -700,277 +734,345 @@ func (a appendingPrintHook) Print(_ print.Context, s string) error {
 }
 
 func TestClient_Review_Print(t *testing.T) {
-	testCases := []struct {
-		name         string
-		printEnabled bool
-		wantResults  []*types.Result
-		wantPrint    []string
-	}{{
-		name:         "Print enabled",
-		printEnabled: true,
-		wantResults: []*types.Result{
-			{
-				Target:            handlertest.TargetName,
-				Msg:               "denied",
-				Constraint:        cts.MakeConstraint(t, clienttest.KindDenyPrint, "denyprint"),
-				EnforcementAction: string(constraints.Deny),
-			},
-		},
-		wantPrint: []string{"denied!"},
-	}, {
-		name:         "Print disabled",
-		printEnabled: false,
-		wantResults: []*types.Result{
-			{
-				Target:            handlertest.TargetName,
-				Msg:               "denied",
-				Constraint:        cts.MakeConstraint(t, clienttest.KindDenyPrint, "denyprint"),
-				EnforcementAction: string(constraints.Deny),
-			},
-		},
-		wantPrint: nil,
-	}}
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			testCases := []struct {
+				name         string
+				printEnabled bool
+				wantResults  []*types.Result
+				wantPrint    []string
+			}{{
+				name:         "Print enabled",
+				printEnabled: true,
+				wantResults: []*types.Result{
+					{
+						Target:            handlertest.TargetName,
+						Msg:               "denied",
+						Constraint:        cts.MakeConstraint(t, clienttest.KindDenyPrint, "denyprint"),
+						EnforcementAction: string(constraints.Deny),
+					},
+				},
+				wantPrint: []string{"denied!"},
+			}, {
+				name:         "Print disabled",
+				printEnabled: false,
+				wantResults: []*types.Result{
+					{
+						Target:            handlertest.TargetName,
+						Msg:               "denied",
+						Constraint:        cts.MakeConstraint(t, clienttest.KindDenyPrint, "denyprint"),
+						EnforcementAction: string(constraints.Deny),
+					},
+				},
+				wantPrint: nil,
+			}}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctx := context.Background()
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					ctx := context.Background()
+
+					var printed []string
+					printHook := appendingPrintHook{printed: &printed}
+
+					d, err := rego.New(append([]rego.Arg{rego.PrintEnabled(tc.printEnabled), rego.PrintHook(printHook)}, mode.args...)...)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					c, err := client.NewClient(client.Targets(&handlertest.Handler{}), client.Driver(d), client.EnforcementPoints("audit"))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					_, err = c.AddTemplate(ctx, clienttest.TemplateDenyPrint())
+					if err != nil {
+						t.Fatalf("got AddTemplate: %v", err)
+					}
+
+					cstr := cts.MakeConstraint(t, clienttest.KindDenyPrint, "denyprint")
+					if _, err = c.AddConstraint(ctx, cstr); err != nil {
+						t.Fatalf("got AddConstraint: %v", err)
+					}
+
+					rsps, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "hanna"}})
+					if err != nil {
+						t.Fatalf("got Review: %v", err)
+					}
+
+					results := rsps.Results()
+					if diff := cmp.Diff(tc.wantResults, results,
+						cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
+						t.Error(diff)
+					}
+
+					if diff := cmp.Diff(tc.wantPrint, printed); diff != "" {
+						t.Error(diff)
+					}
+				})
+			}
+		})
+	}
+}
 
-			var printed []string
-			printHook := appendingPrintHook{printed: &printed}
+func TestE2E_RemoveConstraint(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.NewWithRegoArgs(t, mode.args)
 
-			d, err := rego.New(rego.PrintEnabled(tc.printEnabled), rego.PrintHook(printHook))
+			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			c, err := client.NewClient(client.Targets(&handlertest.Handler{}), client.Driver(d), client.EnforcementPoints("audit"))
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			_, err = c.AddTemplate(ctx, clienttest.TemplateDenyPrint())
+			responses, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
 			if err != nil {
-				t.Fatalf("got AddTemplate: %v", err)
+				t.Fatal(err)
 			}
 
-			cstr := cts.MakeConstraint(t, clienttest.KindDenyPrint, "denyprint")
-			if _, err = c.AddConstraint(ctx, cstr); err != nil {
-				t.Fatalf("got AddConstraint: %v", err)
+			got := responses.Results()
+			want := []*types.Result{{
+				Target:            handlertest.TargetName,
+				Msg:               "denied",
+				Constraint:        cts.MakeConstraint(t, clienttest.KindDeny, "foo"),
+				EnforcementAction: string(constraints.Deny),
+			}}
+
+			if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
+				t.Fatal(diff)
 			}
 
-			rsps, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "hanna"}})
+			_, err = c.RemoveConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
 			if err != nil {
-				t.Fatalf("got Review: %v", err)
+				t.Fatal(err)
 			}
 
-			results := rsps.Results()
-			if diff := cmp.Diff(tc.wantResults, results,
-				cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
-				t.Error(diff)
+			responses2, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
+			if err != nil {
+				t.Fatal(err)
 			}
 
-			if diff := cmp.Diff(tc.wantPrint, printed); diff != "" {
-				t.Error(diff)
+			got2 := responses2.Results()
+			var want2 []*types.Result
+
+			if diff := cmp.Diff(want2, got2, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
+				t.Fatal(diff)
 			}
 		})
 	}
 }
 
-func TestE2E_RemoveConstraint(t *testing.T) {
-	ctx := context.Background()
-	c := clienttest.New(t)
+func TestE2E_RemoveTemplate(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.NewWithRegoArgs(t, mode.args)
 
-	_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
-	if err != nil {
-		t.Fatal(err)
-	}
+			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
-	if err != nil {
-		t.Fatal(err)
-	}
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	responses, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
-	if err != nil {
-		t.Fatal(err)
-	}
+			responses, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	got := responses.Results()
-	want := []*types.Result{{
-		Target:            handlertest.TargetName,
-		Msg:               "denied",
-		Constraint:        cts.MakeConstraint(t, clienttest.KindDeny, "foo"),
-		EnforcementAction: string(constraints.Deny),
-	}}
+			got := responses.Results()
+			want := []*types.Result{{
+				Target:            handlertest.TargetName,
+				Msg:               "denied",
+				Constraint:        cts.MakeConstraint(t, clienttest.KindDeny, "foo"),
+				EnforcementAction: string(constraints.Deny),
+			}}
 
-	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
-		t.Fatal(diff)
-	}
+			if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
+				t.Fatal(diff)
+			}
 
-	_, err = c.RemoveConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
-	if err != nil {
-		t.Fatal(err)
-	}
+			_, err = c.RemoveTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	responses2, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
-	if err != nil {
-		t.Fatal(err)
-	}
+			responses2, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	got2 := responses2.Results()
-	var want2 []*types.Result
+			got2 := responses2.Results()
+			var want2 []*types.Result
 
-	if diff := cmp.Diff(want2, got2, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
-		t.Fatal(diff)
+			if diff := cmp.Diff(want2, got2, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
+				t.Fatal(diff)
+			}
+		})
 	}
 }
 
-func TestE2E_RemoveTemplate(t *testing.T) {
-	ctx := context.Background()
-	c := clienttest.New(t)
-
-	_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	responses, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
-	if err != nil {
-		t.Fatal(err)
-	}
+// TestE2E_Tracing checks that a Tracing(enabled/disabled) works as expected
+// and that TraceDump reflects API consumer expectations.
+func TestE2E_Tracing(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			tests := []struct {
+				name           string
+				tracingEnabled bool
+				deny           bool
+			}{
+				{
+					name:           "tracing disabled without violations",
+					tracingEnabled: false,
+					deny:           false,
+				},
+				{
+					name:           "tracing enabled with violations",
+					tracingEnabled: true,
+					deny:           true,
+				},
+				{
+					name:           "tracing disabled with violations",
+					tracingEnabled: false,
+					deny:           true,
+				},
+				{
+					name:           "tracing enabled without violations",
+					tracingEnabled: true,
+					deny:           false,
+				},
+			}
 
-	got := responses.Results()
-	want := []*types.Result{{
-		Target:            handlertest.TargetName,
-		Msg:               "denied",
-		Constraint:        cts.MakeConstraint(t, clienttest.KindDeny, "foo"),
-		EnforcementAction: string(constraints.Deny),
-	}}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					c := clienttest.NewWithRegoArgs(t, mode.args, client.EnforcementPoints("audit"))
+
+					if tt.deny {
+						_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
+						if err != nil {
+							t.Fatal(err)
+						}
+					} else {
+						_, err := c.AddTemplate(ctx, clienttest.TemplateAllow())
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindAllow, "foo"))
+						if err != nil {
+							t.Fatal(err)
+						}
+					}
 
-	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
-		t.Fatal(diff)
-	}
+					obj := handlertest.Review{Object: handlertest.Object{Name: "bar"}}
 
-	_, err = c.RemoveTemplate(ctx, clienttest.TemplateDeny())
-	if err != nil {
-		t.Fatal(err)
-	}
+					rsps, err := c.Review(ctx, obj, reviews.Tracing(tt.tracingEnabled))
+					if err != nil {
+						t.Fatal(err)
+					}
 
-	responses2, err := c.Review(ctx, handlertest.Review{Object: handlertest.Object{Name: "bar"}})
-	if err != nil {
-		t.Fatal(err)
-	}
+					trace := rsps.ByTarget[handlertest.TargetName].Trace
+					if trace == nil && tt.tracingEnabled {
+						t.Fatal("got nil trace but tracing enabled for Review")
+					} else if trace != nil && !tt.tracingEnabled {
+						t.Fatalf("got trace but tracing disabled: <<%v>>", *trace)
+					}
 
-	got2 := responses2.Results()
-	var want2 []*types.Result
+					_, err = c.AddData(ctx, &obj.Object)
+					if err != nil {
+						t.Fatal(err)
+					}
 
-	if diff := cmp.Diff(want2, got2, cmpopts.IgnoreFields(types.Result{}, "Metadata")); diff != "" {
-		t.Fatal(diff)
+					td := rsps.TraceDump()
+					if tt.tracingEnabled {
+						if tt.deny {
+							if !strings.Contains(td, "Trace:") || strings.Contains(td, types.TracingDisabledHeader) {
+								t.Fatalf("did not find a trace when we were expecting to see one: %s", td)
+							}
+						} else {
+							if strings.Contains(td, types.TracingDisabledHeader) {
+								t.Fatalf("tracing is not disabled, we just didn't see a violation: %s", td)
+							}
+						}
+					} else {
+						if tt.deny {
+							if !strings.Contains(td, types.TracingDisabledHeader) {
+								t.Fatalf("tracing is disabled, there shouldn't be a trace: %s", td)
+							}
+						} else {
+							if strings.Contains(td, types.TracingDisabledHeader) {
+								t.Fatalf("tracing is disabled, but there were no violations so \"%s\" shouldn't be present: %s", types.TracingDisabledHeader, td)
+							}
+						}
+					}
+				})
+			}
+		})
 	}
 }
 
-// TestE2E_Tracing checks that a Tracing(enabled/disabled) works as expected
-// and that TraceDump reflects API consumer expectations.
-func TestE2E_Tracing(t *testing.T) {
-	tests := []struct {
-		name           string
-		tracingEnabled bool
-		deny           bool
-	}{
-		{
-			name:           "tracing disabled without violations",
-			tracingEnabled: false,
-			deny:           false,
-		},
-		{
-			name:           "tracing enabled with violations",
-			tracingEnabled: true,
-			deny:           true,
-		},
-		{
-			name:           "tracing disabled with violations",
-			tracingEnabled: false,
-			deny:           true,
-		},
-		{
-			name:           "tracing enabled without violations",
-			tracingEnabled: true,
-			deny:           false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+// TestE2E_TraceEvents checks that structured trace events are returned for the
+// Constraints evaluated, and survive copying the Response.
+func TestE2E_TraceEvents(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
 			ctx := context.Background()
-			c := clienttest.New(t, client.EnforcementPoints("audit"))
-
-			if tt.deny {
-				_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
-				if err != nil {
-					t.Fatal(err)
-				}
+			c := clienttest.NewWithRegoArgs(t, mode.args, client.EnforcementPoints("audit"))
 
-				_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
-				if err != nil {
-					t.Fatal(err)
-				}
-			} else {
-				_, err := c.AddTemplate(ctx, clienttest.TemplateAllow())
-				if err != nil {
-					t.Fatal(err)
-				}
+			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
 
-				_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindAllow, "foo"))
-				if err != nil {
-					t.Fatal(err)
-				}
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
+			if err != nil {
+				t.Fatal(err)
 			}
 
 			obj := handlertest.Review{Object: handlertest.Object{Name: "bar"}}
 
-			rsps, err := c.Review(ctx, obj, reviews.Tracing(tt.tracingEnabled))
+			rsps, err := c.Review(ctx, obj, reviews.TraceEvents(true))
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			trace := rsps.ByTarget[handlertest.TargetName].Trace
-			if trace == nil && tt.tracingEnabled {
-				t.Fatal("got nil trace but tracing enabled for Review")
-			} else if trace != nil && !tt.tracingEnabled {
-				t.Fatalf("got trace but tracing disabled: <<%v>>", *trace)
+			resp := rsps.ByTarget[handlertest.TargetName].DeepCopy()
+			if len(resp.TraceEvents) == 0 {
+				t.Fatal("got no trace events but trace events enabled for Review")
+			}
+			if resp.Trace != nil {
+				t.Errorf("got trace but tracing disabled: <<%v>>", *resp.Trace)
+			}
+
+			want := types.TracedConstraint{Kind: clienttest.KindDeny, Name: "foo"}
+			for _, event := range resp.TraceEvents {
+				if diff := cmp.Diff(want, event.Constraint); diff != "" {
+					t.Fatal(diff)
+				}
 			}
 
-			_, err = c.AddData(ctx, &obj.Object)
+			rsps, err = c.Review(ctx, obj)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			td := rsps.TraceDump()
-			if tt.tracingEnabled {
-				if tt.deny {
-					if !strings.Contains(td, "Trace:") || strings.Contains(td, types.TracingDisabledHeader) {
-						t.Fatalf("did not find a trace when we were expecting to see one: %s", td)
-					}
-				} else {
-					if strings.Contains(td, types.TracingDisabledHeader) {
-						t.Fatalf("tracing is not disabled, we just didn't see a violation: %s", td)
-					}
-				}
-			} else {
-				if tt.deny {
-					if !strings.Contains(td, types.TracingDisabledHeader) {
-						t.Fatalf("tracing is disabled, there shouldn't be a trace: %s", td)
-					}
-				} else {
-					if strings.Contains(td, types.TracingDisabledHeader) {
-						t.Fatalf("tracing is disabled, but there were no violations so \"%s\" shouldn't be present: %s", types.TracingDisabledHeader, td)
-					}
-				}
+			if events := rsps.ByTarget[handlertest.TargetName].TraceEvents; len(events) != 0 {
+				t.Errorf("got %d trace events but trace events disabled", len(events))
 			}
 		})
 	}
@@ -981,49 +1083,53 @@ func TestE2E_Tracing(t *testing.T) {
 // A non evaluation can occur when a review doesn't match the constraint's match
 // criteria.
 func TestE2E_Tracing_Unmatched(t *testing.T) {
-	tests := []struct {
-		name           string
-		tracingEnabled bool
-	}{
-		{
-			name:           "disabled",
-			tracingEnabled: false,
-		},
-		{
-			name:           "enabled",
-			tracingEnabled: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
-			c := clienttest.New(t, client.Targets([]handler.TargetHandler{&handlertest.Handler{Cache: &handlertest.Cache{}}}...))
-
-			_, err := c.AddData(ctx, &handlertest.Object{Namespace: "ns"})
-			if err != nil {
-				t.Fatal(err)
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			tests := []struct {
+				name           string
+				tracingEnabled bool
+			}{
+				{
+					name:           "disabled",
+					tracingEnabled: false,
+				},
+				{
+					name:           "enabled",
+					tracingEnabled: true,
+				},
 			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					c := clienttest.NewWithRegoArgs(t, mode.args, client.Targets([]handler.TargetHandler{&handlertest.Handler{Cache: &handlertest.Cache{}}}...))
+
+					_, err := c.AddData(ctx, &handlertest.Object{Namespace: "ns"})
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			_, err = c.AddTemplate(ctx, clienttest.TemplateDeny())
-			if err != nil {
-				t.Fatal(err)
-			}
+					_, err = c.AddTemplate(ctx, clienttest.TemplateDeny())
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo", cts.MatchNamespace("aaa")))
-			if err != nil {
-				t.Fatal(err)
-			}
+					_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo", cts.MatchNamespace("aaa")))
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			obj := handlertest.Review{Object: handlertest.Object{Name: "bar", Namespace: "ns"}}
+					obj := handlertest.Review{Object: handlertest.Object{Name: "bar", Namespace: "ns"}}
 
-			rsps, err := c.Review(ctx, obj, reviews.Tracing(tt.tracingEnabled))
-			if err != nil {
-				t.Fatal(err)
-			}
+					rsps, err := c.Review(ctx, obj, reviews.Tracing(tt.tracingEnabled))
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			td := rsps.TraceDump()
-			if strings.Contains(td, types.TracingDisabledHeader) {
-				t.Fatalf("\"%s\" shouldn't be present: %s", types.TracingDisabledHeader, td)
+					td := rsps.TraceDump()
+					if strings.Contains(td, types.TracingDisabledHeader) {
+						t.Fatalf("\"%s\" shouldn't be present: %s", types.TracingDisabledHeader, td)
+					}
+				})
 			}
 		})
 	}
@@ -1031,47 +1137,51 @@ func TestE2E_Tracing_Unmatched(t *testing.T) {
 
 // TestE2E_DriverStats tests that we can turn on and off the Stats() QueryOpt.
 func TestE2E_DriverStats(t *testing.T) {
-	tests := []struct {
-		name         string
-		statsEnabled bool
-	}{
-		{
-			name:         "disabled",
-			statsEnabled: false,
-		},
-		{
-			name:         "enabled",
-			statsEnabled: true,
-		},
-	}
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			tests := []struct {
+				name         string
+				statsEnabled bool
+			}{
+				{
+					name:         "disabled",
+					statsEnabled: false,
+				},
+				{
+					name:         "enabled",
+					statsEnabled: true,
+				},
+			}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
-			c := clienttest.New(t)
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					c := clienttest.NewWithRegoArgs(t, mode.args)
 
-			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
-			if err != nil {
-				t.Fatal(err)
-			}
+					_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
-			if err != nil {
-				t.Fatal(err)
-			}
+					_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "foo"))
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			obj := handlertest.Review{Object: handlertest.Object{Name: "bar"}}
+					obj := handlertest.Review{Object: handlertest.Object{Name: "bar"}}
 
-			rsps, err := c.Review(ctx, obj, reviews.Stats(tt.statsEnabled))
-			if err != nil {
-				t.Fatal(err)
-			}
+					rsps, err := c.Review(ctx, obj, reviews.Stats(tt.statsEnabled))
+					if err != nil {
+						t.Fatal(err)
+					}
 
-			stats := rsps.StatsEntries
-			if stats == nil && tt.statsEnabled {
-				t.Fatal("got nil stats but stats enabled for Review")
-			} else if len(stats) != 0 && !tt.statsEnabled {
-				t.Fatal("got stats but stats disabled")
+					stats := rsps.StatsEntries
+					if stats == nil && tt.statsEnabled {
+						t.Fatal("got nil stats but stats enabled for Review")
+					} else if len(stats) != 0 && !tt.statsEnabled {
+						t.Fatal("got stats but stats disabled")
+					}
+				})
 			}
 		})
 	}
@@ -1119,6 +1229,12 @@ func TestE2E_Client_GetDescriptionForStat(t *testing.T) {
 			statName:        "templateRunTimeNS",
 			expectedUnknown: false,
 		},
+		{
+			name:            "valid source type with known constraint stat",
+			source:          validSource,
+			statName:        "constraintRunTimeNS",
+			expectedUnknown: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -1131,192 +1247,2291 @@ func TestE2E_Client_GetDescriptionForStat(t *testing.T) {
 	}
 }
 
-// TestClient_Review_Namespace tests that namespace data is properly passed
-// to the Rego driver via input.review.namespaceObject for namespace-based policy decisions.
-func TestClient_Review_Namespace(t *testing.T) {
-	tests := []struct {
-		name        string
-		namespace   map[string]interface{}
-		wantEnv     string
-		wantResults int
-		wantMsg     string
-	}{
-		{
-			name:        "no namespace provided - expects violation for missing namespace",
-			namespace:   nil,
-			wantEnv:     "production",
-			wantResults: 1,
-			wantMsg:     "namespace is missing environment label",
-		},
-		{
-			name:        "empty namespace object - expects violation for missing namespace",
-			namespace:   map[string]interface{}{},
-			wantEnv:     "production",
-			wantResults: 1,
-			wantMsg:     "namespace is missing environment label",
-		},
-		{
-			name: "namespace with matching environment label",
-			namespace: map[string]interface{}{
-				"metadata": map[string]interface{}{
-					"name": "test-ns",
-					"labels": map[string]interface{}{
-						"environment": "production",
-					},
-				},
-			},
-			wantEnv:     "production",
-			wantResults: 0, // No violation - environment matches
-		},
-		{
-			name: "namespace with wrong environment label",
-			namespace: map[string]interface{}{
-				"metadata": map[string]interface{}{
-					"name": "test-ns",
-					"labels": map[string]interface{}{
-						"environment": "staging",
-					},
-				},
-			},
-			wantEnv:     "production",
-			wantResults: 1,
-			wantMsg:     "namespace has environment staging but want production",
-		},
-		{
-			name: "namespace missing environment label",
-			namespace: map[string]interface{}{
-				"metadata": map[string]interface{}{
-					"name": "test-ns",
-					"labels": map[string]interface{}{
-						"team": "platform",
-					},
-				},
-			},
-			wantEnv:     "production",
-			wantResults: 1,
-			wantMsg:     "namespace is missing environment label",
-		},
+// TestClient_Review_Concurrency verifies that evaluating targets and drivers
+// concurrently produces the same Responses as evaluating them sequentially.
+func TestClient_Review_Concurrency(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			targetNames := []string{"h1", "h2", "h3"}
+
+			newClient := func(t *testing.T, opts ...client.Opt) *client.Client {
+				t.Helper()
+
+				regoDriver, err := rego.New(mode.args...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var targets []handler.TargetHandler
+				for _, name := range targetNames {
+					targets = append(targets, &handlertest.Handler{Name: ptr.To[string](name)})
+				}
+
+				opts = append([]client.Opt{
+					client.Targets(targets...),
+					client.Driver(regoDriver),
+					client.Driver(fake.New("fake")),
+					client.EnforcementPoints("test"),
+				}, opts...)
+
+				c, err := client.NewClient(opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				ctx := context.Background()
+				for _, target := range targetNames {
+					regoKind := "Rego" + strings.ToUpper(target)
+					fakeKind := "Fake" + strings.ToUpper(target)
+
+					regoTemplate := cts.New(cts.OptName(strings.ToLower(regoKind)), cts.OptCRDNames(regoKind),
+						cts.OptTargets(cts.Target(target, clienttest.ModuleDeny)))
+					fakeTemplate := cts.New(cts.OptName(strings.ToLower(fakeKind)), cts.OptCRDNames(fakeKind),
+						cts.OptTargets(cts.TargetCustomEngines(target,
+							cts.Code("fake", (&fakeschema.Source{RejectWith: "rejected"}).ToUnstructured()))))
+
+					for _, ct := range []*templates.ConstraintTemplate{regoTemplate, fakeTemplate} {
+						if _, err := c.AddTemplate(ctx, ct); err != nil {
+							t.Fatal(err)
+						}
+					}
+
+					for i := 0; i < 3; i++ {
+						for _, kind := range []string{regoKind, fakeKind} {
+							constraint := cts.MakeConstraint(t, kind, fmt.Sprintf("constraint-%d", i))
+							if _, err := c.AddConstraint(ctx, constraint); err != nil {
+								t.Fatal(err)
+							}
+						}
+					}
+				}
+
+				return c
+			}
+
+			ctx := context.Background()
+			review := handlertest.NewReview("", "foo", "bar")
+
+			want, err := newClient(t).Review(ctx, review, reviews.Stats(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, concurrency := range []int{1, 2, 8} {
+				t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+					got, err := newClient(t, client.ReviewConcurrency(concurrency)).Review(ctx, review, reviews.Stats(true))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if len(got.ByTarget) != len(targetNames) {
+						t.Fatalf("got %d targets, want %d", len(got.ByTarget), len(targetNames))
+					}
+
+					if diff := cmp.Diff(want.ByTarget, got.ByTarget); diff != "" {
+						t.Error(diff)
+					}
+
+					if len(got.StatsEntries) != len(want.StatsEntries) {
+						t.Errorf("got %d stats entries, want %d", len(got.StatsEntries), len(want.StatsEntries))
+					}
+				})
+			}
+		})
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+// TestClient_ReviewBatch verifies that ReviewBatch returns the same Responses
+// and errors for each object as reviewing the objects individually.
+func TestClient_ReviewBatch(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
 			ctx := context.Background()
 
-			c := clienttest.New(t)
+			cache := &handlertest.Cache{}
+			regoDriver, err := rego.New(mode.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-			ct := clienttest.TemplateCheckNamespace()
-			_, err := c.AddTemplate(ctx, ct)
+			c, err := client.NewClient(
+				client.Targets(&handlertest.Handler{Cache: cache}),
+				client.Driver(regoDriver),
+				client.Driver(fake.New("fake")),
+				client.EnforcementPoints("audit.gatekeeper.sh"),
+			)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			constraint := cts.MakeConstraint(t, clienttest.KindCheckNamespace, "constraint", cts.WantEnvironment(tt.wantEnv))
-			_, err = c.AddConstraint(ctx, constraint)
+			fakeTemplate := cts.New(cts.OptName("fakedeny"), cts.OptCRDNames("FakeDeny"),
+				cts.OptTargets(cts.TargetCustomEngines(handlertest.TargetName,
+					cts.Code("fake", (&fakeschema.Source{RejectWith: "rejected"}).ToUnstructured()))))
+
+			for _, ct := range []*templates.ConstraintTemplate{
+				clienttest.TemplateDeny(),
+				clienttest.TemplateCheckData(),
+				clienttest.TemplateRuntimeError(),
+				fakeTemplate,
+			} {
+				if _, err := c.AddTemplate(ctx, ct); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := c.AddData(ctx, &handlertest.Object{Namespace: "cached"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, constraint := range []*unstructured.Unstructured{
+				cts.MakeConstraint(t, clienttest.KindDeny, "deny-all"),
+				cts.MakeConstraint(t, clienttest.KindDeny, "deny-cached", cts.MatchNamespace("cached")),
+				cts.MakeConstraint(t, clienttest.KindDeny, "deny-uncached", cts.MatchNamespace("uncached")),
+				cts.MakeConstraint(t, clienttest.KindCheckData, "want-bar", cts.WantData("bar")),
+				cts.MakeConstraint(t, clienttest.KindRuntimeError, "runtime-error"),
+				cts.MakeConstraint(t, "FakeDeny", "fake-deny"),
+			} {
+				if _, err := c.AddConstraint(ctx, constraint); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			objs := []interface{}{
+				handlertest.NewReview("", "foo", "bar"),
+				handlertest.NewReview("cached", "foo", "qux"),
+				handlertest.NewReview("uncached", "foo", "bar"),
+				handlertest.Review{Ignored: true, Object: handlertest.Object{Name: "ignored"}},
+				handlertest.Object{Name: "wrong-type"},
+				handlertest.NewReview("", "foo", "qux"),
+			}
+
+			for _, opts := range [][]reviews.ReviewOpt{
+				nil,
+				{reviews.Stats(true)},
+				{reviews.Tracing(true)},
+			} {
+				got, gotErr := c.ReviewBatch(ctx, objs, opts...)
+				if len(got) != len(objs) {
+					t.Fatalf("got %d Responses, want %d", len(got), len(objs))
+				}
+
+				var gotBatchErrs *clienterrors.ErrorMap
+				if gotErr != nil && !errors.As(gotErr, &gotBatchErrs) {
+					t.Fatalf("got ReviewBatch() error of type %T, want %T", gotErr, gotBatchErrs)
+				}
+
+				for i, obj := range objs {
+					want, wantErr := c.Review(ctx, obj, opts...)
+
+					var gotObjErr error
+					if gotBatchErrs != nil {
+						gotObjErr = (*gotBatchErrs)[strconv.Itoa(i)]
+					}
+					if (wantErr == nil) != (gotObjErr == nil) || (wantErr != nil && wantErr.Error() != gotObjErr.Error()) {
+						t.Errorf("object %d: got error %v, want %v", i, gotObjErr, wantErr)
+					}
+
+					if diff := cmp.Diff(want.ByTarget, got[i].ByTarget, cmpopts.IgnoreFields(types.Response{}, "Trace")); diff != "" {
+						t.Errorf("object %d: %s", i, diff)
+					}
+
+					if len(got[i].StatsEntries) != len(want.StatsEntries) {
+						t.Errorf("object %d: got %d stats entries, want %d", i, len(got[i].StatsEntries), len(want.StatsEntries))
+					}
+
+					for target, resp := range want.ByTarget {
+						if (resp.Trace == nil) != (got[i].ByTarget[target].Trace == nil) {
+							t.Errorf("object %d: got trace %v, want trace %v", i, got[i].ByTarget[target].Trace != nil, resp.Trace != nil)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Review_MultiTarget(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			const kind = "MultiTarget"
+			targetModule := func(target string) string {
+				return fmt.Sprintf(`package foo
+
+violation[{"msg": msg}] {
+  msg := "denied by %s"
+}
+`, target)
+			}
+
+			d, err := rego.New(mode.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := client.NewClient(
+				client.Targets(
+					&handlertest.Handler{Name: ptr.To[string]("h1")},
+					&handlertest.Handler{Name: ptr.To[string]("h2")},
+					&handlertest.Handler{Name: ptr.To[string]("h3")},
+				),
+				client.Driver(d),
+				client.EnforcementPoints("audit.gatekeeper.sh"),
+			)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			review := handlertest.NewReview("test-ns", "test-obj", "test-data")
+			templ := cts.New(cts.OptName("multitarget"), cts.OptCRDNames(kind),
+				cts.OptTargets(cts.Target("h1", targetModule("h1")), cts.Target("h2", targetModule("h2"))))
+
+			resp, err := c.AddTemplate(ctx, templ)
+			if err != nil {
+				t.Fatalf("got AddTemplate() error = %v, want nil", err)
+			}
+			if diff := cmp.Diff(map[string]bool{"h1": true, "h2": true}, resp.Handled); diff != "" {
+				t.Error(diff)
+			}
 
-			// Pass namespace via reviews.Namespace option
-			var opts []reviews.ReviewOpt
-			if tt.namespace != nil {
-				opts = append(opts, reviews.Namespace(tt.namespace))
+			constraint := cts.MakeConstraint(t, kind, "constraint")
+			if _, err := c.AddConstraint(ctx, constraint); err != nil {
+				t.Fatal(err)
 			}
 
-			responses, err := c.Review(ctx, review, opts...)
+			got, err := c.Review(ctx, handlertest.NewReview("", "foo", "bar"))
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			results := responses.Results()
-			if len(results) != tt.wantResults {
-				t.Errorf("got %d results, want %d. Results: %v", len(results), tt.wantResults, results)
+			want := []*types.Result{{
+				Target:            "h1",
+				Msg:               "denied by h1",
+				Constraint:        constraint,
+				EnforcementAction: string(constraints.Deny),
+			}, {
+				Target:            "h2",
+				Msg:               "denied by h2",
+				Constraint:        constraint,
+				EnforcementAction: string(constraints.Deny),
+			}}
+			diffOpts := []cmp.Option{
+				cmpopts.IgnoreFields(types.Result{}, "Metadata"),
+				cmpopts.SortSlices(func(a, b *types.Result) bool { return a.Target < b.Target }),
+			}
+			if diff := cmp.Diff(want, got.Results(), diffOpts...); diff != "" {
+				t.Error(diff)
 			}
 
-			if tt.wantResults > 0 && len(results) > 0 {
-				if results[0].Msg != tt.wantMsg {
-					t.Errorf("got message %q, want %q", results[0].Msg, tt.wantMsg)
-				}
+			if _, err := c.RemoveTemplate(ctx, templ); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err = c.Review(ctx, handlertest.NewReview("", "foo", "bar"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if results := got.Results(); len(results) != 0 {
+				t.Errorf("got %d results after RemoveTemplate, want 0", len(results))
 			}
 		})
 	}
 }
 
-// TestClient_Review_ClusterScopedResource verifies that cluster-scoped resources
-// (resources without a namespace, like ClusterRole, PersistentVolume, etc.)
-// can be reviewed correctly. This ensures empty namespace handling doesn't cause
-// issues for cluster-scoped resources, including when namespace-aware policies are used.
-func TestClient_Review_ClusterScopedResource(t *testing.T) {
-	t.Run("with deny policy", func(t *testing.T) {
-		ctx := context.Background()
+func TestClient_Snapshot(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
 
-		c := clienttest.New(t)
+			newClient := func(t *testing.T, opts ...client.Opt) *client.Client {
+				t.Helper()
 
-		// Use TemplateDeny which unconditionally denies - doesn't depend on namespace
-		ct := clienttest.TemplateDeny()
-		_, err := c.AddTemplate(ctx, ct)
-		if err != nil {
-			t.Fatal(err)
-		}
+				regoDriver, err := rego.New(mode.args...)
+				if err != nil {
+					t.Fatal(err)
+				}
 
-		constraint := cts.MakeConstraint(t, clienttest.KindDeny, "deny-all")
-		_, err = c.AddConstraint(ctx, constraint)
-		if err != nil {
-			t.Fatal(err)
-		}
+				opts = append([]client.Opt{
+					client.Targets(&handlertest.Handler{Cache: &handlertest.Cache{}}),
+					client.Driver(regoDriver),
+					client.Driver(fake.New("fake")),
+				}, opts...)
+
+				c, err := client.NewClient(opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return c
+			}
 
-		// Create a cluster-scoped review (empty namespace in the object itself)
-		review := handlertest.NewReview("", "cluster-resource", "test-data")
+			c := newClient(t, client.EnforcementPoints("audit.gatekeeper.sh"))
 
-		responses, err := c.Review(ctx, review)
-		if err != nil {
-			t.Fatalf("unexpected error during review: %v", err)
-		}
+			fakeTemplate := cts.New(cts.OptName("fakedeny"), cts.OptCRDNames("FakeDeny"),
+				cts.OptTargets(cts.TargetCustomEngines(handlertest.TargetName,
+					cts.Code("fake", (&fakeschema.Source{RejectWith: "rejected"}).ToUnstructured()))))
 
-		results := responses.Results()
-		if len(results) != 1 {
-			t.Errorf("got %d results, want 1. Results: %v", len(results), results)
-		}
-	})
+			for _, ct := range []*templates.ConstraintTemplate{
+				clienttest.TemplateDeny(),
+				clienttest.TemplateCheckData(),
+				clienttest.TemplateForbidDuplicates(),
+				fakeTemplate,
+			} {
+				if _, err := c.AddTemplate(ctx, ct); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, obj := range []*handlertest.Object{
+				{Namespace: "cached"},
+				{Name: "existing", Data: "taken"},
+			} {
+				if _, err := c.AddData(ctx, obj); err != nil {
+					t.Fatal(err)
+				}
+			}
 
-	t.Run("with namespace-aware policy", func(t *testing.T) {
-		ctx := context.Background()
+			for _, constraint := range []*unstructured.Unstructured{
+				cts.MakeConstraint(t, clienttest.KindDeny, "deny-cached", cts.MatchNamespace("cached")),
+				cts.MakeConstraint(t, clienttest.KindCheckData, "want-bar", cts.WantData("bar")),
+				cts.MakeConstraint(t, clienttest.KindForbidDuplicates, "no-duplicates"),
+				cts.MakeConstraint(t, "FakeDeny", "fake-deny"),
+			} {
+				if _, err := c.AddConstraint(ctx, constraint); err != nil {
+					t.Fatal(err)
+				}
+			}
 
-		c := clienttest.New(t)
+			_, err := c.AddExemption(&client.Exemption{
+				Name:      "exempt",
+				Kinds:     []string{clienttest.KindCheckData},
+				Selectors: map[string]interface{}{handlertest.TargetName: map[string]interface{}{"name": "exempt"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
 
-		// Use TemplateCheckNamespace which checks namespace labels
-		ct := clienttest.TemplateCheckNamespace()
-		_, err := c.AddTemplate(ctx, ct)
-		if err != nil {
-			t.Fatal(err)
-		}
+			var snap strings.Builder
+			if err := c.Snapshot(ctx, &snap); err != nil {
+				t.Fatalf("got Snapshot() error = %v, want nil", err)
+			}
 
-		constraint := cts.MakeConstraint(t, clienttest.KindCheckNamespace, "check-ns",
-			cts.WantEnvironment("production"))
-		_, err = c.AddConstraint(ctx, constraint)
-		if err != nil {
-			t.Fatal(err)
-		}
+			restored := newClient(t, client.RestoreFrom(strings.NewReader(snap.String())))
 
-		// Create a cluster-scoped review (empty namespace in the object)
-		review := handlertest.NewReview("", "cluster-resource", "test-data")
+			objs := []interface{}{
+				handlertest.NewReview("", "foo", "bar"),
+				handlertest.NewReview("cached", "foo", "qux"),
+				handlertest.NewReview("", "foo", "taken"),
+				handlertest.NewReview("", "exempt", "qux"),
+			}
 
-		responses, err := c.Review(ctx, review)
-		if err != nil {
-			t.Fatalf("unexpected error during review: %v", err)
-		}
+			for i, obj := range objs {
+				want, err := c.Review(ctx, obj)
+				if err != nil {
+					t.Fatal(err)
+				}
 
-		results := responses.Results()
-		if len(results) != 1 {
-			t.Errorf("got %d results, want 1. Results: %v", len(results), results)
-		}
+				got, err := restored.Review(ctx, obj)
+				if err != nil {
+					t.Fatalf("object %d: got Review() error = %v, want nil", i, err)
+				}
 
-		if len(results) > 0 {
-			wantMsg := "namespace is missing environment label"
-			if results[0].Msg != wantMsg {
-				t.Errorf("got message %q, want %q", results[0].Msg, wantMsg)
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("object %d: %s", i, diff)
+				}
 			}
-		}
-	})
+
+			var gotSnap strings.Builder
+			if err := restored.Snapshot(ctx, &gotSnap); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(snap.String(), gotSnap.String()); diff != "" {
+				t.Errorf("snapshot of restored Client differs: %s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_RestoreFrom_Invalid(t *testing.T) {
+	tcs := []struct {
+		name     string
+		snapshot string
+	}{
+		{name: "not JSON", snapshot: "{"},
+		{name: "wrong kind", snapshot: `{"kind": "Other", "version": 1}`},
+		{name: "unsupported version", snapshot: `{"kind": "ConstraintClientSnapshot", "version": 99}`},
+		{
+			name:     "unknown target",
+			snapshot: `{"kind": "ConstraintClientSnapshot", "version": 1, "inventory": {"other": {}}}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := rego.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.NewClient(
+				client.Targets(&handlertest.Handler{}),
+				client.Driver(d),
+				client.EnforcementPoints("test"),
+				client.RestoreFrom(strings.NewReader(tc.snapshot)),
+			)
+			if !errors.Is(err, client.ErrInvalidSnapshot) {
+				t.Errorf("got NewClient() error = %v, want %v", err, client.ErrInvalidSnapshot)
+			}
+		})
+	}
+}
+
+// TestClient_Review_Namespace tests that namespace data is properly passed
+// to the Rego driver via input.review.namespaceObject for namespace-based policy decisions.
+func TestClient_Review_NamespacedConstraints(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			const kind = "Namespaced"
+			module := `package foo
+
+violation[{"msg": msg}] {
+  msg := input.parameters.msg
+}
+`
+
+			d, err := rego.New(mode.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := client.NewClient(
+				client.Targets(&handlertest.Handler{}),
+				client.Driver(d),
+				client.EnforcementPoints("audit.gatekeeper.sh"),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			templ := cts.New(cts.OptName("namespaced"), cts.OptCRDNames(kind),
+				cts.OptCRDScope(templates.ScopeNamespaced),
+				cts.OptCRDSchema(cts.PropMap{"msg": cts.PropTyped("string")}),
+				cts.OptTargets(cts.Target(handlertest.TargetName, module)))
+
+			_, err = c.AddTemplate(ctx, templ)
+			if err != nil {
+				t.Fatalf("got AddTemplate() error = %v, want nil", err)
+			}
+
+			// Namespaced Constraints in different namespaces may share a name.
+			fooConstraint := cts.MakeConstraint(t, kind, "constraint", cts.Namespace("foo"),
+				cts.Set("in foo", "spec", "parameters", "msg"))
+			barConstraint := cts.MakeConstraint(t, kind, "constraint", cts.Namespace("bar"),
+				cts.Set("in bar", "spec", "parameters", "msg"))
+			for _, constraint := range []*unstructured.Unstructured{fooConstraint, barConstraint} {
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					t.Fatalf("got AddConstraint() error = %v, want nil", err)
+				}
+			}
+
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, kind, "cluster"))
+			if !errors.Is(err, constraints.ErrInvalidConstraint) {
+				t.Errorf("got AddConstraint() error = %v, want %v", err, constraints.ErrInvalidConstraint)
+			}
+
+			review := func(t *testing.T, namespace string) []*types.Result {
+				t.Helper()
+
+				resp, err := c.Review(ctx, handlertest.NewReview(namespace, "obj", "bar"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return resp.Results()
+			}
+
+			diffOpts := cmpopts.IgnoreFields(types.Result{}, "Metadata")
+
+			got := review(t, "foo")
+			want := []*types.Result{{
+				Target:            handlertest.TargetName,
+				Msg:               "in foo",
+				Constraint:        fooConstraint,
+				EnforcementAction: string(constraints.Deny),
+			}}
+			if diff := cmp.Diff(want, got, diffOpts); diff != "" {
+				t.Error(diff)
+			}
+
+			if got := review(t, ""); len(got) != 0 {
+				t.Errorf("got %d results for cluster-scoped object, want 0", len(got))
+			}
+
+			_, err = c.RemoveConstraint(ctx, fooConstraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := review(t, "foo"); len(got) != 0 {
+				t.Errorf("got %d results after RemoveConstraint, want 0", len(got))
+			}
+
+			gotConstraint, err := c.GetConstraint(barConstraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(barConstraint, gotConstraint); diff != "" {
+				t.Error(diff)
+			}
+
+			got = review(t, "bar")
+			want = []*types.Result{{
+				Target:            handlertest.TargetName,
+				Msg:               "in bar",
+				Constraint:        barConstraint,
+				EnforcementAction: string(constraints.Deny),
+			}}
+			if diff := cmp.Diff(want, got, diffOpts); diff != "" {
+				t.Error(diff)
+			}
+
+			// Existing Constraints would be invalid for a cluster-scoped kind.
+			clusterTempl := templ.DeepCopy()
+			clusterTempl.Spec.CRD.Spec.Scope = templates.ScopeCluster
+			_, err = c.AddTemplate(ctx, clusterTempl)
+			if !errors.Is(err, clienterrors.ErrChangeScope) {
+				t.Errorf("got AddTemplate() error = %v, want %v", err, clienterrors.ErrChangeScope)
+			}
+		})
+	}
+}
+
+func TestClient_AddTemplate_NamespacedRequiresReviewNamespacer(t *testing.T) {
+	// Embedding only the TargetHandler interface hides ReviewNamespace.
+	h := struct{ handler.TargetHandler }{&handlertest.Handler{}}
+
+	d, err := rego.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := client.NewClient(client.Targets(h), client.Driver(d),
+		client.EnforcementPoints("audit.gatekeeper.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templ := cts.New(cts.OptCRDScope(templates.ScopeNamespaced))
+
+	_, err = c.AddTemplate(context.Background(), templ)
+	if !errors.Is(err, clienterrors.ErrInvalidConstraintTemplate) {
+		t.Errorf("got AddTemplate() error = %v, want %v", err, clienterrors.ErrInvalidConstraintTemplate)
+	}
+}
+
+// unindexedHandler hides the handler.IndexableMatcher methods of a Handler so
+// Client runs every Matcher.
+type unindexedHandler struct {
+	handler.TargetHandler
+	handler.Cacher
+}
+
+// TestClient_Review_IndexedMatchers tests that Review returns the same results
+// whether or not Client uses an index to skip Matchers.
+func TestClient_Review_IndexedMatchers(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newClient := func(t *testing.T, target handler.TargetHandler) *client.Client {
+				t.Helper()
+
+				c := clienttest.NewWithRegoArgs(t, mode.args, client.Targets(target))
+
+				// "qux" is deliberately not cached, so Matchers for it return errors.
+				for _, ns := range []string{"foo", "bar", "baz"} {
+					_, err := c.AddData(ctx, &handlertest.Object{Namespace: ns})
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for i, ns := range []string{"", "foo", "foo", "bar", "qux"} {
+					constraint := cts.MakeConstraint(t, clienttest.KindCheckData, fmt.Sprintf("constraint-%d", i),
+						cts.WantData("bar"), cts.MatchNamespace(ns))
+					_, err = c.AddConstraint(ctx, constraint)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				// Removed Constraints must not remain candidates.
+				_, err = c.RemoveConstraint(ctx, cts.MakeConstraint(t, clienttest.KindCheckData, "constraint-2"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return c
+			}
+
+			indexed := newClient(t, &handlertest.Handler{Cache: &handlertest.Cache{}})
+
+			h := &handlertest.Handler{Cache: &handlertest.Cache{}}
+			unindexed := newClient(t, &unindexedHandler{TargetHandler: h, Cacher: h})
+
+			for _, ns := range []string{"", "foo", "bar", "baz", "qux"} {
+				t.Run(ns, func(t *testing.T) {
+					review := handlertest.NewReview(ns, "obj", "qux")
+
+					want, err := unindexed.Review(ctx, review)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					got, err := indexed.Review(ctx, review)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					diffOpt := cmpopts.IgnoreFields(types.Result{}, "Metadata")
+					if diff := cmp.Diff(want.Results(), got.Results(), diffOpt); diff != "" {
+						t.Error(diff)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestClient_Review_Namespace(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			tests := []struct {
+				name        string
+				namespace   map[string]interface{}
+				wantEnv     string
+				wantResults int
+				wantMsg     string
+			}{
+				{
+					name:        "no namespace provided - expects violation for missing namespace",
+					namespace:   nil,
+					wantEnv:     "production",
+					wantResults: 1,
+					wantMsg:     "namespace is missing environment label",
+				},
+				{
+					name:        "empty namespace object - expects violation for missing namespace",
+					namespace:   map[string]interface{}{},
+					wantEnv:     "production",
+					wantResults: 1,
+					wantMsg:     "namespace is missing environment label",
+				},
+				{
+					name: "namespace with matching environment label",
+					namespace: map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"name": "test-ns",
+							"labels": map[string]interface{}{
+								"environment": "production",
+							},
+						},
+					},
+					wantEnv:     "production",
+					wantResults: 0, // No violation - environment matches
+				},
+				{
+					name: "namespace with wrong environment label",
+					namespace: map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"name": "test-ns",
+							"labels": map[string]interface{}{
+								"environment": "staging",
+							},
+						},
+					},
+					wantEnv:     "production",
+					wantResults: 1,
+					wantMsg:     "namespace has environment staging but want production",
+				},
+				{
+					name: "namespace missing environment label",
+					namespace: map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"name": "test-ns",
+							"labels": map[string]interface{}{
+								"team": "platform",
+							},
+						},
+					},
+					wantEnv:     "production",
+					wantResults: 1,
+					wantMsg:     "namespace is missing environment label",
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+
+					c := clienttest.NewWithRegoArgs(t, mode.args)
+
+					ct := clienttest.TemplateCheckNamespace()
+					_, err := c.AddTemplate(ctx, ct)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					constraint := cts.MakeConstraint(t, clienttest.KindCheckNamespace, "constraint", cts.WantEnvironment(tt.wantEnv))
+					_, err = c.AddConstraint(ctx, constraint)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					review := handlertest.NewReview("test-ns", "test-obj", "test-data")
+
+					// Pass namespace via reviews.Namespace option
+					var opts []reviews.ReviewOpt
+					if tt.namespace != nil {
+						opts = append(opts, reviews.Namespace(tt.namespace))
+					}
+
+					responses, err := c.Review(ctx, review, opts...)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					results := responses.Results()
+					if len(results) != tt.wantResults {
+						t.Errorf("got %d results, want %d. Results: %v", len(results), tt.wantResults, results)
+					}
+
+					if tt.wantResults > 0 && len(results) > 0 {
+						if results[0].Msg != tt.wantMsg {
+							t.Errorf("got message %q, want %q", results[0].Msg, tt.wantMsg)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestClient_Review_ClusterScopedResource verifies that cluster-scoped resources
+// (resources without a namespace, like ClusterRole, PersistentVolume, etc.)
+// can be reviewed correctly. This ensures empty namespace handling doesn't cause
+// issues for cluster-scoped resources, including when namespace-aware policies are used.
+func TestClient_Review_ClusterScopedResource(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			t.Run("with deny policy", func(t *testing.T) {
+				ctx := context.Background()
+
+				c := clienttest.NewWithRegoArgs(t, mode.args)
+
+				// Use TemplateDeny which unconditionally denies - doesn't depend on namespace
+				ct := clienttest.TemplateDeny()
+				_, err := c.AddTemplate(ctx, ct)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				constraint := cts.MakeConstraint(t, clienttest.KindDeny, "deny-all")
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Create a cluster-scoped review (empty namespace in the object itself)
+				review := handlertest.NewReview("", "cluster-resource", "test-data")
+
+				responses, err := c.Review(ctx, review)
+				if err != nil {
+					t.Fatalf("unexpected error during review: %v", err)
+				}
+
+				results := responses.Results()
+				if len(results) != 1 {
+					t.Errorf("got %d results, want 1. Results: %v", len(results), results)
+				}
+			})
+
+			t.Run("with namespace-aware policy", func(t *testing.T) {
+				ctx := context.Background()
+
+				c := clienttest.NewWithRegoArgs(t, mode.args)
+
+				// Use TemplateCheckNamespace which checks namespace labels
+				ct := clienttest.TemplateCheckNamespace()
+				_, err := c.AddTemplate(ctx, ct)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				constraint := cts.MakeConstraint(t, clienttest.KindCheckNamespace, "check-ns",
+					cts.WantEnvironment("production"))
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Create a cluster-scoped review (empty namespace in the object)
+				review := handlertest.NewReview("", "cluster-resource", "test-data")
+
+				responses, err := c.Review(ctx, review)
+				if err != nil {
+					t.Fatalf("unexpected error during review: %v", err)
+				}
+
+				results := responses.Results()
+				if len(results) != 1 {
+					t.Errorf("got %d results, want 1. Results: %v", len(results), results)
+				}
+
+				if len(results) > 0 {
+					wantMsg := "namespace is missing environment label"
+					if results[0].Msg != wantMsg {
+						t.Errorf("got message %q, want %q", results[0].Msg, wantMsg)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestClient_Review_DecisionCache(t *testing.T) {
+	ctx := context.Background()
+
+	review := func(t *testing.T, c *client.Client, data string) (results int, cached bool) {
+		t.Helper()
+
+		responses, err := c.Review(ctx, handlertest.NewReview("", "obj", data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(responses.Results()), isCached(responses)
+	}
+
+	c := clienttest.New(t,
+		client.Targets(&handlertest.Handler{Cache: &handlertest.Cache{}}),
+		client.DecisionCache(2))
+
+	_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraint := cts.MakeConstraint(t, clienttest.KindCheckData, "constraint", cts.WantData("bar"))
+	_, err = c.AddConstraint(ctx, constraint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results, cached := review(t, c, "foo"); results != 1 || cached {
+		t.Fatalf("first review: got %d results, cached %t; want 1 results, not cached", results, cached)
+	}
+	if results, cached := review(t, c, "foo"); results != 1 || !cached {
+		t.Fatalf("repeated review: got %d results, cached %t; want 1 results, cached", results, cached)
+	}
+
+	// Changing data must invalidate cached decisions.
+	_, err = c.AddData(ctx, &handlertest.Object{Namespace: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := review(t, c, "foo"); cached {
+		t.Fatal("review after AddData: got cached, want not cached")
+	}
+
+	// Changing Constraints must invalidate cached decisions.
+	_, err = c.RemoveConstraint(ctx, constraint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results, cached := review(t, c, "foo"); results != 0 || cached {
+		t.Fatalf("review after RemoveConstraint: got %d results, cached %t; want 0 results, not cached", results, cached)
+	}
+
+	// The least recently used decision is evicted once the cache is full.
+	review(t, c, "bar")
+	review(t, c, "qux")
+	if _, cached := review(t, c, "qux"); !cached {
+		t.Error("recent review: got not cached, want cached")
+	}
+	if _, cached := review(t, c, "foo"); cached {
+		t.Error("evicted review: got cached, want not cached")
+	}
+}
+
+// unkeyedHandler is a Handler which is not a handler.ReviewKeyer, so Client
+// can't tell which of its reviews are identical.
+type unkeyedHandler struct {
+	*handlertest.Handler
+}
+
+// ReviewKey shadows the Handler's ReviewKey with a different signature.
+func (h *unkeyedHandler) ReviewKey(interface{}) {}
+
+func TestClient_Review_DecisionCache_Keys(t *testing.T) {
+	tcs := []struct {
+		name       string
+		target     handler.TargetHandler
+		opts       []reviews.ReviewOpt
+		wantCached bool
+	}{
+		{
+			name:       "same options",
+			target:     &handlertest.Handler{Cache: &handlertest.Cache{}},
+			wantCached: true,
+		},
+		{
+			name:   "different timeout",
+			target: &handlertest.Handler{Cache: &handlertest.Cache{}},
+			opts:   []reviews.ReviewOpt{reviews.Timeout(time.Hour)},
+		},
+		{
+			name:   "target without review keys",
+			target: &unkeyedHandler{Handler: &handlertest.Handler{Cache: &handlertest.Cache{}}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.New(t, client.Targets(tc.target), client.DecisionCache(10))
+
+			_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindCheckData, "constraint", cts.WantData("bar")))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.Review(ctx, handlertest.NewReview("", "obj", "foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "foo"), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := len(responses.Results()); got != 1 {
+				t.Errorf("got %d results, want 1", got)
+			}
+			if got := isCached(responses); got != tc.wantCached {
+				t.Errorf("got cached %t, want %t", got, tc.wantCached)
+			}
+		})
+	}
+}
+
+func TestClient_Review_DecisionCache_Tracing(t *testing.T) {
+	tcs := []struct {
+		name          string
+		driverTracing bool
+		opts          []reviews.ReviewOpt
+	}{
+		{
+			name: "review tracing",
+			opts: []reviews.ReviewOpt{reviews.Tracing(true)},
+		},
+		{
+			name:          "driver tracing",
+			driverTracing: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			d, err := rego.New(rego.Tracing(tc.driverTracing))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := client.NewClient(client.Driver(d), client.DecisionCache(10),
+				client.Targets(&handlertest.Handler{Cache: &handlertest.Cache{}}),
+				client.EnforcementPoints("audit.gatekeeper.sh"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.AddTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "constraint"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 2; i++ {
+				responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"), tc.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if isCached(responses) {
+					t.Fatal("got cached response for traced review")
+				}
+
+				if responses.ByTarget[handlertest.TargetName].Trace == nil {
+					t.Error("got nil trace, want trace")
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Review_DecisionCache_Canceled(t *testing.T) {
+	slow := cts.New(cts.OptName("slows"), cts.OptCRDNames("Slows"),
+		cts.OptTargets(cts.Target(handlertest.TargetName, `package foo
+
+violation[{"msg": msg}] {
+  n := count([i | numbers.range(1, 10000)[i]; numbers.range(1, 10000)[_]])
+  n < 0
+  msg := "unreachable"
+}
+`)))
+
+	tcs := []struct {
+		name    string
+		context func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name: "deadline exceeded",
+			context: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+		},
+		{
+			name: "canceled",
+			context: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.New(t, client.DecisionCache(10))
+
+			_, err := c.AddTemplate(ctx, slow)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, "Slows", "slow"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			review := handlertest.NewReview("", "obj", "qux")
+
+			reviewCtx, cancel := tc.context()
+			defer cancel()
+
+			// The Constraint fails to evaluate as reviewCtx is done, which must not
+			// be returned for later reviews.
+			responses, err := c.Review(reviewCtx, review)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := responses.Results()
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want a single result for the failed evaluation", len(results))
+			}
+
+			responses, err = c.Review(ctx, review, reviews.Timeout(10*time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isCached(responses) {
+				t.Fatal("got cached response for review whose context was done")
+			}
+			results = responses.Results()
+			if len(results) != 1 || !strings.Contains(results[0].Msg, clienterrors.ErrEvaluationTimeout.Error()) {
+				t.Errorf("got results %v, want a single result for %v", results, clienterrors.ErrEvaluationTimeout)
+			}
+		})
+	}
+}
+
+// isCached returns whether responses were returned from the decision cache.
+func isCached(responses *types.Responses) bool {
+	for _, entry := range responses.StatsEntries {
+		if entry.Scope != instrumentation.ReviewScope {
+			continue
+		}
+		for _, stat := range entry.Stats {
+			if stat.Name == client.CachedStatName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestClient_ValidateTemplate(t *testing.T) {
+	tcs := []struct {
+		name    string
+		rego    string
+		wantErr error
+		// wantCode is the Code of the first CompileError, if any are expected.
+		wantCode string
+	}{
+		{
+			name: "valid",
+			rego: `package foo
+
+violation[{"msg": msg}] {
+  msg := "denied"
+}`,
+		},
+		{
+			name: "parse error",
+			rego: `package foo
+
+violation[{"msg": msg}] {
+  msg :=
+}`,
+			wantErr:  clienterrors.ErrInvalidConstraintTemplate,
+			wantCode: "rego_parse_error",
+		},
+		{
+			name: "unsafe variable",
+			rego: `package foo
+
+violation[{"msg": msg}] {
+  x == 1
+  msg := "denied"
+}`,
+			wantErr:  clienterrors.ErrInvalidConstraintTemplate,
+			wantCode: "rego_unsafe_var_error",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.New(t)
+
+			templ := cts.New(cts.OptTargets(cts.TargetWithVersion(handlertest.TargetName, tc.rego, cts.RegoVersion)))
+
+			report, err := c.ValidateTemplate(ctx, templ)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+
+			if report.CRD == nil {
+				t.Error("got nil CRD, want CRD")
+			}
+			if report.Driver != schema.Name {
+				t.Errorf("got driver %q, want %q", report.Driver, schema.Name)
+			}
+
+			if tc.wantCode == "" {
+				if len(report.CompileErrors) != 0 {
+					t.Errorf("got compile errors %v, want none", report.CompileErrors)
+				}
+			} else {
+				if len(report.CompileErrors) == 0 {
+					t.Fatalf("got no compile errors, want %q", tc.wantCode)
+				}
+				got := report.CompileErrors[0]
+				if got.Code != tc.wantCode || got.Target != handlertest.TargetName {
+					t.Errorf("got compile error %+v, want code %q for target %q", got, tc.wantCode, handlertest.TargetName)
+				}
+				if got.Location == nil || got.Location.Row == 0 {
+					t.Errorf("got location %v, want location", got.Location)
+				}
+			}
+
+			// The Template must not have been added.
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, cts.MockTemplate, "constraint"))
+			if !errors.Is(err, client.ErrMissingConstraintTemplate) {
+				t.Errorf("got AddConstraint error %v, want %v", err, client.ErrMissingConstraintTemplate)
+			}
+		})
+	}
+}
+
+func TestClient_ValidateTemplate_InvalidConstraints(t *testing.T) {
+	ctx := context.Background()
+	c := clienttest.New(t)
+
+	_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"valid", "invalid"} {
+		var args []cts.ConstraintArg
+		if name == "invalid" {
+			args = append(args, cts.WantData("bar"))
+		}
+		_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindCheckData, name, args...))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ := clienttest.TemplateCheckData()
+	templ.Spec.CRD.Spec.Validation.OpenAPIV3Schema.Properties["wantData"] = apiextensions.JSONSchemaProps{Type: "integer"}
+
+	report, err := c.ValidateTemplate(ctx, templ)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.InvalidConstraints) != 1 || report.InvalidConstraints[0].Constraint.GetName() != "invalid" {
+		t.Fatalf("got invalid constraints %v, want only %q", report.InvalidConstraints, "invalid")
+	}
+	if !errors.Is(report.InvalidConstraints[0].Err, constraints.ErrSchema) {
+		t.Errorf("got error %v, want %v", report.InvalidConstraints[0].Err, constraints.ErrSchema)
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	c := clienttest.New(t)
+
+	events := make(chan client.Event, 100)
+	unsubscribe := c.Subscribe(func(e client.Event) {
+		events <- e
+	})
+	defer unsubscribe()
+
+	// A subscriber which never returns must not block Client.
+	blocked := make(chan struct{})
+	defer close(blocked)
+	c.Subscribe(func(client.Event) {
+		<-blocked
+	})
+
+	_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraint := cts.MakeConstraint(t, clienttest.KindCheckData, "constraint", cts.WantData("bar"))
+	for _, want := range []string{"bar", "bar", "qux"} {
+		_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindCheckData, "constraint", cts.WantData(want)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	obj := &handlertest.Object{Namespace: "foo"}
+	_, err = c.AddData(ctx, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.RemoveData(ctx, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Review(ctx, handlertest.NewReview("", "obj", "bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.RemoveTemplate(ctx, clienttest.TemplateCheckData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []client.EventType{
+		client.TemplateAdded,
+		client.ConstraintAdded,
+		client.ConstraintUpdated,
+		client.DataAdded,
+		client.DataRemoved,
+		client.ConstraintRemoved,
+		client.TemplateRemoved,
+	}
+
+	var got []client.EventType
+	for range want {
+		select {
+		case e := <-events:
+			got = append(got, e.Type)
+
+			switch e.Type {
+			case client.TemplateAdded:
+				if e.Template.GetName() != "checkdata" || e.Driver != schema.Name {
+					t.Errorf("got template %q with driver %q, want %q with driver %q",
+						e.Template.GetName(), e.Driver, "checkdata", schema.Name)
+				}
+			case client.ConstraintRemoved:
+				if e.Constraint.GetName() != constraint.GetName() {
+					t.Errorf("got constraint %q, want %q", e.Constraint.GetName(), constraint.GetName())
+				}
+			case client.DataAdded, client.DataRemoved:
+				if e.Target != handlertest.TargetName {
+					t.Errorf("got target %q, want %q", e.Target, handlertest.TargetName)
+				}
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	unsubscribe()
+
+	_, err = c.AddTemplate(ctx, clienttest.TemplateCheckData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("got event %v after unsubscribing", e.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestClient_Subscribe_QueueFull verifies that Events published while a
+// subscriber's queue is full are dropped, and the subscriber is told how many
+// were dropped once it catches up.
+func TestClient_Subscribe_QueueFull(t *testing.T) {
+	ctx := context.Background()
+	c := clienttest.New(t, client.SubscriberQueueSize(2))
+
+	events := make(chan client.Event, 100)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	first := true
+	unsubscribe := c.Subscribe(func(e client.Event) {
+		events <- e
+		if first {
+			first = false
+			close(entered)
+			<-release
+		}
+	})
+	defer unsubscribe()
+
+	_, err := c.AddTemplate(ctx, clienttest.TemplateCheckData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscriber is now blocked delivering TemplateAdded, so two of these
+	// Events fit in its queue and the rest are dropped.
+	<-entered
+	for i := 0; i < 5; i++ {
+		_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindCheckData, fmt.Sprintf("constraint-%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+
+	type event struct {
+		Type       client.EventType
+		Constraint string
+		Dropped    int
+	}
+
+	var got []event
+	receive := func(n int) {
+		t.Helper()
+
+		for i := 0; i < n; i++ {
+			select {
+			case e := <-events:
+				got = append(got, event{Type: e.Type, Dropped: e.Dropped})
+				if e.Constraint != nil {
+					got[len(got)-1].Constraint = e.Constraint.GetName()
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("timed out waiting for events, got %v", got)
+			}
+		}
+	}
+
+	receive(4)
+
+	// The subscriber has caught up, so later Events are delivered again.
+	_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindCheckData, "constraint-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receive(1)
+
+	want := []event{
+		{Type: client.TemplateAdded},
+		{Type: client.ConstraintAdded, Constraint: "constraint-0"},
+		{Type: client.ConstraintAdded, Constraint: "constraint-1"},
+		{Type: client.EventsDropped, Dropped: 3},
+		{Type: client.ConstraintAdded, Constraint: "constraint-5"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestClient_Subscribe_Concurrent verifies that the Events of each Template
+// are delivered in the order they were committed while other Templates are
+// changed concurrently.
+func TestClient_Subscribe_Concurrent(t *testing.T) {
+	const (
+		nTemplates = 4
+		nUpdates   = 20
+	)
+
+	ctx := context.Background()
+	c := clienttest.New(t)
+
+	events := make(chan client.Event, nTemplates*(nUpdates+3))
+	unsubscribe := c.Subscribe(func(e client.Event) {
+		events <- e
+	})
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < nTemplates; i++ {
+		wg.Go(func() {
+			template := clienttest.TemplateCheckDataNumbered(i)
+			_, err := c.AddTemplate(ctx, template)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			kind := clienttest.KindCheckDataNumbered(i)
+			for j := 0; j < nUpdates; j++ {
+				_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, kind, "constraint", cts.WantData(strconv.Itoa(j))))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+
+			_, err = c.RemoveTemplate(ctx, template)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	// Describe each Event by its type and, for Constraint Events, the
+	// Constraint's parameter.
+	want := []string{string(client.TemplateAdded), string(client.ConstraintAdded) + " 0"}
+	for j := 1; j < nUpdates; j++ {
+		want = append(want, fmt.Sprintf("%s %d", client.ConstraintUpdated, j))
+	}
+	want = append(want, fmt.Sprintf("%s %d", client.ConstraintRemoved, nUpdates-1), string(client.TemplateRemoved))
+
+	got := make(map[string][]string)
+	for i := 0; i < nTemplates*len(want); i++ {
+		select {
+		case e := <-events:
+			description := string(e.Type)
+			kind := ""
+			if e.Template != nil {
+				kind = e.Template.Spec.CRD.Spec.Names.Kind
+			}
+			if e.Constraint != nil {
+				kind = e.Constraint.GetKind()
+				wantData, _, _ := unstructured.NestedString(e.Constraint.Object, "spec", "parameters", "wantData")
+				description += " " + wantData
+			}
+			got[kind] = append(got[kind], description)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+
+	for i := 0; i < nTemplates; i++ {
+		kind := clienttest.KindCheckDataNumbered(i)
+		if diff := cmp.Diff(want, got[kind]); diff != "" {
+			t.Errorf("%s: %s", kind, diff)
+		}
+	}
+}
+
+func TestClient_Subscribe_ReadsCommittedState(t *testing.T) {
+	ctx := context.Background()
+	c := clienttest.New(t, client.DecisionCache(10), client.OnSchemaChange(client.QuarantineInvalidConstraints))
+
+	v1 := cts.New(cts.OptCRDSchema(cts.PropMap{"foo": cts.PropTyped("string")}))
+
+	// v2 gives the existing Constraint a new default.
+	defaultBar := apiextensions.JSON("z")
+	bar := cts.PropTyped("string")
+	bar.Default = &defaultBar
+	v2 := cts.New(cts.OptCRDSchema(cts.PropMap{"foo": cts.PropTyped("string"), "bar": bar}))
+
+	review := handlertest.NewReview("", "obj", "qux")
+
+	// Subscribers read Client back from their callbacks, so must see every
+	// change the Events they receive describe.
+	type observed struct {
+		event      client.EventType
+		template   bool
+		constraint *unstructured.Unstructured
+		results    int
+	}
+	observations := make(chan observed, 10)
+	unsubscribe := c.Subscribe(func(e client.Event) {
+		o := observed{event: e.Type}
+		if e.Template != nil {
+			got, err := c.GetTemplate(e.Template)
+			o.template = err == nil && got.SemanticEqual(e.Template)
+		}
+		if e.Constraint != nil {
+			o.constraint, _ = c.GetConstraint(e.Constraint)
+		}
+		responses, err := c.Review(ctx, review)
+		if err == nil {
+			o.results = len(responses.Results())
+		}
+		observations <- o
+	})
+	defer unsubscribe()
+
+	// Cache a decision the following changes must invalidate.
+	_, err := c.Review(ctx, review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraint := cts.MakeConstraint(t, cts.MockTemplate, "a", cts.Set("x", "spec", "parameters", "foo"))
+
+	type want struct {
+		event    client.EventType
+		template bool
+		bar      string
+		results  int
+	}
+
+	// Each change is only made once the Events of the last were observed, so
+	// observations reflect the state after that change.
+	for _, step := range []struct {
+		name   string
+		change func() (*types.Responses, error)
+		want   []want
+	}{{
+		name:   "add template",
+		change: func() (*types.Responses, error) { return c.AddTemplate(ctx, v1) },
+		want:   []want{{event: client.TemplateAdded, template: true}},
+	}, {
+		name:   "add constraint",
+		change: func() (*types.Responses, error) { return c.AddConstraint(ctx, constraint) },
+		want:   []want{{event: client.ConstraintAdded, results: 1}},
+	}, {
+		name:   "update template",
+		change: func() (*types.Responses, error) { return c.AddTemplate(ctx, v2) },
+		want: []want{
+			{event: client.ConstraintUpdated, bar: "z", results: 1},
+			{event: client.TemplateUpdated, template: true, results: 1},
+		},
+	}} {
+		_, err = step.change()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		for _, want := range step.want {
+			select {
+			case got := <-observations:
+				if got.event != want.event {
+					t.Fatalf("%s: got event %v, want %v", step.name, got.event, want.event)
+				}
+				if got.template != want.template {
+					t.Errorf("%s: got template visible = %t, want %t", step.name, got.template, want.template)
+				}
+				if got.results != want.results {
+					t.Errorf("%s: got %d results, want %d", step.name, got.results, want.results)
+				}
+				if want.bar != "" {
+					gotBar, _, _ := unstructured.NestedString(got.constraint.Object, "spec", "parameters", "bar")
+					if gotBar != want.bar {
+						t.Errorf("%s: got spec.parameters.bar = %q, want %q", step.name, gotBar, want.bar)
+					}
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("%s: timed out waiting for %v", step.name, want.event)
+			}
+		}
+	}
+}
+
+func TestClient_Review_Timeout(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.NewWithRegoArgs(t, mode.args)
+
+			slow := cts.New(cts.OptName("slows"), cts.OptCRDNames("Slows"),
+				cts.OptTargets(cts.Target(handlertest.TargetName, `package foo
+
+violation[{"msg": msg}] {
+  n := count([i | numbers.range(1, 10000)[i]; numbers.range(1, 10000)[_]])
+  n < 0
+  msg := "unreachable"
+}
+`)))
+
+			for _, templ := range []*templates.ConstraintTemplate{slow, clienttest.TemplateDeny()} {
+				_, err := c.AddTemplate(ctx, templ)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, constraint := range []*unstructured.Unstructured{
+				cts.MakeConstraint(t, "Slows", "slow", cts.EnforcementAction("warn")),
+				cts.MakeConstraint(t, clienttest.KindDeny, "deny"),
+			} {
+				_, err := c.AddConstraint(ctx, constraint)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"), reviews.Timeout(10*time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := responses.Results()
+			if len(results) != 2 {
+				t.Fatalf("got %d results, want 2", len(results))
+			}
+
+			for _, result := range results {
+				switch result.Constraint.GetName() {
+				case "slow":
+					if !strings.Contains(result.Msg, clienterrors.ErrEvaluationTimeout.Error()) || result.EnforcementAction != "warn" {
+						t.Errorf("got message %q with action %q, want timeout with action %q",
+							result.Msg, result.EnforcementAction, "warn")
+					}
+				case "deny":
+					if strings.Contains(result.Msg, clienterrors.ErrEvaluationTimeout.Error()) {
+						t.Errorf("got message %q for constraint which did not time out", result.Msg)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Review_ExplainMatching(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.NewWithRegoArgs(t, mode.args,
+				client.Targets(&handlertest.Handler{Cache: &handlertest.Cache{}}),
+				client.EnforcementPoints("audit", "webhook"))
+
+			for _, ns := range []string{"aaa", "bbb"} {
+				_, err := c.AddData(ctx, &handlertest.Object{Namespace: ns})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, constraint := range []*unstructured.Unstructured{
+				cts.MakeConstraint(t, clienttest.KindDeny, "all"),
+				cts.MakeConstraint(t, clienttest.KindDeny, "aaa", cts.MatchNamespace("aaa")),
+				cts.MakeConstraint(t, clienttest.KindDeny, "bbb", cts.MatchNamespace("bbb")),
+				cts.MakeScopedEnforcementConstraint(t, clienttest.KindDeny, "webhook-only",
+					string(constraints.Scoped), []string{string(constraints.Deny)}, "webhook"),
+			} {
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tests := []struct {
+				name      string
+				namespace string
+				want      map[string]types.MatchDecision
+			}{
+				{
+					name:      "cached namespace",
+					namespace: "aaa",
+					want: map[string]types.MatchDecision{
+						"aaa":          types.MatchEvaluated,
+						"all":          types.MatchEvaluated,
+						"bbb":          types.MatchNotMatched,
+						"webhook-only": types.MatchSkippedEnforcementPoint,
+					},
+				},
+				{
+					name:      "uncached namespace",
+					namespace: "zzz",
+					want: map[string]types.MatchDecision{
+						"aaa":          types.MatchError,
+						"all":          types.MatchEvaluated,
+						"bbb":          types.MatchError,
+						"webhook-only": types.MatchSkippedEnforcementPoint,
+					},
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					responses, err := c.Review(ctx, handlertest.NewReview(tt.namespace, "obj", "qux"),
+						reviews.EnforcementPoint("audit"), reviews.ExplainMatching(true))
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					got := make(map[string]types.MatchDecision)
+					for _, explanation := range responses.ByTarget[handlertest.TargetName].MatchExplanations {
+						got[explanation.Constraint.GetName()] = explanation.Decision
+
+						if explanation.Decision == types.MatchError && explanation.Error == "" {
+							t.Errorf("got no error for constraint %q", explanation.Constraint.GetName())
+						}
+						if explanation.Decision == types.MatchNotMatched && len(explanation.Reasons) == 0 {
+							t.Errorf("got no reasons for constraint %q", explanation.Constraint.GetName())
+						}
+					}
+
+					if diff := cmp.Diff(tt.want, got); diff != "" {
+						t.Error(diff)
+					}
+				})
+			}
+
+			responses, err := c.Review(ctx, handlertest.NewReview("aaa", "obj", "qux"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if explanations := responses.ByTarget[handlertest.TargetName].MatchExplanations; explanations != nil {
+				t.Errorf("got %d explanations without ExplainMatching, want none", len(explanations))
+			}
+		})
+	}
+}
+
+func TestClient_ShadowTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	var mtx sync.Mutex
+	var shadowResults []client.ShadowResult
+	takeShadowResults := func() []client.ShadowResult {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		got := shadowResults
+		shadowResults = nil
+		return got
+	}
+
+	c := clienttest.New(t, client.ShadowResults(func(result client.ShadowResult) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		shadowResults = append(shadowResults, result)
+	}))
+
+	active := cts.New()
+	candidate := cts.New(cts.OptTargets(cts.Target(handlertest.TargetName, `package foo
+
+violation[{"msg": msg}] {
+  msg := "candidate"
+}
+`)))
+
+	_, err := c.AddShadowTemplate(ctx, candidate)
+	if !errors.Is(err, client.ErrMissingConstraintTemplate) {
+		t.Fatalf("got AddShadowTemplate() error = %v, want %v", err, client.ErrMissingConstraintTemplate)
+	}
+
+	_, err = c.AddTemplate(ctx, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, cts.MockTemplate, "foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherTargets := cts.New(cts.OptTargets(cts.Target("other", clienttest.ModuleDeny)))
+	_, err = c.AddShadowTemplate(ctx, otherTargets)
+	if !errors.Is(err, client.ErrShadowTemplate) {
+		t.Fatalf("got AddShadowTemplate() error = %v, want %v", err, client.ErrShadowTemplate)
+	}
+
+	_, err = c.AddShadowTemplate(ctx, candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	review := func(t *testing.T) []string {
+		t.Helper()
+
+		responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msgs []string
+		for _, result := range responses.Results() {
+			msgs = append(msgs, result.Msg)
+		}
+		return msgs
+	}
+
+	messages := func(results []*types.Result) []string {
+		var msgs []string
+		for _, result := range results {
+			msgs = append(msgs, result.Msg)
+		}
+		return msgs
+	}
+
+	// The shadow Template must not affect the decision.
+	if diff := cmp.Diff([]string{"denied"}, review(t)); diff != "" {
+		t.Error(diff)
+	}
+
+	got := takeShadowResults()
+	if len(got) != 1 {
+		t.Fatalf("got %d shadow results, want 1", len(got))
+	}
+	if got[0].Err != nil {
+		t.Fatal(got[0].Err)
+	}
+	if got[0].Template != cts.MockTemplateName || got[0].Target != handlertest.TargetName {
+		t.Errorf("got shadow result for template %q and target %q", got[0].Template, got[0].Target)
+	}
+	if diff := cmp.Diff([]string{"candidate"}, messages(got[0].Added)); diff != "" {
+		t.Errorf("added: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"denied"}, messages(got[0].Removed)); diff != "" {
+		t.Errorf("removed: %s", diff)
+	}
+
+	_, err = c.DiscardShadowTemplate(ctx, candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	review(t)
+	if got := takeShadowResults(); len(got) != 0 {
+		t.Errorf("got %d shadow results after discarding shadow template, want 0", len(got))
+	}
+
+	_, err = c.PromoteShadowTemplate(ctx, candidate)
+	if !errors.Is(err, client.ErrMissingShadowTemplate) {
+		t.Fatalf("got PromoteShadowTemplate() error = %v, want %v", err, client.ErrMissingShadowTemplate)
+	}
+
+	_, err = c.AddShadowTemplate(ctx, candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.PromoteShadowTemplate(ctx, candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"candidate"}, review(t)); diff != "" {
+		t.Error(diff)
+	}
+	if got := takeShadowResults(); len(got) != 0 {
+		t.Errorf("got %d shadow results after promoting shadow template, want 0", len(got))
+	}
+}
+
+func TestClient_Review_Exemptions(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := clienttest.NewWithRegoArgs(t, mode.args)
+
+			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"a", "b"} {
+				_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, tc := range []struct {
+				name      string
+				exemption *client.Exemption
+			}{{
+				name:      "no name",
+				exemption: &client.Exemption{},
+			}, {
+				name: "unknown target",
+				exemption: &client.Exemption{
+					Name:      "unknown",
+					Selectors: map[string]interface{}{"other": map[string]interface{}{}},
+				},
+			}, {
+				name: "invalid selector",
+				exemption: &client.Exemption{
+					Name:      "invalid",
+					Selectors: map[string]interface{}{handlertest.TargetName: "ns"},
+				},
+			}} {
+				_, err = c.AddExemption(tc.exemption)
+				if !errors.Is(err, client.ErrInvalidExemption) {
+					t.Errorf("%s: got AddExemption() error = %v, want %v", tc.name, err, client.ErrInvalidExemption)
+				}
+			}
+
+			_, err = c.AddExemption(&client.Exemption{
+				Name:        "exempt-a",
+				Kinds:       []string{clienttest.KindDeny},
+				Constraints: []string{"a"},
+				Selectors:   map[string]interface{}{handlertest.TargetName: map[string]interface{}{"namespace": "ns"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			review := func(t *testing.T, namespace string) *types.Response {
+				t.Helper()
+
+				responses, err := c.Review(ctx, handlertest.NewReview(namespace, "obj", "qux"), reviews.ExplainMatching(true))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return responses.ByTarget[handlertest.TargetName]
+			}
+
+			constraintNames := func(results []*types.Result) []string {
+				var names []string
+				for _, result := range results {
+					names = append(names, result.Constraint.GetName())
+				}
+				return names
+			}
+
+			resp := review(t, "ns")
+			if diff := cmp.Diff([]string{"b"}, constraintNames(resp.Results)); diff != "" {
+				t.Error(diff)
+			}
+			if len(resp.Exempted) != 1 || resp.Exempted[0].Constraint.GetName() != "a" || resp.Exempted[0].Exemption != "exempt-a" {
+				t.Errorf("got exempted %v, want constraint %q exempted by %q", resp.Exempted, "a", "exempt-a")
+			}
+			for _, explanation := range resp.MatchExplanations {
+				want := types.MatchEvaluated
+				if explanation.Constraint.GetName() == "a" {
+					want = types.MatchExempted
+				}
+				if explanation.Decision != want {
+					t.Errorf("got decision %q for constraint %q, want %q", explanation.Decision, explanation.Constraint.GetName(), want)
+				}
+			}
+
+			resp = review(t, "other")
+			if diff := cmp.Diff([]string{"a", "b"}, constraintNames(resp.Results)); diff != "" {
+				t.Error(diff)
+			}
+			if len(resp.Exempted) != 0 {
+				t.Errorf("got %d exempted constraints for object not selected by exemption, want 0", len(resp.Exempted))
+			}
+
+			got, err := c.GetExemption("exempt-a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "exempt-a" {
+				t.Errorf("got exemption %q, want %q", got.Name, "exempt-a")
+			}
+
+			_, err = c.RemoveExemption("exempt-a")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp = review(t, "ns")
+			if diff := cmp.Diff([]string{"a", "b"}, constraintNames(resp.Results)); diff != "" {
+				t.Error(diff)
+			}
+
+			_, err = c.GetExemption("exempt-a")
+			if !errors.Is(err, client.ErrMissingExemption) {
+				t.Errorf("got GetExemption() error = %v, want %v", err, client.ErrMissingExemption)
+			}
+		})
+	}
+}
+
+// fakeClock is a clock.PassiveClock whose time is set by tests.
+type fakeClock struct {
+	mtx sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *fakeClock) Step(d time.Duration) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestClient_Review_ActiveWindow(t *testing.T) {
+	for _, mode := range evaluationModes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			later := start.Add(time.Hour).Format(time.RFC3339)
+			clk := &fakeClock{now: start}
+
+			c := clienttest.NewWithRegoArgs(t, mode.args, client.Clock(clk), client.DecisionCache(10))
+
+			_, err := c.AddTemplate(ctx, clienttest.TemplateDeny())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			windowed := []*unstructured.Unstructured{
+				cts.MakeConstraint(t, clienttest.KindDeny, "always"),
+				cts.MakeConstraint(t, clienttest.KindDeny, "expiring", cts.Set(later, "spec", "activeUntil")),
+				cts.MakeConstraint(t, clienttest.KindDeny, "future", cts.Set(later, "spec", "activeFrom")),
+			}
+			for _, constraint := range windowed {
+				_, err = c.AddConstraint(ctx, constraint)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "empty",
+				cts.Set(later, "spec", "activeFrom"),
+				cts.Set(start.Format(time.RFC3339), "spec", "activeUntil")))
+			if !errors.Is(err, constraints.ErrInvalidConstraint) {
+				t.Errorf("got AddConstraint() error = %v, want %v", err, constraints.ErrInvalidConstraint)
+			}
+
+			review := func(t *testing.T) *types.Response {
+				t.Helper()
+
+				responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"), reviews.ExplainMatching(true))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return responses.ByTarget[handlertest.TargetName]
+			}
+
+			check := func(t *testing.T, resp *types.Response, wantEvaluated []string, wantInactive string) {
+				t.Helper()
+
+				var gotEvaluated []string
+				for _, result := range resp.Results {
+					gotEvaluated = append(gotEvaluated, result.Constraint.GetName())
+				}
+				if diff := cmp.Diff(wantEvaluated, gotEvaluated); diff != "" {
+					t.Error(diff)
+				}
+
+				if len(resp.Inactive) != 1 || resp.Inactive[0].Constraint.GetName() != wantInactive || resp.Inactive[0].Reason == "" {
+					t.Errorf("got inactive %v, want only %q with a reason", resp.Inactive, wantInactive)
+				}
+
+				for _, explanation := range resp.MatchExplanations {
+					want := types.MatchEvaluated
+					if explanation.Constraint.GetName() == wantInactive {
+						want = types.MatchInactive
+					}
+					if explanation.Decision != want {
+						t.Errorf("got decision %q for constraint %q, want %q", explanation.Decision, explanation.Constraint.GetName(), want)
+					}
+				}
+			}
+
+			check(t, review(t), []string{"always", "expiring"}, "future")
+
+			// The previous review must not have been cached, or it would be returned
+			// even though the windows have changed.
+			clk.Step(2 * time.Hour)
+			check(t, review(t), []string{"always", "future"}, "expiring")
+		})
+	}
+}
+
+// blockingDriver is a Driver whose AddConstraint waits until release is
+// closed.
+type blockingDriver struct {
+	drivers.Driver
+
+	adding  chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingDriver) AddConstraint(ctx context.Context, constraint *unstructured.Unstructured) error {
+	close(d.adding)
+	<-d.release
+	return d.Driver.AddConstraint(ctx, constraint)
+}
+
+// TestClient_Review_DuringMutation verifies that Review does not wait for
+// mutations which are blocked in a driver, and does not see their changes
+// until they complete.
+func TestClient_Review_DuringMutation(t *testing.T) {
+	ctx := context.Background()
+
+	regoDriver, err := rego.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &blockingDriver{Driver: regoDriver, adding: make(chan struct{}), release: make(chan struct{})}
+
+	c, err := client.NewClient(
+		client.Targets(&handlertest.Handler{}),
+		client.Driver(d),
+		client.EnforcementPoints("audit.gatekeeper.sh"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.AddTemplate(ctx, clienttest.TemplateDeny())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added := make(chan error)
+	go func() {
+		_, err := c.AddConstraint(ctx, cts.MakeConstraint(t, clienttest.KindDeny, "constraint"))
+		added <- err
+	}()
+	<-d.adding
+
+	responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(responses.Results()); got != 0 {
+		t.Errorf("got %d results while the constraint was being added, want 0", got)
+	}
+
+	close(d.release)
+	if err = <-added; err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err = c.Review(ctx, handlertest.NewReview("", "obj", "qux"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(responses.Results()); got != 1 {
+		t.Errorf("got %d results after the constraint was added, want 1", got)
+	}
+}
+
+// TestClient_ConcurrentMutations verifies that Constraints of different kinds
+// may be added concurrently with each other and with reviews.
+func TestClient_ConcurrentMutations(t *testing.T) {
+	ctx := context.Background()
+	c := clienttest.New(t)
+
+	const kinds = 4
+	const constraintsPerKind = 10
+
+	for i := 0; i < kinds; i++ {
+		_, err := c.AddTemplate(ctx, cts.New(cts.OptName(fmt.Sprintf("kind%d", i)), cts.OptCRDNames(fmt.Sprintf("Kind%d", i))))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan struct{})
+	reviewErrs := make(chan error, 1)
+	go func() {
+		defer close(reviewErrs)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			_, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"))
+			if err != nil {
+				reviewErrs <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, kinds*constraintsPerKind)
+	for i := 0; i < kinds; i++ {
+		wg.Add(1)
+		go func(kind string) {
+			defer wg.Done()
+			for j := 0; j < constraintsPerKind; j++ {
+				_, err := c.AddConstraint(ctx, cts.MakeConstraint(t, kind, fmt.Sprintf("constraint%d", j)))
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(fmt.Sprintf("Kind%d", i))
+	}
+	wg.Wait()
+	close(done)
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	for err := range reviewErrs {
+		t.Error(err)
+	}
+
+	responses, err := c.Review(ctx, handlertest.NewReview("", "obj", "qux"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(responses.Results()); got != kinds*constraintsPerKind {
+		t.Errorf("got %d results, want %d", got, kinds*constraintsPerKind)
+	}
 }
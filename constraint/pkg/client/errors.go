@@ -26,6 +26,22 @@ var (
 	ErrReview = errors.New("target.HandleReview failed")
 	// ErrUnsupportedEnforcementPoints indicates unsupported enforcement points.
 	ErrUnsupportedEnforcementPoints = errors.New("enforcement point not supported by client")
+	// ErrSnapshot indicates a failure to snapshot a client.
+	ErrSnapshot = errors.New("unable to snapshot client")
+	// ErrInvalidSnapshot indicates a snapshot could not be read or restored.
+	ErrInvalidSnapshot = errors.New("invalid client snapshot")
+	// ErrShadowTemplate indicates a ConstraintTemplate cannot shadow the active
+	// version of the Template.
+	ErrShadowTemplate = errors.New("unable to shadow ConstraintTemplate")
+	// ErrMissingShadowTemplate indicates a Template has no shadow version.
+	ErrMissingShadowTemplate = errors.New("missing shadow ConstraintTemplate")
+	// ErrInvalidExemption indicates an Exemption could not be added.
+	ErrInvalidExemption = errors.New("invalid Exemption")
+	// ErrMissingExemption indicates a required Exemption is missing.
+	ErrMissingExemption = errors.New("missing Exemption")
+	// ErrSchemaChange indicates an updated ConstraintTemplate's schema does not
+	// accept some of the Template's existing Constraints.
+	ErrSchemaChange = errors.New("ConstraintTemplate schema change invalidates existing Constraints")
 )
 
 // IsUnrecognizedConstraintError returns true if err is an ErrMissingConstraint.
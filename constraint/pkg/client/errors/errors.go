@@ -3,7 +3,10 @@
 //nolint:revive // Package name intentionally conflicts with stdlib; use alias "clienterrors" when importing.
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrAutoreject is returned when constraints cannot be matched.
@@ -37,6 +40,22 @@ var (
 	ErrInvalidModule = errors.New("invalid module")
 	// ErrChangeTargets is returned when attempting to change targets on a ConstraintTemplate with Constraints.
 	ErrChangeTargets = errors.New("ConstraintTemplates with Constraints may not change targets")
+	// ErrChangeScope is returned when attempting to change the scope of a ConstraintTemplate with Constraints.
+	ErrChangeScope = errors.New("ConstraintTemplates with Constraints may not change scope")
+	// ErrEvaluationTimeout is returned when evaluating a Template's Constraints
+	// takes longer than allowed.
+	ErrEvaluationTimeout = errors.New("evaluation timed out")
+	// ErrEvaluationLimit is returned when evaluating a Template's Constraints
+	// exceeds a resource limit. Each limit has its own error wrapping it.
+	ErrEvaluationLimit = errors.New("evaluation limit exceeded")
+	// ErrStepLimit is returned when evaluating a Template's Constraints takes
+	// more steps than allowed.
+	ErrStepLimit = fmt.Errorf("%w: too many evaluation steps", ErrEvaluationLimit)
+	// ErrResultLimit is returned when a Constraint returns more results than
+	// allowed.
+	ErrResultLimit = fmt.Errorf("%w: too many results", ErrEvaluationLimit)
+	// ErrReviewSizeLimit is returned when a review is larger than allowed.
+	ErrReviewSizeLimit = fmt.Errorf("%w: review too large", ErrEvaluationLimit)
 	// ErrNoDriver is returned when no language driver handles the constraint template.
 	ErrNoDriver = errors.New("no language driver is installed that handles this constraint template")
 )
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+)
+
+// EventType identifies the kind of change an Event describes.
+type EventType string
+
+const (
+	// TemplateAdded means a Template which Client did not know about was added.
+	TemplateAdded EventType = "TemplateAdded"
+	// TemplateUpdated means a Template was replaced with a different version, or
+	// moved to a different Driver.
+	TemplateUpdated EventType = "TemplateUpdated"
+	// TemplateRemoved means a Template was removed, after any Constraints of the
+	// Template.
+	TemplateRemoved EventType = "TemplateRemoved"
+
+	// ConstraintAdded means a Constraint which Client did not know about was
+	// added.
+	ConstraintAdded EventType = "ConstraintAdded"
+	// ConstraintUpdated means a Constraint was replaced with a different
+	// version.
+	ConstraintUpdated EventType = "ConstraintUpdated"
+	// ConstraintRemoved means a Constraint was removed, either directly or
+	// because its Template was removed.
+	ConstraintRemoved EventType = "ConstraintRemoved"
+
+	// DataAdded means data was added or replaced for a target.
+	DataAdded EventType = "DataAdded"
+	// DataRemoved means data was removed for a target.
+	DataRemoved EventType = "DataRemoved"
+
+	// EventsDropped means Events were not delivered to the subscriber because
+	// its queue was full. Subscribers which track Client's state should resync
+	// it, for example with ListTemplates and ListConstraints.
+	EventsDropped EventType = "EventsDropped"
+)
+
+// defaultSubscriberQueueSize is how many Events may wait to be delivered to
+// each subscriber unless set with SubscriberQueueSize.
+const defaultSubscriberQueueSize = 1024
+
+// Event describes a change committed to a Client.
+type Event struct {
+	Type EventType
+
+	// Template is a copy of the Template, for Template events.
+	Template *templates.ConstraintTemplate
+
+	// Driver is the name of the Driver enforcing the Template, for
+	// TemplateAdded and TemplateUpdated events.
+	Driver string
+
+	// PreviousDriver is the name of the Driver which enforced the Template
+	// before a TemplateUpdated event, if the Template moved to a different
+	// Driver.
+	PreviousDriver string
+
+	// Constraint is a copy of the Constraint with default parameters applied,
+	// for Constraint events.
+	Constraint *unstructured.Unstructured
+
+	// Target is the name of the target whose data changed, for data events.
+	Target string
+
+	// Key is the path of the changed data relative to the target's data, as
+	// returned by the target's ProcessData, for data events.
+	Key []string
+
+	// Dropped is the number of Events which were not delivered, for
+	// EventsDropped events.
+	Dropped int
+}
+
+// Subscribe calls fn with an Event for each change committed to Client after
+// Subscribe returns. Events are ordered per Template: changes to a Template and
+// its Constraints are delivered in the order they were committed, but changes
+// to different Templates are committed concurrently and may be delivered in
+// either order. Changes to data are only ordered with respect to other changes
+// to the same data.
+//
+// Each subscriber receives Events on its own goroutine and Events waiting to
+// be delivered are buffered, so slow subscribers do not block Client or each
+// other. fn is never called concurrently with itself. Events are shared by
+// all subscribers, so fn must not modify them.
+//
+// Up to SubscriberQueueSize Events are buffered for each subscriber. Once its
+// buffer is full, further Events are dropped until fn has been called with
+// every buffered Event. fn is then called with a single EventsDropped Event
+// counting the dropped Events, before any later Events.
+//
+// The returned function stops delivery of Events. Events which have not yet
+// been delivered are discarded. It is safe to call more than once.
+func (c *Client) Subscribe(fn func(Event)) func() {
+	s := &subscriber{fn: fn, size: c.subscriberQueueSize}
+	s.ready = sync.NewCond(&s.mtx)
+
+	c.subscribers.add(s)
+	go s.run()
+
+	return func() {
+		c.subscribers.remove(s)
+		s.stop()
+	}
+}
+
+// publish queues events for delivery to every subscriber. Callers must publish
+// Events in the order the changes they describe were committed, and only once
+// the state they describe is visible. Events for a Template must be published
+// while holding its lock, so they are queued in the order they were committed. Decisions cached before the changes are
+// invalidated first, so subscribers which review from their callbacks see the
+// changes too.
+func (c *Client) publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	c.invalidateDecisions()
+	for _, e := range events {
+		c.subscribers.publish(e)
+	}
+}
+
+// subscribers is the set of functions subscribed to a Client's Events. The
+// zero value has no subscribers and is ready to use. Threadsafe.
+type subscribers struct {
+	mtx sync.Mutex
+	set map[*subscriber]bool
+}
+
+func (s *subscribers) add(sub *subscriber) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.set == nil {
+		s.set = make(map[*subscriber]bool)
+	}
+	s.set[sub] = true
+}
+
+func (s *subscribers) remove(sub *subscriber) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.set, sub)
+}
+
+// publish queues e for every subscriber. Holding the lock ensures every
+// subscriber sees concurrently published Events in the same order.
+func (s *subscribers) publish(e Event) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for sub := range s.set {
+		sub.enqueue(e)
+	}
+}
+
+// subscriber delivers Events to fn in the order they were queued.
+type subscriber struct {
+	fn func(Event)
+
+	// size is the most Events queue may hold.
+	size int
+
+	mtx   sync.Mutex
+	ready *sync.Cond
+	queue []Event
+	// dropped is the number of Events dropped since queue was last full. While
+	// non-zero, Events are dropped rather than queued.
+	dropped int
+	stopped bool
+}
+
+func (s *subscriber) enqueue(e Event) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.stopped {
+		return
+	}
+	if s.dropped > 0 || len(s.queue) >= s.size {
+		s.dropped++
+		return
+	}
+	s.queue = append(s.queue, e)
+	s.ready.Signal()
+}
+
+func (s *subscriber) stop() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.stopped = true
+	s.queue = nil
+	s.dropped = 0
+	s.ready.Signal()
+}
+
+// run delivers queued Events until stop is called.
+func (s *subscriber) run() {
+	for {
+		s.mtx.Lock()
+		for len(s.queue) == 0 && s.dropped == 0 && !s.stopped {
+			s.ready.Wait()
+		}
+		if s.stopped {
+			s.mtx.Unlock()
+			return
+		}
+
+		var e Event
+		if len(s.queue) > 0 {
+			e = s.queue[0]
+			s.queue[0] = Event{}
+			s.queue = s.queue[1:]
+		} else {
+			// Every Event queued before the drops has been delivered.
+			e = Event{Type: EventsDropped, Dropped: s.dropped}
+			s.dropped = 0
+		}
+		s.mtx.Unlock()
+
+		s.fn(e)
+	}
+}
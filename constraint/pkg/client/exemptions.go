@@ -0,0 +1,253 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/constraints"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// Exemption exempts the objects selected by target-specific selectors from
+// some Constraints. Exempted Constraints are not evaluated for those objects,
+// and are instead listed in the Exempted field of the target's Response.
+type Exemption struct {
+	// Name uniquely identifies the Exemption.
+	Name string `json:"name"`
+
+	// Kinds are the kinds of Constraints the Exemption applies to. If empty,
+	// the Exemption applies to Constraints of every kind.
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Constraints are the names of the Constraints the Exemption applies to. If
+	// empty, the Exemption applies to every Constraint of Kinds.
+	Constraints []string `json:"constraints,omitempty"`
+
+	// Selectors is a map from target name to the untyped JSON selector of the
+	// objects the Exemption applies to, which is interpreted by the target's
+	// handler.ExemptionMatcher. The Exemption does not apply to reviews for
+	// targets without a selector.
+	Selectors map[string]interface{} `json:"selectors"`
+}
+
+// DeepCopy returns a deep copy of the Exemption.
+func (e *Exemption) DeepCopy() *Exemption {
+	if e == nil {
+		return nil
+	}
+
+	out := &Exemption{Name: e.Name}
+	if e.Kinds != nil {
+		out.Kinds = append([]string(nil), e.Kinds...)
+	}
+	if e.Constraints != nil {
+		out.Constraints = append([]string(nil), e.Constraints...)
+	}
+	if e.Selectors != nil {
+		out.Selectors = make(map[string]interface{}, len(e.Selectors))
+		for target, selector := range e.Selectors {
+			out.Selectors[target] = runtime.DeepCopyJSONValue(selector)
+		}
+	}
+
+	return out
+}
+
+// exemptionClient is an Exemption along with the per-target Matchers for its
+// selectors.
+//
+// Not threadsafe.
+type exemptionClient struct {
+	exemption *Exemption
+
+	kinds       map[string]bool
+	constraints map[string]bool
+
+	matchers map[string]constraints.Matcher
+}
+
+// appliesTo returns true if the Exemption applies to constraint.
+func (e *exemptionClient) appliesTo(constraint *unstructured.Unstructured) bool {
+	if len(e.kinds) != 0 && !e.kinds[constraint.GetKind()] {
+		return false
+	}
+
+	return len(e.constraints) == 0 || e.constraints[constraint.GetName()]
+}
+
+// AddExemption adds exemption to Client, replacing any existing Exemption with
+// the same name. Every target exemption has a selector for must implement
+// handler.ExemptionMatcher.
+func (c *Client) AddExemption(exemption *Exemption) (*types.Responses, error) {
+	resp := types.NewResponses()
+
+	if exemption == nil || exemption.Name == "" {
+		return resp, fmt.Errorf("%w: exemptions must have a name", ErrInvalidExemption)
+	}
+
+	entry := &exemptionClient{
+		exemption:   exemption.DeepCopy(),
+		kinds:       make(map[string]bool, len(exemption.Kinds)),
+		constraints: make(map[string]bool, len(exemption.Constraints)),
+		matchers:    make(map[string]constraints.Matcher, len(exemption.Selectors)),
+	}
+	for _, kind := range exemption.Kinds {
+		entry.kinds[kind] = true
+	}
+	for _, name := range exemption.Constraints {
+		entry.constraints[name] = true
+	}
+
+	for targetName, selector := range entry.exemption.Selectors {
+		target, found := c.targets[targetName]
+		if !found {
+			return resp, fmt.Errorf("%w: exemption %q has a selector for unknown target %q",
+				ErrInvalidExemption, exemption.Name, targetName)
+		}
+
+		exemptionMatcher, ok := target.(handler.ExemptionMatcher)
+		if !ok {
+			return resp, fmt.Errorf("%w: target %q does not support exemptions",
+				ErrInvalidExemption, targetName)
+		}
+
+		matcher, err := exemptionMatcher.ToExemptionMatcher(selector)
+		if err != nil {
+			return resp, fmt.Errorf("%w: invalid selector for target %q: %w",
+				ErrInvalidExemption, targetName, err)
+		}
+		entry.matchers[targetName] = matcher
+	}
+
+	defer c.invalidateDecisions()
+
+	c.update(func(s *state) {
+		if _, found := s.exemptions[exemption.Name]; !found {
+			s.exemptionNames = append(s.exemptionNames, exemption.Name)
+			sort.Strings(s.exemptionNames)
+		}
+		s.exemptions[exemption.Name] = entry
+	})
+
+	for targetName := range entry.matchers {
+		resp.Handled[targetName] = true
+	}
+	return resp, nil
+}
+
+// RemoveExemption removes the Exemption with the given name. Succeeds if the
+// Exemption does not exist.
+func (c *Client) RemoveExemption(name string) (*types.Responses, error) {
+	resp := types.NewResponses()
+
+	defer c.invalidateDecisions()
+
+	var entry *exemptionClient
+	c.update(func(s *state) {
+		entry = s.exemptions[name]
+		if entry == nil {
+			return
+		}
+
+		delete(s.exemptions, name)
+		for i, exemptionName := range s.exemptionNames {
+			if exemptionName == name {
+				s.exemptionNames = append(s.exemptionNames[:i], s.exemptionNames[i+1:]...)
+				break
+			}
+		}
+	})
+	if entry == nil {
+		return resp, nil
+	}
+
+	for targetName := range entry.matchers {
+		resp.Handled[targetName] = true
+	}
+	return resp, nil
+}
+
+// GetExemption returns a copy of the Exemption with the given name.
+func (c *Client) GetExemption(name string) (*Exemption, error) {
+	entry, found := c.getState().exemptions[name]
+	if !found {
+		return nil, fmt.Errorf("%w: exemption %q not found", ErrMissingExemption, name)
+	}
+
+	return entry.exemption.DeepCopy(), nil
+}
+
+// reviewExemptions decides which Exemptions apply to a single review for a
+// target. Each Exemption's Matcher runs at most once per review.
+//
+// Not threadsafe.
+type reviewExemptions struct {
+	state  *state
+	target string
+	review interface{}
+
+	// selected caches whether each Exemption's selector matched the review.
+	selected map[string]bool
+}
+
+// exemptionsFor returns the Exemptions in s for review, or nil if s has no
+// Exemptions.
+func (s *state) exemptionsFor(target string, review interface{}) *reviewExemptions {
+	if len(s.exemptions) == 0 {
+		return nil
+	}
+
+	return &reviewExemptions{
+		state:    s,
+		target:   target,
+		review:   review,
+		selected: make(map[string]bool),
+	}
+}
+
+// exemption returns the name of the first Exemption which exempts the review
+// from constraint, or "" if none do. Exemptions whose Matchers return an error
+// do not apply, so the Constraint is evaluated.
+func (e *reviewExemptions) exemption(constraint *unstructured.Unstructured) string {
+	if e == nil {
+		return ""
+	}
+
+	for _, name := range e.state.exemptionNames {
+		entry := e.state.exemptions[name]
+		if !entry.appliesTo(constraint) {
+			continue
+		}
+
+		selected, cached := e.selected[name]
+		if !cached {
+			if matcher, found := entry.matchers[e.target]; found {
+				matches, err := matcher.Match(e.review)
+				selected = err == nil && matches
+			}
+			e.selected[name] = selected
+		}
+
+		if selected {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// markExempted records in explanations that constraint was exempted.
+func markExempted(explanations []*types.MatchExplanation, constraint *unstructured.Unstructured, exemption string) {
+	for _, explanation := range explanations {
+		if explanation.Constraint == constraint {
+			explanation.Decision = types.MatchExempted
+			explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("object is exempted by %q", exemption))
+			explanation.Error = ""
+			return
+		}
+	}
+}
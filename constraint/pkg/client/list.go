@@ -0,0 +1,217 @@
+package client
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+)
+
+// Selector filters the Templates and Constraints returned by ListTemplates and
+// ListConstraints. Every non-empty field must match for an object to be
+// selected, so the zero Selector selects everything.
+type Selector struct {
+	// Labels selects objects by their labels.
+	Labels labels.Selector
+
+	// EnforcementAction selects Constraints with this spec.enforcementAction,
+	// and Templates with at least one such Constraint.
+	EnforcementAction string
+
+	// Target selects Templates which apply to the named target, and their
+	// Constraints.
+	Target string
+
+	// Driver selects Templates enforced by the named driver, and their
+	// Constraints. Drivers are named after the engine whose code they run.
+	Driver string
+
+	// NeedsConstraintReplay, if set, selects Templates whose Constraints do or
+	// do not need to be replayed into their driver, and their Constraints.
+	NeedsConstraintReplay *bool
+}
+
+// TemplateInfo is a Template known to Client along with its state.
+type TemplateInfo struct {
+	// Template is a copy of the Template.
+	Template *templates.ConstraintTemplate
+
+	// Driver is the name of the driver enforcing the Template.
+	Driver string
+
+	// Targets are the names of the targets the Template applies to.
+	Targets []string
+
+	// NeedsConstraintReplay is true if adding the Template's Constraints to
+	// Driver failed after the Template last changed drivers. The replay is
+	// attempted again the next time the Template is added.
+	NeedsConstraintReplay bool
+
+	// Constraints is the number of Constraints of the Template.
+	Constraints int
+}
+
+// ConstraintInfo is a Constraint known to Client along with its state.
+type ConstraintInfo struct {
+	// Constraint is a copy of the Constraint.
+	Constraint *unstructured.Unstructured
+
+	// Template is the name of the Constraint's Template.
+	Template string
+
+	// EnforcementAction is the Constraint's spec.enforcementAction.
+	EnforcementAction string
+
+	// Driver is the name of the driver enforcing the Constraint.
+	Driver string
+
+	// NeedsConstraintReplay is true if the Constraint may be missing from
+	// Driver. See TemplateInfo.NeedsConstraintReplay.
+	NeedsConstraintReplay bool
+
+	// QuarantineReason, if set, is why the Constraint was quarantined after its
+	// Template's schema changed. See QuarantineInvalidConstraints.
+	QuarantineReason string
+}
+
+// ListTemplates returns the Templates selected by selector, ordered by name.
+func (c *Client) ListTemplates(selector Selector) []*TemplateInfo {
+	s := c.getState()
+
+	var result []*TemplateInfo
+	for _, name := range s.templateNames() {
+		cached := s.templates[name]
+		driver := c.driverForTemplate(cached.template)
+		if !selector.selectsTemplate(cached, driver) || !selector.selectsLabels(cached.template.GetLabels()) {
+			continue
+		}
+
+		if selector.EnforcementAction != "" && !selector.selectsAnyConstraint(cached) {
+			continue
+		}
+
+		result = append(result, &TemplateInfo{
+			Template:              cached.getTemplate(),
+			Driver:                driver,
+			Targets:               cached.targetNames(),
+			NeedsConstraintReplay: cached.needsConstraintReplay,
+			Constraints:           cached.constraints.len(),
+		})
+	}
+
+	return result
+}
+
+// ListConstraints returns the Constraints of kind selected by selector,
+// ordered by kind and then by namespace and name. If kind is empty, lists
+// Constraints of every kind. Labels in selector apply to the Constraints
+// rather than their Templates.
+//
+// Returns an error if kind is not empty and there is no Template for it.
+func (c *Client) ListConstraints(kind string, selector Selector) ([]*ConstraintInfo, error) {
+	s := c.getState()
+
+	names := s.templateNames()
+	if kind != "" {
+		name := strings.ToLower(kind)
+		if s.templates[name] == nil {
+			return nil, templateNotFound(name)
+		}
+		names = []string{name}
+	}
+
+	var result []*ConstraintInfo
+	for _, name := range names {
+		cached := s.templates[name]
+		driver := c.driverForTemplate(cached.template)
+		if !selector.selectsTemplate(cached, driver) {
+			continue
+		}
+
+		for _, key := range cached.constraintKeys() {
+			constraint := cached.constraint(key)
+			if !selector.selectsConstraint(constraint) {
+				continue
+			}
+
+			result = append(result, &ConstraintInfo{
+				Constraint:            constraint.getConstraint(),
+				Template:              name,
+				EnforcementAction:     constraint.enforcementAction,
+				Driver:                driver,
+				NeedsConstraintReplay: cached.needsConstraintReplay,
+				QuarantineReason:      constraint.quarantined,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// templateNames returns the names of every Template in s in sorted order.
+func (s *state) templateNames() []string {
+	names := make([]string, 0, len(s.templates))
+	for name := range s.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// selectsTemplate returns true if the Template-level fields of s match cached.
+// Labels are not checked, as they apply to whichever kind of object is being
+// listed.
+func (s *Selector) selectsTemplate(cached *templateClient, driver string) bool {
+	if s.Driver != "" && s.Driver != driver {
+		return false
+	}
+
+	if s.NeedsConstraintReplay != nil && *s.NeedsConstraintReplay != cached.needsConstraintReplay {
+		return false
+	}
+
+	if s.Target != "" {
+		found := false
+		for _, target := range cached.targetNames() {
+			if target == s.Target {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectsAnyConstraint returns true if any of cached's Constraints have the
+// EnforcementAction of s.
+func (s *Selector) selectsAnyConstraint(cached *templateClient) bool {
+	for _, constraint := range cached.constraints.all() {
+		if constraint.enforcementAction == s.EnforcementAction {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectsConstraint returns true if the Constraint-level fields of s match
+// constraint.
+func (s *Selector) selectsConstraint(constraint *constraintClient) bool {
+	if s.EnforcementAction != "" && s.EnforcementAction != constraint.enforcementAction {
+		return false
+	}
+
+	return s.selectsLabels(constraint.constraint.GetLabels())
+}
+
+func (s *Selector) selectsLabels(objLabels map[string]string) bool {
+	return s.Labels == nil || s.Labels.Matches(labels.Set(objLabels))
+}
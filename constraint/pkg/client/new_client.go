@@ -1,19 +1,27 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	regoSchema "github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego/schema"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"k8s.io/utils/clock"
 )
 
 // NewClient creates a new client.
 func NewClient(opts ...Opt) (*Client, error) {
 	c := &Client{
-		templates:      make(map[string]*templateClient),
-		drivers:        make(map[string]drivers.Driver),
-		driverPriority: make(map[string]int),
+		drivers:             make(map[string]drivers.Driver),
+		driverPriority:      make(map[string]int),
+		clock:               clock.RealClock{},
+		schemaChangePolicy:  IgnoreSchemaChange,
+		subscriberQueueSize: defaultSubscriberQueueSize,
 	}
 
+	c.state.Store(newState())
+
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
 			return nil, err
@@ -25,10 +33,53 @@ func NewClient(opts ...Opt) (*Client, error) {
 			ErrCreatingClient)
 	}
 
+	var snap *snapshot
+	if c.restoreFrom != nil {
+		var err error
+		snap, err = readSnapshot(c.restoreFrom)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCreatingClient, err)
+		}
+
+		if len(c.enforcementPoints) == 0 {
+			c.enforcementPoints = snap.EnforcementPoints
+		}
+	}
+
 	if len(c.enforcementPoints) == 0 {
 		return nil, fmt.Errorf("%w: must specify at least one enforcement point with client.EnforcementPoints",
 			ErrCreatingClient)
 	}
 
+	if c.atomicData {
+		if d, ok := c.drivers[regoSchema.Name]; ok {
+			if _, ok := d.(drivers.DataTransactor); !ok {
+				return nil, fmt.Errorf("%w: driver %q does not support atomic data writes",
+					ErrCreatingClient, d.Name())
+			}
+		}
+
+		// Rolling back a Cache which can't be read would remove the objects it
+		// held before rather than restoring them.
+		for name, target := range c.targets {
+			cacher, ok := target.(handler.Cacher)
+			if !ok {
+				continue
+			}
+			cache := cacher.GetCache()
+			if _, ok := cache.(handler.CacheReader); cache != nil && !ok {
+				return nil, fmt.Errorf("%w: cache of target %q does not implement handler.CacheReader",
+					ErrCreatingClient, name)
+			}
+		}
+	}
+
+	if snap != nil {
+		err := c.restore(context.Background(), snap)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCreatingClient, err)
+		}
+	}
+
 	return c, nil
 }
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"hash/maphash"
+	"iter"
+	"slices"
+)
+
+const (
+	// persistentMapBits is how many bits of a key's hash select the child of a
+	// persistentMapNode at each level.
+	persistentMapBits  = 5
+	persistentMapWidth = 1 << persistentMapBits
+	persistentMapMask  = persistentMapWidth - 1
+
+	// persistentMapLeafSize is how many entries a leaf holds before it is split
+	// into children. Leaves are only split while their keys' hashes have bits
+	// left, so a leaf of colliding keys may be larger.
+	persistentMapLeafSize = 8
+)
+
+var persistentMapSeed = maphash.MakeSeed()
+
+// persistentMapHash returns the hash of key. Methods taking a hash must always
+// be given the same hash for a key, which tests use to force collisions.
+func persistentMapHash(key string) uint64 {
+	return maphash.String(persistentMapSeed, key)
+}
+
+// persistentMap is an immutable map from strings to values of type V. set and
+// delete return a new map which shares all but O(log n) of its nodes with the
+// old one, so copies of large maps are cheap to modify. The zero value is an
+// empty map.
+//
+// Threadsafe, as maps are never modified once created.
+type persistentMap[V any] struct {
+	root *persistentMapNode[V]
+	size int
+}
+
+// persistentMapNode is either an interior node whose children are selected by
+// the next persistentMapBits of their keys' hashes, or a leaf holding entries.
+type persistentMapNode[V any] struct {
+	children *[persistentMapWidth]*persistentMapNode[V]
+	entries  []persistentMapEntry[V]
+}
+
+type persistentMapEntry[V any] struct {
+	hash  uint64
+	key   string
+	value V
+}
+
+// len returns the number of keys in m.
+func (m persistentMap[V]) len() int {
+	return m.size
+}
+
+// get returns the value of key, and whether m contains key.
+func (m persistentMap[V]) get(key string) (V, bool) {
+	return m.getHashed(key, persistentMapHash(key))
+}
+
+// getHashed is get for a key whose hash is hash.
+func (m persistentMap[V]) getHashed(key string, hash uint64) (V, bool) {
+	node := m.root
+	for shift := uint(0); node != nil; shift += persistentMapBits {
+		if node.children == nil {
+			for _, entry := range node.entries {
+				if entry.key == key {
+					return entry.value, true
+				}
+			}
+			break
+		}
+		node = node.children[(hash>>shift)&persistentMapMask]
+	}
+
+	var zero V
+	return zero, false
+}
+
+// set returns a copy of m where key has value.
+func (m persistentMap[V]) set(key string, value V) persistentMap[V] {
+	return m.setHashed(key, persistentMapHash(key), value)
+}
+
+// setHashed is set for a key whose hash is hash.
+func (m persistentMap[V]) setHashed(key string, hash uint64, value V) persistentMap[V] {
+	entry := persistentMapEntry[V]{hash: hash, key: key, value: value}
+
+	root, added := m.root.set(entry, 0)
+	out := persistentMap[V]{root: root, size: m.size}
+	if added {
+		out.size++
+	}
+	return out
+}
+
+// delete returns a copy of m without key. Returns m if it does not contain key.
+func (m persistentMap[V]) delete(key string) persistentMap[V] {
+	return m.deleteHashed(key, persistentMapHash(key))
+}
+
+// deleteHashed is delete for a key whose hash is hash.
+func (m persistentMap[V]) deleteHashed(key string, hash uint64) persistentMap[V] {
+	root, deleted := m.root.delete(key, hash, 0)
+	if !deleted {
+		return m
+	}
+	return persistentMap[V]{root: root, size: m.size - 1}
+}
+
+// all iterates over the keys and values of m in an unspecified order.
+func (m persistentMap[V]) all() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		m.root.all(yield)
+	}
+}
+
+// set returns a copy of n, which is at depth shift, containing entry. Returns
+// true if entry's key was not already present.
+func (n *persistentMapNode[V]) set(entry persistentMapEntry[V], shift uint) (*persistentMapNode[V], bool) {
+	if n == nil {
+		return &persistentMapNode[V]{entries: []persistentMapEntry[V]{entry}}, true
+	}
+
+	if n.children != nil {
+		children := *n.children
+		i := (entry.hash >> shift) & persistentMapMask
+		child, added := children[i].set(entry, shift+persistentMapBits)
+		children[i] = child
+		return &persistentMapNode[V]{children: &children}, added
+	}
+
+	for i := range n.entries {
+		if n.entries[i].key == entry.key {
+			entries := slices.Clone(n.entries)
+			entries[i] = entry
+			return &persistentMapNode[V]{entries: entries}, false
+		}
+	}
+
+	// Clip so appending never writes to the backing array of n's entries.
+	entries := append(slices.Clip(n.entries), entry)
+	if len(entries) <= persistentMapLeafSize || shift >= 64 {
+		return &persistentMapNode[V]{entries: entries}, true
+	}
+
+	var children [persistentMapWidth]*persistentMapNode[V]
+	for _, e := range entries {
+		i := (e.hash >> shift) & persistentMapMask
+		children[i], _ = children[i].set(e, shift+persistentMapBits)
+	}
+	return &persistentMapNode[V]{children: &children}, true
+}
+
+// delete returns a copy of n, which is at depth shift, without key. Returns
+// n and false if n does not contain key, and nil if n would be empty.
+func (n *persistentMapNode[V]) delete(key string, hash uint64, shift uint) (*persistentMapNode[V], bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	if n.children != nil {
+		i := (hash >> shift) & persistentMapMask
+		child, deleted := n.children[i].delete(key, hash, shift+persistentMapBits)
+		if !deleted {
+			return n, false
+		}
+
+		children := *n.children
+		children[i] = child
+		for _, c := range children {
+			if c != nil {
+				return &persistentMapNode[V]{children: &children}, true
+			}
+		}
+		return nil, true
+	}
+
+	for i := range n.entries {
+		if n.entries[i].key != key {
+			continue
+		}
+
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		entries := make([]persistentMapEntry[V], 0, len(n.entries)-1)
+		entries = append(entries, n.entries[:i]...)
+		entries = append(entries, n.entries[i+1:]...)
+		return &persistentMapNode[V]{entries: entries}, true
+	}
+
+	return n, false
+}
+
+// all calls yield for each entry under n until it returns false. Returns false
+// if yield did.
+func (n *persistentMapNode[V]) all(yield func(string, V) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	if n.children != nil {
+		for _, child := range n.children {
+			if !child.all(yield) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, entry := range n.entries {
+		if !yield(entry.key, entry.value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPersistentMap(t *testing.T) {
+	tests := []struct {
+		name string
+		// nKeys is how many distinct keys are set and deleted.
+		nKeys int
+		hash  func(key string) uint64
+	}{
+		{
+			// Enough keys to split leaves several levels deep.
+			name:  "distinct hashes",
+			nKeys: 2000,
+			hash:  persistentMapHash,
+		},
+		{
+			// Keys only differ in the last bits of their hashes to select
+			// children, so leaves are split down to the deepest level.
+			name:  "shared prefixes",
+			nKeys: 500,
+			hash: func(key string) uint64 {
+				return persistentMapHash(key) >> 60 << 60
+			},
+		},
+		{
+			// Every key collides, so leaves grow past persistentMapLeafSize once
+			// the hashes have no bits left.
+			name:  "colliding hashes",
+			nKeys: 100,
+			hash: func(string) uint64 {
+				return 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(0)) //nolint:gosec
+
+			var m persistentMap[int]
+			want := make(map[string]int)
+
+			// versions are earlier maps and their contents, which later operations
+			// must not affect.
+			var versions []persistentMap[int]
+			var wantVersions []map[string]int
+
+			for i := 0; i < 10*tt.nKeys; i++ {
+				key := fmt.Sprintf("key-%d", rng.Intn(tt.nKeys))
+
+				if rng.Intn(3) == 0 {
+					m = m.deleteHashed(key, tt.hash(key))
+					delete(want, key)
+				} else {
+					m = m.setHashed(key, tt.hash(key), i)
+					want[key] = i
+				}
+
+				if i%(tt.nKeys/2) == 0 {
+					versions = append(versions, m)
+					wantVersions = append(wantVersions, maps.Clone(want))
+				}
+			}
+			versions = append(versions, m)
+			wantVersions = append(wantVersions, want)
+
+			for i, version := range versions {
+				got := make(map[string]int)
+				for key, value := range version.all() {
+					got[key] = value
+				}
+
+				if diff := cmp.Diff(wantVersions[i], got); diff != "" {
+					t.Fatalf("version %d: %s", i, diff)
+				}
+				if version.len() != len(wantVersions[i]) {
+					t.Errorf("version %d: got len() %d, want %d", i, version.len(), len(wantVersions[i]))
+				}
+
+				for key := 0; key < tt.nKeys; key++ {
+					key := fmt.Sprintf("key-%d", key)
+					value, found := version.getHashed(key, tt.hash(key))
+					wantValue, wantFound := wantVersions[i][key]
+					if value != wantValue || found != wantFound {
+						t.Fatalf("version %d: got get(%q) = %d, %t, want %d, %t",
+							i, key, value, found, wantValue, wantFound)
+					}
+				}
+			}
+
+			// Deleting every key must leave no empty nodes behind.
+			for key := range want {
+				m = m.deleteHashed(key, tt.hash(key))
+			}
+			if m.len() != 0 || m.root != nil {
+				t.Errorf("got len() %d and root %v after deleting every key, want empty map", m.len(), m.root)
+			}
+		})
+	}
+}
+
+// BenchmarkPersistentMap_Set compares setting a key in a persistentMap with
+// setting it in a clone of a built-in map, which is how Templates were copied
+// before they shared their Constraints.
+func BenchmarkPersistentMap_Set(b *testing.B) {
+	for _, n := range []int{10, 1000, 5000} {
+		var m persistentMap[int]
+		builtin := make(map[string]int, n)
+		for i := 0; i < n; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			m = m.set(key, i)
+			builtin[key] = i
+		}
+
+		b.Run(fmt.Sprintf("persistent/%d-Keys", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = m.set("key-new", i)
+			}
+		})
+
+		b.Run(fmt.Sprintf("clone/%d-Keys", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				next := maps.Clone(builtin)
+				next["key-new"] = i
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	constraintlib "github.com/open-policy-agent/frameworks/constraint/pkg/core/constraints"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// SchemaChangePolicy is what AddTemplate does when an updated Template's
+// schema no longer accepts some of the Template's existing Constraints.
+type SchemaChangePolicy string
+
+const (
+	// IgnoreSchemaChange makes AddTemplate update the Template without checking
+	// its existing Constraints, which are kept as they were, without any new
+	// defaults, and continue to match reviews.
+	IgnoreSchemaChange SchemaChangePolicy = "Ignore"
+
+	// RejectSchemaChange makes AddTemplate return an ErrSchemaChange and leave
+	// the Template unchanged.
+	RejectSchemaChange SchemaChangePolicy = "Reject"
+
+	// QuarantineInvalidConstraints makes AddTemplate update the Template and
+	// quarantine the invalid Constraints. Quarantined Constraints are removed
+	// from their driver and never match reviews, until either AddConstraint
+	// replaces them with valid versions or a later version of the Template
+	// accepts them again.
+	QuarantineInvalidConstraints SchemaChangePolicy = "Quarantine"
+)
+
+// revalidation is the outcome of checking a Template's existing Constraints
+// against the schema of an updated version of the Template.
+type revalidation struct {
+	// keys are the keys of every existing Constraint in sorted order.
+	keys []string
+
+	// invalid maps the key of each Constraint the new schema does not accept
+	// to why.
+	invalid map[string]error
+
+	// defaulted maps the key of each Constraint the new schema accepts to a copy
+	// of the Constraint with the new schema's defaults applied.
+	defaulted map[string]*unstructured.Unstructured
+}
+
+// revalidateConstraints checks the Constraints of cached against crd as
+// AddConstraint would, without modifying cached.
+func revalidateConstraints(cached *templateClient, crd *apiextensions.CustomResourceDefinition, targets []handler.TargetHandler) *revalidation {
+	r := &revalidation{
+		invalid:   make(map[string]error),
+		defaulted: make(map[string]*unstructured.Unstructured),
+	}
+
+	r.keys = cached.constraintKeys()
+
+	validator := &templateClient{crd: crd, targets: targets}
+	for _, key := range r.keys {
+		constraint := cached.constraint(key).getConstraint()
+
+		err := validator.ValidateConstraint(constraint)
+		if err != nil {
+			r.invalid[key] = err
+			continue
+		}
+
+		constraint, err = validator.ApplyDefaultParams(constraint)
+		if err != nil {
+			r.invalid[key] = err
+			continue
+		}
+
+		r.defaulted[key] = constraint
+	}
+
+	return r
+}
+
+// report returns the invalid Constraints of cached, ordered by key.
+func (r *revalidation) report(cached *templateClient) []*types.InvalidConstraint {
+	var report []*types.InvalidConstraint
+	for _, key := range r.keys {
+		if err, found := r.invalid[key]; found {
+			report = append(report, &types.InvalidConstraint{
+				Constraint: cached.constraint(key).getConstraint(),
+				Error:      err.Error(),
+			})
+		}
+	}
+
+	return report
+}
+
+// quarantine marks the invalid Constraints of cached as quarantined, and the
+// rest as not. cached must not be published. Returns the keys of newly
+// quarantined Constraints and of Constraints which are no longer quarantined.
+func (r *revalidation) quarantine(cached *templateClient) (quarantined, released []string) {
+	for _, key := range r.keys {
+		entry := cached.constraint(key)
+
+		reason := ""
+		if err, found := r.invalid[key]; found {
+			reason = err.Error()
+			if entry.quarantined == "" {
+				quarantined = append(quarantined, key)
+			}
+		} else if entry.quarantined != "" {
+			released = append(released, key)
+		}
+
+		if reason != entry.quarantined {
+			// constraintClients may be shared with published states, so they are
+			// replaced rather than modified.
+			cpy := *entry
+			cpy.quarantined = reason
+			cached.constraints = cached.constraints.set(key, &cpy)
+		}
+	}
+
+	return quarantined, released
+}
+
+// applyRevalidation updates driver and cached after cached's Template has
+// been updated: newly quarantined Constraints are removed from driver, and
+// Constraints which are no longer quarantined or gained new defaults are
+// added to driver again. cached must not be published, and callers must hold
+// its Template's lock. Returns the Events for the Constraints which changed, to
+// be published once cached is, including when an error is returned.
+func (c *Client) applyRevalidation(ctx context.Context, driver drivers.Driver, cached *templateClient, r *revalidation, quarantined, released []string) ([]Event, error) {
+	for _, key := range quarantined {
+		err := driver.RemoveConstraint(ctx, cached.constraint(key).constraint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var events []Event
+
+	isReleased := make(map[string]bool, len(released))
+	for _, key := range released {
+		isReleased[key] = true
+	}
+
+	for _, key := range r.keys {
+		constraint, found := r.defaulted[key]
+		if !found {
+			continue
+		}
+
+		changed := !constraintlib.SemanticEqualWithLabelsAndAnnotations(cached.constraint(key).constraint, constraint)
+		if changed {
+			_, err := cached.AddConstraint(constraint, c.enforcementPoints)
+			if err != nil {
+				return events, err
+			}
+		}
+
+		if !changed && !isReleased[key] {
+			continue
+		}
+
+		err := driver.AddConstraint(ctx, constraint)
+		if changed {
+			events = append(events, Event{Type: ConstraintUpdated, Constraint: constraint.DeepCopy()})
+		}
+		if err != nil {
+			return events, err
+		}
+	}
+
+	return events, nil
+}
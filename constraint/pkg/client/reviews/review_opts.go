@@ -1,6 +1,11 @@
 // Package reviews provides options and configuration for review queries.
 package reviews
 
+import (
+	"strings"
+	"time"
+)
+
 // ReviewCfg contains configuration options for a single review query.
 type ReviewCfg struct {
 	TracingEnabled   bool
@@ -10,6 +15,19 @@ type ReviewCfg struct {
 	// For namespaced resources, this contains the full namespace object
 	// including metadata and labels. For cluster-scoped resources, this is nil.
 	Namespace map[string]interface{}
+	// Timeout is the longest the Constraints of each Template may be evaluated
+	// for. Zero means the driver's default.
+	Timeout time.Duration
+	// ExplainMatching requests an explanation of whether each Constraint
+	// applied to the review.
+	ExplainMatching bool
+	// TraceEventsEnabled requests structured trace events in addition to any
+	// trace string.
+	TraceEventsEnabled bool
+	// TraceKinds and TraceNames, if not empty, limit tracing to Constraints
+	// with those kinds and names.
+	TraceKinds []string
+	TraceNames []string
 }
 
 // ReviewOpt specifies optional arguments for Query driver calls.
@@ -23,6 +41,50 @@ func Tracing(enabled bool) ReviewOpt {
 	}
 }
 
+// TraceEvents makes drivers which support it return the steps taken to evaluate
+// each Constraint as structured types.TraceEvents. Unlike the string enabled
+// by Tracing, events record which Constraint each step was for, so drivers may
+// evaluate Constraints one at a time while they are enabled.
+func TraceEvents(enabled bool) ReviewOpt {
+	return func(cfg *ReviewCfg) {
+		cfg.TraceEventsEnabled = enabled
+	}
+}
+
+// TraceKinds limits tracing, whether enabled with Tracing, TraceEvents or for
+// the whole driver, to Constraints of the given kinds. Kinds are compared
+// case-insensitively.
+func TraceKinds(kinds ...string) ReviewOpt {
+	return func(cfg *ReviewCfg) {
+		cfg.TraceKinds = kinds
+	}
+}
+
+// TraceNames limits tracing, whether enabled with Tracing, TraceEvents or for
+// the whole driver, to Constraints with the given names.
+func TraceNames(names ...string) ReviewOpt {
+	return func(cfg *ReviewCfg) {
+		cfg.TraceNames = names
+	}
+}
+
+// TracesConstraint returns true if cfg's TraceKinds and TraceNames allow
+// tracing the Constraint of kind with name. Does not check whether tracing is
+// enabled.
+func (cfg *ReviewCfg) TracesConstraint(kind, name string) bool {
+	kindFound := len(cfg.TraceKinds) == 0
+	for _, k := range cfg.TraceKinds {
+		kindFound = kindFound || strings.EqualFold(k, kind)
+	}
+
+	nameFound := len(cfg.TraceNames) == 0
+	for _, n := range cfg.TraceNames {
+		nameFound = nameFound || n == name
+	}
+
+	return kindFound && nameFound
+}
+
 // Stats enables the driver to return evaluation stats for a single
 // query. If stats is enabled for the Driver at construction time, then
 // Stats(false) does not disable Stats for this single query.
@@ -46,3 +108,22 @@ func Namespace(ns map[string]interface{}) ReviewOpt {
 		cfg.Namespace = ns
 	}
 }
+
+// Timeout limits how long the Constraints of each Template may be evaluated
+// for during a single query, overriding the driver's default. Constraints of
+// Templates which exceed the limit are reported as violated with an error
+// message instead of stopping the rest of the query.
+func Timeout(d time.Duration) ReviewOpt {
+	return func(cfg *ReviewCfg) {
+		cfg.Timeout = d
+	}
+}
+
+// ExplainMatching makes Client explain, in each target's Response, whether
+// each of the target's Constraints applied to the review and why. Matchers
+// implementing constraints.ExplainingMatcher add human-readable reasons.
+func ExplainMatching(enabled bool) ReviewOpt {
+	return func(cfg *ReviewCfg) {
+		cfg.ExplainMatching = enabled
+	}
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/reviews"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// ShadowResult compares the Results of a shadow Template with those of the
+// active Template for a single review.
+type ShadowResult struct {
+	// Template is the name of the Template.
+	Template string
+
+	// Target is the name of the target the object was reviewed for.
+	Target string
+
+	// Review is the target's review of the object, as returned by its
+	// HandleReview.
+	Review interface{}
+
+	// Added are the violations the shadow Template found which the active
+	// Template did not. Results are compared by Constraint and message.
+	Added []*types.Result
+
+	// Removed are the violations the active Template found which the shadow
+	// Template did not.
+	Removed []*types.Result
+
+	// Err is the error encountered evaluating the shadow Template, if any. If
+	// set, Added and Removed are empty.
+	Err error
+}
+
+// AddShadowTemplate compiles templ as a candidate version of the Template of
+// the same name, replacing any existing candidate. While the shadow Template
+// exists, Review also evaluates it for the Template's matched Constraints and
+// reports how its Results differ to the function set with ShadowResults. The
+// shadow Template never affects the Responses returned by Review.
+//
+// templ must have the same targets and scope as the active Template, and must
+// be enforced by the same driver, which must implement drivers.ShadowQuerier.
+// Shadow Templates are discarded if the active Template is removed or moves to
+// a different driver.
+func (c *Client) AddShadowTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*types.Responses, error) {
+	resp := types.NewResponses()
+
+	defer c.templateLocks.lock(templ.GetName())()
+
+	targetNames, err := getTargetNames(templ)
+	if err != nil {
+		return resp, err
+	}
+
+	cached := c.getState().templates[templ.GetName()]
+	if cached == nil {
+		return resp, templateNotFound(templ.GetName())
+	}
+
+	err = checkCompatible(cached, templ)
+	if err != nil {
+		return resp, fmt.Errorf("%w: %w", ErrShadowTemplate, err)
+	}
+
+	err = validateTemplateMetadata(templ)
+	if err != nil {
+		return resp, err
+	}
+
+	targets, err := c.getTargetHandlers(templ)
+	if err != nil {
+		return resp, err
+	}
+
+	// Ensure the shadow Template's CRD is valid so it may be promoted.
+	_, err = createCRD(ctx, templ, targets)
+	if err != nil {
+		return resp, err
+	}
+
+	driverN := c.driverForTemplate(cached.template)
+	if shadowDriverN := c.driverForTemplate(templ); shadowDriverN != driverN {
+		return resp, fmt.Errorf("%w: template %q is enforced by driver %q, but the shadow template would be enforced by driver %q",
+			ErrShadowTemplate, templ.GetName(), driverN, shadowDriverN)
+	}
+
+	shadower, ok := c.drivers[driverN].(drivers.ShadowQuerier)
+	if !ok {
+		return resp, fmt.Errorf("%w: driver %q does not support shadow templates",
+			ErrShadowTemplate, driverN)
+	}
+
+	err = shadower.AddShadowTemplate(ctx, templ)
+	if err != nil {
+		return resp, err
+	}
+
+	cpy := templ.DeepCopy()
+	cpy.Status = templates.ConstraintTemplateStatus{}
+	next := cached.clone()
+	next.shadow = cpy
+	c.setTemplate(templ.GetName(), next)
+
+	for _, targetName := range targetNames {
+		resp.Handled[targetName] = true
+	}
+	return resp, nil
+}
+
+// PromoteShadowTemplate replaces the active version of templ's Template with
+// its shadow version, as AddTemplate would, and then discards the shadow
+// Template. On error, the shadow Template is kept.
+func (c *Client) PromoteShadowTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*types.Responses, error) {
+	name := templ.GetName()
+
+	defer c.templateLocks.lock(name)()
+	defer c.invalidateDecisions()
+
+	cached := c.getState().templates[name]
+	if cached == nil || cached.shadow == nil {
+		return types.NewResponses(), fmt.Errorf("%w: template %q has no shadow version",
+			ErrMissingShadowTemplate, name)
+	}
+
+	resp, err := c.addTemplate(ctx, cached.shadow)
+	if err != nil {
+		return resp, err
+	}
+
+	// addTemplate discards the shadow Template if the Template moved to a
+	// different driver.
+	next := c.getState().templates[name].clone()
+	if next.shadow == nil {
+		return resp, nil
+	}
+
+	err = discardShadow(ctx, c.drivers[c.driverForTemplate(next.template)], next)
+	c.setTemplate(name, next)
+	return resp, err
+}
+
+// DiscardShadowTemplate removes the shadow version of templ's Template, if
+// any. The active version is unaffected.
+func (c *Client) DiscardShadowTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*types.Responses, error) {
+	resp := types.NewResponses()
+
+	defer c.templateLocks.lock(templ.GetName())()
+
+	cached := c.getState().templates[templ.GetName()]
+	if cached == nil || cached.shadow == nil {
+		return resp, nil
+	}
+
+	next := cached.clone()
+	err := discardShadow(ctx, c.drivers[c.driverForTemplate(next.template)], next)
+	if err != nil {
+		return resp, err
+	}
+	c.setTemplate(templ.GetName(), next)
+
+	for _, target := range cached.targets {
+		resp.Handled[target.GetName()] = true
+	}
+	return resp, nil
+}
+
+// discardShadow removes the shadow Template of cached from driver. cached must
+// not be published.
+func discardShadow(ctx context.Context, driver drivers.Driver, cached *templateClient) error {
+	if shadower, ok := driver.(drivers.ShadowQuerier); ok {
+		err := shadower.RemoveShadowTemplate(ctx, cached.shadow)
+		if err != nil {
+			return err
+		}
+	}
+
+	cached.shadow = nil
+	return nil
+}
+
+// reviewShadows evaluates the shadow Templates of the Constraints matched for
+// each target, and reports how their Results differ from those in outcomes.
+// outcomes are parallel to targetNames.
+func (c *Client) reviewShadows(ctx context.Context, s *state, targetNames []string, targetReviews map[string]interface{}, matches map[string]*targetMatches, outcomes []reviewOutcome, opts ...reviews.ReviewOpt) {
+	if c.shadowResults == nil {
+		return
+	}
+
+	for i, target := range targetNames {
+		if outcomes[i].err != nil {
+			continue
+		}
+
+		byTemplate := make(map[string][]*unstructured.Unstructured)
+		for _, constraint := range matches[target].constraints {
+			name := strings.ToLower(constraint.GetKind())
+			if cached := s.templates[name]; cached != nil && cached.shadow != nil {
+				byTemplate[name] = append(byTemplate[name], constraint)
+			}
+		}
+
+		names := make([]string, 0, len(byTemplate))
+		for name := range byTemplate {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			result := c.reviewShadow(ctx, s, target, name, byTemplate[name], targetReviews[target], matches[target], outcomes[i].resp.Results, opts...)
+			c.shadowResults(result)
+		}
+	}
+}
+
+// reviewShadow evaluates constraints with the shadow version of the named
+// Template, and compares the Results with the active Template's Results in
+// active.
+func (c *Client) reviewShadow(ctx context.Context, s *state, target, name string, constraints []*unstructured.Unstructured, review interface{}, matches *targetMatches, active []*types.Result, opts ...reviews.ReviewOpt) ShadowResult {
+	result := ShadowResult{Template: name, Target: target, Review: review}
+
+	cached := s.templates[name]
+	driverN := c.driverForTemplate(cached.template)
+	// AddShadowTemplate ensures the driver is a ShadowQuerier.
+	shadower, ok := c.drivers[driverN].(drivers.ShadowQuerier)
+	if !ok {
+		result.Err = fmt.Errorf("%w: driver %q does not support shadow templates",
+			ErrShadowTemplate, driverN)
+		return result
+	}
+
+	qr, err := shadower.QueryShadow(ctx, target, constraints, review, opts...)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	var shadowResults []*types.Result
+	if qr != nil {
+		shadowResults = qr.Results
+	}
+	c.fillEnforcementActions(shadowResults, matches)
+
+	var activeResults []*types.Result
+	for _, r := range active {
+		if strings.ToLower(r.Constraint.GetKind()) == name {
+			activeResults = append(activeResults, r)
+		}
+	}
+
+	result.Added, result.Removed = c.diffResults(activeResults, shadowResults)
+	return result
+}
+
+// diffResults returns the Results in shadow which are not in active, and the
+// copies of Results in active which are not in shadow. Results are equal if
+// they are for the same Constraint and have the same message.
+func (c *Client) diffResults(active, shadow []*types.Result) (added, removed []*types.Result) {
+	key := func(r *types.Result) string {
+		return c.actionKey(r.Constraint) + "\n" + r.Msg
+	}
+
+	unmatched := make(map[string]int)
+	for _, r := range active {
+		unmatched[key(r)]++
+	}
+
+	for _, r := range shadow {
+		k := key(r)
+		if unmatched[k] > 0 {
+			unmatched[k]--
+			continue
+		}
+		added = append(added, r)
+	}
+
+	for _, r := range active {
+		k := key(r)
+		if unmatched[k] > 0 {
+			unmatched[k]--
+			removed = append(removed, r.DeepCopy())
+		}
+	}
+
+	return added, removed
+}
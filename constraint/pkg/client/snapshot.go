@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	regoSchema "github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/rego/schema"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+)
+
+// snapshotKind and snapshotVersion identify the format written by Snapshot.
+// snapshotVersion must be incremented whenever the format changes in a way
+// older versions of Client cannot read.
+const (
+	snapshotKind    = "ConstraintClientSnapshot"
+	snapshotVersion = 1
+)
+
+// snapshot is the serialized state of a Client.
+type snapshot struct {
+	Kind    string `json:"kind"`
+	Version int    `json:"version"`
+
+	EnforcementPoints []string `json:"enforcementPoints,omitempty"`
+
+	Templates []*templates.ConstraintTemplate `json:"templates,omitempty"`
+
+	// Constraints are stored with default parameters applied.
+	Constraints []*unstructured.Unstructured `json:"constraints,omitempty"`
+
+	Exemptions []*Exemption `json:"exemptions,omitempty"`
+
+	// Inventory is a map from target name to the data cached for referential
+	// Constraints by the "Rego" driver for that target.
+	Inventory map[string]json.RawMessage `json:"inventory,omitempty"`
+}
+
+// Snapshot writes the Templates, Constraints, Exemptions, enforcement points,
+// and data cached for referential Constraints to w. A Client created with
+// RestoreFrom and the same Targets and Drivers returns the same results from
+// Review. Shadow Templates and quarantined Constraints are not included.
+//
+// Templates, Constraints, and Exemptions are captured together, but cached data
+// is read afterward as AddData does not lock Client. Callers which need an
+// exact point-in-time snapshot should not add or remove data while Snapshot
+// runs.
+func (c *Client) Snapshot(ctx context.Context, w io.Writer) error {
+	snap := snapshot{
+		Kind:    snapshotKind,
+		Version: snapshotVersion,
+	}
+
+	s := c.getState()
+	snap.EnforcementPoints = append([]string(nil), c.enforcementPoints...)
+
+	for _, name := range s.templateNames() {
+		cached := s.templates[name]
+		snap.Templates = append(snap.Templates, cached.getTemplate())
+
+		for _, constraintName := range cached.constraintKeys() {
+			// Restoring quarantined Constraints would fail validation.
+			if cached.constraint(constraintName).quarantined != "" {
+				continue
+			}
+			snap.Constraints = append(snap.Constraints, cached.constraint(constraintName).getConstraint())
+		}
+	}
+
+	for _, name := range s.exemptionNames {
+		snap.Exemptions = append(snap.Exemptions, s.exemptions[name].exemption.DeepCopy())
+	}
+
+	if d, ok := c.drivers[regoSchema.Name]; ok {
+		reader, ok := d.(drivers.DataReader)
+		if !ok {
+			return fmt.Errorf("%w: driver %q does not support reading cached data",
+				ErrSnapshot, d.Name())
+		}
+
+		snap.Inventory = make(map[string]json.RawMessage)
+		for _, target := range c.knownTargets() {
+			data, err := reader.ReadData(ctx, target, nil)
+			if err != nil {
+				return fmt.Errorf("%w: reading data for target %q: %w", ErrSnapshot, target, err)
+			}
+			if data == nil {
+				continue
+			}
+
+			raw, err := json.Marshal(data)
+			if err != nil {
+				return fmt.Errorf("%w: encoding data for target %q: %w", ErrSnapshot, target, err)
+			}
+			snap.Inventory[target] = raw
+		}
+	}
+
+	err := json.NewEncoder(w).Encode(snap)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	return nil
+}
+
+// readSnapshot decodes a snapshot written by Snapshot from r.
+func readSnapshot(r io.Reader) (*snapshot, error) {
+	snap := &snapshot{}
+	err := json.NewDecoder(r).Decode(snap)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+
+	if snap.Kind != snapshotKind {
+		return nil, fmt.Errorf("%w: got kind %q, want %q",
+			ErrInvalidSnapshot, snap.Kind, snapshotKind)
+	}
+
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d, want %d",
+			ErrInvalidSnapshot, snap.Version, snapshotVersion)
+	}
+
+	return snap, nil
+}
+
+// restore adds the Templates, Constraints, Exemptions, and cached data in snap
+// to Client. Each is added as if it were new, so Templates are compiled again.
+// Caches of targets which implement handler.CacheRestorer are rebuilt from the
+// restored data.
+func (c *Client) restore(ctx context.Context, snap *snapshot) error {
+	for _, templ := range snap.Templates {
+		_, err := c.AddTemplate(ctx, templ)
+		if err != nil {
+			return fmt.Errorf("%w: adding template %q: %w", ErrInvalidSnapshot, templ.GetName(), err)
+		}
+	}
+
+	for _, constraint := range snap.Constraints {
+		_, err := c.AddConstraint(ctx, constraint)
+		if err != nil {
+			return fmt.Errorf("%w: adding constraint %s %q: %w",
+				ErrInvalidSnapshot, constraint.GetKind(), constraint.GetName(), err)
+		}
+	}
+
+	for _, exemption := range snap.Exemptions {
+		_, err := c.AddExemption(exemption)
+		if err != nil {
+			return fmt.Errorf("%w: adding exemption %q: %w", ErrInvalidSnapshot, exemption.Name, err)
+		}
+	}
+
+	targetNames := make([]string, 0, len(snap.Inventory))
+	for name := range snap.Inventory {
+		targetNames = append(targetNames, name)
+	}
+	sort.Strings(targetNames)
+
+	for _, name := range targetNames {
+		target, ok := c.targets[name]
+		if !ok {
+			return fmt.Errorf("%w: data for unknown target %q", ErrInvalidSnapshot, name)
+		}
+
+		// Decode with OPA's JSON decoder to preserve numeric precision, as
+		// AddData does.
+		var data interface{}
+		err := util.UnmarshalJSON(snap.Inventory[name], &data)
+		if err != nil {
+			return fmt.Errorf("%w: decoding data for target %q: %w", ErrInvalidSnapshot, name, err)
+		}
+
+		if d, ok := c.drivers[regoSchema.Name]; ok {
+			err = d.AddData(ctx, name, nil, data)
+			if err != nil {
+				return fmt.Errorf("%w: adding data for target %q: %w", ErrInvalidSnapshot, name, err)
+			}
+		} else if !c.ignoreNoReferentialDriverWarning {
+			return fmt.Errorf("%w: data for target %q: %w", ErrInvalidSnapshot, name, ErrNoReferentialDriver)
+		}
+
+		cacher, ok := target.(handler.Cacher)
+		if !ok {
+			continue
+		}
+
+		if restorer, ok := cacher.GetCache().(handler.CacheRestorer); ok {
+			err = restorer.Restore(data)
+			if err != nil {
+				return fmt.Errorf("%w: restoring cache for target %q: %w", ErrInvalidSnapshot, name, err)
+			}
+		}
+	}
+
+	return nil
+}
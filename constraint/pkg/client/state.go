@@ -0,0 +1,119 @@
+package client
+
+import (
+	"sync"
+)
+
+// state is the Templates, Constraints, and Exemptions known to Client at one
+// point in time. Once published, a state and the templateClients and
+// exemptionClients it refers to are never modified, so Review reads them
+// without locking. Mutations copy whatever they change and publish a new
+// state.
+type state struct {
+	// templates is a map from a Template's name to its entry.
+	templates map[string]*templateClient
+
+	// exemptions is a map from each Exemption's name to its entry.
+	exemptions map[string]*exemptionClient
+
+	// exemptionNames are the keys of exemptions in sorted order, so the first
+	// Exemption which applies to a review is always reported.
+	exemptionNames []string
+}
+
+func newState() *state {
+	return &state{
+		templates:  make(map[string]*templateClient),
+		exemptions: make(map[string]*exemptionClient),
+	}
+}
+
+// clone returns a shallow copy of s whose maps and slices may be modified
+// without affecting s.
+func (s *state) clone() *state {
+	out := &state{
+		templates:      make(map[string]*templateClient, len(s.templates)),
+		exemptions:     make(map[string]*exemptionClient, len(s.exemptions)),
+		exemptionNames: append([]string(nil), s.exemptionNames...),
+	}
+	for name, template := range s.templates {
+		out.templates[name] = template
+	}
+	for name, exemption := range s.exemptions {
+		out.exemptions[name] = exemption
+	}
+
+	return out
+}
+
+// getState returns the most recently published state.
+func (c *Client) getState() *state {
+	return c.state.Load()
+}
+
+// update publishes the state produced by applying fn to a copy of the current
+// state. Updates are serialized, so concurrent updates of different Templates
+// never lose each other's changes. fn must not block.
+func (c *Client) update(fn func(s *state)) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	next := c.state.Load().clone()
+	fn(next)
+	c.state.Store(next)
+}
+
+// setTemplate publishes entry as the Template named name.
+func (c *Client) setTemplate(name string, entry *templateClient) {
+	c.update(func(s *state) {
+		s.templates[name] = entry
+	})
+}
+
+// templateLocks serializes mutations of each Template and its Constraints, so
+// mutations of different Templates proceed concurrently. Threadsafe.
+type templateLocks struct {
+	mtx sync.Mutex
+
+	// locks maps each Template's name to its lock. Locks are removed once no
+	// goroutine holds or is waiting on them, so removed Templates don't leave
+	// their locks behind.
+	locks map[string]*templateLock
+}
+
+// templateLock is the lock of a single Template.
+type templateLock struct {
+	mtx sync.Mutex
+
+	// refs is how many goroutines hold or are waiting on mtx. Guarded by
+	// templateLocks.mtx.
+	refs int
+}
+
+// lock acquires the lock for the Template named name, and returns the function
+// which releases it.
+func (l *templateLocks) lock(name string) func() {
+	l.mtx.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*templateLock)
+	}
+	templateMtx, found := l.locks[name]
+	if !found {
+		templateMtx = &templateLock{}
+		l.locks[name] = templateMtx
+	}
+	templateMtx.refs++
+	l.mtx.Unlock()
+
+	templateMtx.mtx.Lock()
+	return func() {
+		templateMtx.mtx.Unlock()
+
+		l.mtx.Lock()
+		templateMtx.refs--
+		if templateMtx.refs == 0 {
+			delete(l.locks, name)
+		}
+		l.mtx.Unlock()
+	}
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	apiconstraints "github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/crds"
@@ -9,6 +11,7 @@ import (
 	constraintlib "github.com/open-policy-agent/frameworks/constraint/pkg/core/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
 	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
@@ -25,8 +28,10 @@ type templateClient struct {
 	// template is a copy of the original ConstraintTemplate added to Client.
 	template *templates.ConstraintTemplate
 
-	// constraints are all currently-known Constraints for this Template.
-	constraints map[string]*constraintClient
+	// constraints are all currently-known Constraints for this Template, keyed
+	// by constraintKey. Shared with clones, so adding or removing a Constraint
+	// doesn't copy every other Constraint.
+	constraints persistentMap[*constraintClient]
 
 	// crd is a cache of the generated CustomResourceDefinition generated from
 	// this Template. This is used to validate incoming Constraints before adding
@@ -42,12 +47,20 @@ type templateClient struct {
 	// activeDrivers keeps track of drivers that are in an ambiguous state due to a failed
 	// cross-driver update. This allows us to clean up stale state on old drivers.
 	activeDrivers map[string]bool
+
+	// indexes are the indexes of constraints for each target whose handler is a
+	// handler.IndexableMatcher.
+	indexes map[string]*constraintIndex
+
+	// shadow is a copy of the candidate version of the Template added with
+	// AddShadowTemplate, if any.
+	shadow *templates.ConstraintTemplate
 }
 
 func newTemplateClient() *templateClient {
 	return &templateClient{
-		constraints:   make(map[string]*constraintClient),
 		activeDrivers: make(map[string]bool),
+		indexes:       make(map[string]*constraintIndex),
 	}
 }
 
@@ -75,10 +88,57 @@ func (e *templateClient) ApplyDefaultParams(constraint *unstructured.Unstructure
 	return constraint, nil
 }
 
+// clone returns a copy of e which may be modified without affecting e. The
+// Template, CRD, and constraintClients are shared, as they are replaced rather
+// than modified. Constraints and their indexes are persistent, so cloning
+// takes time independent of the number of Constraints.
+func (e *templateClient) clone() *templateClient {
+	out := *e
+
+	out.activeDrivers = make(map[string]bool, len(e.activeDrivers))
+	for driver, active := range e.activeDrivers {
+		out.activeDrivers[driver] = active
+	}
+
+	out.indexes = make(map[string]*constraintIndex, len(e.indexes))
+	for target, idx := range e.indexes {
+		out.indexes[target] = idx.clone()
+	}
+
+	return &out
+}
+
+// constraint returns the entry of the Constraint with key, or nil if there is
+// none.
+func (e *templateClient) constraint(key string) *constraintClient {
+	constraint, _ := e.constraints.get(key)
+	return constraint
+}
+
+// constraintKeys returns the keys of the Template's Constraints in sorted
+// order.
+func (e *templateClient) constraintKeys() []string {
+	keys := make([]string, 0, e.constraints.len())
+	for key := range e.constraints.all() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (e *templateClient) getTemplate() *templates.ConstraintTemplate {
 	return e.template.DeepCopy()
 }
 
+// targetNames returns the names of the targets the Template applies to.
+func (e *templateClient) targetNames() []string {
+	names := make([]string, len(e.targets))
+	for i, target := range e.targets {
+		names[i] = target.GetName()
+	}
+	return names
+}
+
 func (e *templateClient) Update(templ *templates.ConstraintTemplate, crd *apiextensions.CustomResourceDefinition, targets ...handler.TargetHandler) {
 	cpy := templ.DeepCopy()
 	cpy.Status = templates.ConstraintTemplateStatus{}
@@ -113,9 +173,15 @@ func (e *templateClient) AddConstraint(constraint *unstructured.Unstructured, en
 		}
 	}
 
+	activeFrom, activeUntil, err := apiconstraints.GetActiveWindow(constraint)
+	if err != nil {
+		return false, err
+	}
+
 	// Compare with the already-existing Constraint.
 	// If identical, exit early.
-	cached, found := e.constraints[constraint.GetName()]
+	key := constraintKey(constraint)
+	cached, found := e.constraints.get(key)
 	if found && constraintlib.SemanticEqualWithLabelsAndAnnotations(cached.constraint, constraint) {
 		return false, nil
 	}
@@ -125,51 +191,122 @@ func (e *templateClient) AddConstraint(constraint *unstructured.Unstructured, en
 		return false, err
 	}
 
+	indexKeys, err := makeIndexKeys(e.targets, constraint)
+	if err != nil {
+		return false, err
+	}
+
 	cpy := constraint.DeepCopy()
 	delete(cpy.Object, statusField)
 
-	e.constraints[constraint.GetName()] = &constraintClient{
+	e.constraints = e.constraints.set(key, &constraintClient{
 		constraint:              cpy,
 		matchers:                matchers,
 		enforcementAction:       enforcementAction,
 		enforcementActionsForEP: enforcementActionsForEPs,
+		activeFrom:              activeFrom,
+		activeUntil:             activeUntil,
+	})
+
+	for target, keys := range indexKeys {
+		idx, found := e.indexes[target]
+		if !found {
+			idx = newConstraintIndex()
+			e.indexes[target] = idx
+		}
+		idx.add(key, keys)
 	}
 
 	return true, nil
 }
 
-// GetConstraint returns the Constraint with name for this Template.
-func (e *templateClient) GetConstraint(name string) (*unstructured.Unstructured, error) {
-	constraint, found := e.constraints[name]
+// GetConstraint returns the Constraint with key for this Template.
+func (e *templateClient) GetConstraint(key string) (*unstructured.Unstructured, error) {
+	constraint, found := e.constraints.get(key)
 	if !found {
 		kind := e.template.Spec.CRD.Spec.Names.Kind
-		return nil, fmt.Errorf("%w: %q %q", ErrMissingConstraint, kind, name)
+		return nil, fmt.Errorf("%w: %q %q", ErrMissingConstraint, kind, key)
 	}
 
 	return constraint.getConstraint(), nil
 }
 
-func (e *templateClient) RemoveConstraint(name string) {
-	delete(e.constraints, name)
+func (e *templateClient) RemoveConstraint(key string) {
+	e.constraints = e.constraints.delete(key)
+
+	for _, idx := range e.indexes {
+		idx.remove(key)
+	}
 }
 
-// Matches returns a map from Constraint names to the results of running Matchers
-// against the passed review.
+// Matches returns a map from Constraint keys to the results of running Matchers
+// against the passed review at now.
 //
-// ignoredTargets specifies the targets whose matchers to not run.
-func (e *templateClient) Matches(target string, review interface{}, enforcementPoints []string) map[string]constraintMatchResult {
+// If indexed is true, only runs the Matchers of Constraints which may match a
+// review with reviewKeys, if target's handler is a handler.IndexableMatcher.
+// Otherwise, runs every Constraint's Matcher.
+func (e *templateClient) Matches(target string, review interface{}, now time.Time, reviewKeys []string, indexed bool, enforcementPoints []string) map[string]constraintMatchResult {
 	result := make(map[string]constraintMatchResult)
 
-	for name, constraint := range e.constraints {
-		cResult := constraint.matches(target, review, enforcementPoints...)
+	match := func(name string) {
+		cResult, _ := e.constraint(name).matches(target, review, now, false, enforcementPoints...)
 		if cResult != nil {
 			result[name] = *cResult
 		}
 	}
 
+	idx, found := e.indexes[target]
+	if indexed && found {
+		idx.candidates(reviewKeys, match)
+		return result
+	}
+
+	for name := range e.constraints.all() {
+		match(name)
+	}
+
 	return result
 }
 
+// ExplainMatches is Matches, but runs every Constraint's Matcher and also
+// explains the decision made for each Constraint with a Matcher for target.
+func (e *templateClient) ExplainMatches(target string, review interface{}, now time.Time, enforcementPoints []string) (map[string]constraintMatchResult, []*types.MatchExplanation) {
+	result := make(map[string]constraintMatchResult)
+	var explanations []*types.MatchExplanation
+
+	for name, constraint := range e.constraints.all() {
+		cResult, explanation := constraint.matches(target, review, now, true, enforcementPoints...)
+		if cResult != nil {
+			result[name] = *cResult
+		}
+		if explanation != nil {
+			explanations = append(explanations, explanation)
+		}
+	}
+
+	return result, explanations
+}
+
+// SkipOperation explains that every Constraint with a Matcher for target was
+// skipped because the Template does not apply to operation.
+func (e *templateClient) SkipOperation(target, operation string) []*types.MatchExplanation {
+	var explanations []*types.MatchExplanation
+	for _, constraint := range e.constraints.all() {
+		if _, found := constraint.matchers[target]; !found {
+			continue
+		}
+
+		explanations = append(explanations, &types.MatchExplanation{
+			Constraint: constraint.constraint,
+			Decision:   types.MatchSkippedOperation,
+			Reasons: []string{fmt.Sprintf("template %q does not apply to %s operations",
+				e.template.GetName(), operation)},
+		})
+	}
+
+	return explanations
+}
+
 func makeMatchers(targets []handler.TargetHandler, constraint *unstructured.Unstructured) (map[string]constraintlib.Matcher, error) {
 	result := make(map[string]constraintlib.Matcher)
 	errs := clienterrors.ErrorMap{}
@@ -181,6 +318,23 @@ func makeMatchers(targets []handler.TargetHandler, constraint *unstructured.Unst
 			errs.Add(name, fmt.Errorf("%w: %v", apiconstraints.ErrInvalidConstraint, err))
 		}
 
+		if namespace := constraint.GetNamespace(); namespace != "" {
+			// Client refuses namespaced Templates for targets which are not
+			// ReviewNamespacers, so this should never fail.
+			namespacer, ok := target.(handler.ReviewNamespacer)
+			if !ok {
+				errs.Add(name, fmt.Errorf("%w: target does not support namespaced constraints",
+					apiconstraints.ErrInvalidConstraint))
+				continue
+			}
+
+			matcher = &namespacedMatcher{
+				namespace:  namespace,
+				namespacer: namespacer,
+				matcher:    matcher,
+			}
+		}
+
 		result[name] = matcher
 	}
 
@@ -191,6 +345,91 @@ func makeMatchers(targets []handler.TargetHandler, constraint *unstructured.Unst
 	return result, nil
 }
 
+// makeIndexKeys returns the index keys of constraint for each target whose
+// handler is a handler.IndexableMatcher.
+func makeIndexKeys(targets []handler.TargetHandler, constraint *unstructured.Unstructured) (map[string][]string, error) {
+	result := make(map[string][]string)
+	errs := clienterrors.ErrorMap{}
+
+	for _, target := range targets {
+		indexer, ok := target.(handler.IndexableMatcher)
+		if !ok {
+			continue
+		}
+
+		name := target.GetName()
+		keys, err := indexer.ConstraintIndexKeys(constraint)
+		if err != nil {
+			errs.Add(name, fmt.Errorf("%w: %v", apiconstraints.ErrInvalidConstraint, err))
+		}
+
+		result[name] = keys
+	}
+
+	if len(errs) > 0 {
+		return nil, &errs
+	}
+
+	return result, nil
+}
+
+// namespacedMatcher limits a namespaced Constraint to objects in its own
+// namespace. The target's Matcher only runs for objects in that namespace.
+type namespacedMatcher struct {
+	namespace  string
+	namespacer handler.ReviewNamespacer
+	matcher    constraintlib.Matcher
+}
+
+func (m *namespacedMatcher) Match(review interface{}) (bool, error) {
+	namespace, err := m.namespacer.ReviewNamespace(review)
+	if err != nil {
+		return false, err
+	}
+
+	if namespace != m.namespace {
+		return false, nil
+	}
+
+	return m.matcher.Match(review)
+}
+
+func (m *namespacedMatcher) ExplainMatch(review interface{}) (bool, []string, error) {
+	namespace, err := m.namespacer.ReviewNamespace(review)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if namespace != m.namespace {
+		return false, []string{fmt.Sprintf("object is in namespace %q, but the constraint only applies to namespace %q",
+			namespace, m.namespace)}, nil
+	}
+
+	return explainMatch(m.matcher, review)
+}
+
+var _ constraintlib.ExplainingMatcher = &namespacedMatcher{}
+
+// explainMatch runs matcher against review, returning reasons for its decision
+// if matcher is a constraints.ExplainingMatcher.
+func explainMatch(matcher constraintlib.Matcher, review interface{}) (bool, []string, error) {
+	if explainer, ok := matcher.(constraintlib.ExplainingMatcher); ok {
+		return explainer.ExplainMatch(review)
+	}
+
+	matches, err := matcher.Match(review)
+	return matches, nil, err
+}
+
+// constraintKey returns the key of constraint among the Constraints of its
+// Template: its name, prefixed with its namespace if it is namespaced.
+func constraintKey(constraint *unstructured.Unstructured) string {
+	if namespace := constraint.GetNamespace(); namespace != "" {
+		return namespace + "/" + constraint.GetName()
+	}
+	return constraint.GetName()
+}
+
 // MatchesOperation checks if the given operation type matches any of the template's target operations.
 func (e *templateClient) MatchesOperation(operation string) bool {
 	if len(e.template.Spec.Targets) != 1 {
@@ -198,8 +437,32 @@ func (e *templateClient) MatchesOperation(operation string) bool {
 		return true
 	}
 
-	target := e.template.Spec.Targets[0]
+	return e.MatchesTargetOperation(e.template.Spec.Targets[0].Target, operation)
+}
+
+// MatchesTargetOperation checks if the given operation type matches the operations
+// of the template's entry for target. If the template has no entry for target,
+// checks whether the operation matches the operations of any of its targets, as
+// the admission request is shared by every target's review.
+func (e *templateClient) MatchesTargetOperation(target, operation string) bool {
+	for _, t := range e.template.Spec.Targets {
+		if t.Target == target {
+			return matchesOperation(t, operation)
+		}
+	}
+
+	for _, t := range e.template.Spec.Targets {
+		if matchesOperation(t, operation) {
+			return true
+		}
+	}
+
+	return false
+}
 
+// matchesOperation checks if the given operation type matches the operations of
+// target.
+func matchesOperation(target templates.Target, operation string) bool {
 	// If no operations are specified, match all operations by default to maintain backward compatibility.
 	if len(target.Operations) == 0 {
 		return true
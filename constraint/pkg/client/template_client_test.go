@@ -167,6 +167,66 @@ func TestTemplateClient_MatchesOperation(t *testing.T) {
 	}
 }
 
+func TestTemplateClient_MatchesTargetOperation(t *testing.T) {
+	tc := &templateClient{
+		template: &templates.ConstraintTemplate{
+			Spec: templates.ConstraintTemplateSpec{
+				Targets: []templates.Target{
+					{
+						Target:     "admission.k8s.gatekeeper.sh",
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+					},
+					{
+						Target: "config.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		target    string
+		operation string
+		expected  bool
+	}{
+		{
+			name:      "listed operation for restricted target",
+			target:    "admission.k8s.gatekeeper.sh",
+			operation: "CREATE",
+			expected:  true,
+		},
+		{
+			name:      "unlisted operation for restricted target",
+			target:    "admission.k8s.gatekeeper.sh",
+			operation: "UPDATE",
+			expected:  false,
+		},
+		{
+			name:      "any operation for unrestricted target",
+			target:    "config.example.com",
+			operation: "UPDATE",
+			expected:  true,
+		},
+		{
+			name:      "target not in template",
+			target:    "other",
+			operation: "UPDATE",
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tc.MatchesTargetOperation(tt.target, tt.operation)
+			if result != tt.expected {
+				t.Errorf("MatchesTargetOperation(%q, %q) = %v, expected %v",
+					tt.target, tt.operation, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestTemplateClient_MatchesOperation_EdgeCases(t *testing.T) {
 	t.Run("nil template", func(t *testing.T) {
 		tc := &templateClient{
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	clienterrors "github.com/open-policy-agent/frameworks/constraint/pkg/client/errors"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+)
+
+// TemplateReport describes what would happen if a ConstraintTemplate were
+// added with AddTemplate.
+type TemplateReport struct {
+	// CRD is the CRD which would be created for the Template's Constraints.
+	CRD *apiextensions.CustomResourceDefinition
+
+	// Driver is the name of the Driver which would enforce the Template.
+	Driver string
+
+	// CompileErrors are the problems the Driver found in the Template's code.
+	// Always empty if the Driver does not implement drivers.TemplateValidator.
+	CompileErrors []drivers.CompileError
+
+	// InvalidConstraints are the Template's existing Constraints which do not
+	// conform to the new CRD's schema, in the order of their keys.
+	InvalidConstraints []InvalidConstraint
+}
+
+// InvalidConstraint is a Constraint which does not conform to the schema of
+// its Template's CRD.
+type InvalidConstraint struct {
+	Constraint *unstructured.Unstructured
+	Err        error
+}
+
+// ValidateTemplate checks templ as AddTemplate would, without modifying
+// Client or its Drivers. Returns an error if AddTemplate would fail.
+// On error, the report return value will still be populated with whatever
+// was determined before the error so that partial results can be analyzed.
+func (c *Client) ValidateTemplate(ctx context.Context, templ *templates.ConstraintTemplate) (*TemplateReport, error) {
+	report := &TemplateReport{}
+
+	if templ == nil {
+		return report, fmt.Errorf("%w: got nil ConstraintTemplate",
+			clienterrors.ErrInvalidConstraintTemplate)
+	}
+
+	_, err := getTargetNames(templ)
+	if err != nil {
+		return report, err
+	}
+
+	cached := c.getState().templates[templ.GetName()]
+
+	err = checkTemplateChange(cached, templ)
+	if err != nil {
+		return report, err
+	}
+
+	err = validateTemplateMetadata(templ)
+	if err != nil {
+		return report, err
+	}
+
+	targets, err := c.getTargetHandlers(templ)
+	if err != nil {
+		return report, err
+	}
+
+	report.CRD, err = createCRD(ctx, templ, targets)
+	if err != nil {
+		return report, err
+	}
+
+	if cached != nil {
+		// Check existing Constraints against the new CRD as AddConstraint would.
+		validator := &templateClient{crd: report.CRD, targets: targets}
+
+		for _, key := range cached.constraintKeys() {
+			constraint := cached.constraint(key).getConstraint()
+			err = validator.ValidateConstraint(constraint)
+			if err != nil {
+				report.InvalidConstraints = append(report.InvalidConstraints, InvalidConstraint{
+					Constraint: constraint,
+					Err:        err,
+				})
+			}
+		}
+	}
+
+	report.Driver = c.driverForTemplate(templ)
+	driver, ok := c.drivers[report.Driver]
+	if !ok {
+		return report, fmt.Errorf("%w: available drivers: %v, wanted %q",
+			clienterrors.ErrNoDriver, c.driverPriority, report.Driver)
+	}
+
+	templateValidator, ok := driver.(drivers.TemplateValidator)
+	if !ok {
+		return report, nil
+	}
+
+	report.CompileErrors, err = templateValidator.ValidateTemplate(ctx, templ)
+	if err != nil {
+		return report, err
+	}
+
+	if len(report.CompileErrors) != 0 {
+		errs := make([]error, len(report.CompileErrors))
+		for i, compileErr := range report.CompileErrors {
+			errs[i] = compileErr
+		}
+		return report, fmt.Errorf("%w: %w", clienterrors.ErrInvalidConstraintTemplate, errors.Join(errs...))
+	}
+
+	return report, nil
+}
@@ -42,3 +42,13 @@ type Matcher interface {
 	// Note that this is the review object returned by HandleReview.
 	Match(review interface{}) (bool, error)
 }
+
+// ExplainingMatcher is an optional interface for Matchers which can explain
+// their decisions. Client uses it when a review requests match explanations.
+type ExplainingMatcher interface {
+	Matcher
+
+	// ExplainMatch returns what Match would, along with human-readable reasons
+	// for the decision.
+	ExplainMatch(review interface{}) (bool, []string, error)
+}
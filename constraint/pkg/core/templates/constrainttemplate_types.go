@@ -42,10 +42,23 @@ type CRD struct {
 
 // CRDSpec defines the spec for the CRD.
 type CRDSpec struct {
-	Names      Names       `json:"names,omitempty"`
+	Names Names `json:"names,omitempty"`
+	// Scope is the scope of Constraints of this kind. Either ScopeCluster or
+	// ScopeNamespaced. Defaults to ScopeCluster if unset.
+	Scope      string      `json:"scope,omitempty"`
 	Validation *Validation `json:"validation,omitempty"`
 }
 
+// Scopes of the Constraint kinds generated from ConstraintTemplates.
+const (
+	// ScopeCluster Constraints are cluster-scoped and may match objects in any
+	// namespace.
+	ScopeCluster = "Cluster"
+	// ScopeNamespaced Constraints belong to a namespace and only match objects
+	// in that namespace.
+	ScopeNamespaced = "Namespaced"
+)
+
 // Names defines the naming conventions for the constraint kind.
 type Names struct {
 	Kind       string   `json:"kind,omitempty"`
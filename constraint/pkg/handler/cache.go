@@ -30,6 +30,26 @@ type Cache interface {
 	Remove(relPath []string)
 }
 
+// CacheReader is an optional interface for Caches which can return the object
+// currently stored at a key. Client uses it to restore replaced objects when it
+// rolls back an atomic AddData, so Clients created with AtomicData require the
+// Cache of every target to implement it.
+type CacheReader interface {
+	// Get returns the object at key, or nil if Cache holds no object at key.
+	Get(relPath []string) (interface{}, error)
+}
+
+// CacheRestorer is an optional interface for Caches which can be rebuilt from
+// the data Client stores for referential Constraints. Client uses it when
+// restoring a snapshot, since snapshots hold the untyped JSON form of cached
+// objects rather than the objects themselves.
+type CacheRestorer interface {
+	// Restore adds the objects in inventory to Cache. inventory is the untyped
+	// JSON tree of every object stored for the Cache's target, keyed by the
+	// relPath the Handler returned for each object from ProcessData.
+	Restore(inventory interface{}) error
+}
+
 // NoCache is a Cache implementation that does not cache anything.
 type NoCache struct{}
 
@@ -46,4 +66,7 @@ func (n NoCache) Get(_ []string) (interface{}, error) {
 // Remove is a no-op.
 func (n NoCache) Remove(_ []string) {}
 
-var _ Cache = NoCache{}
+var (
+	_ Cache       = NoCache{}
+	_ CacheReader = NoCache{}
+)
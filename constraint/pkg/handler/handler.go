@@ -49,3 +49,60 @@ type TargetHandler interface {
 	// review.
 	ToMatcher(constraint *unstructured.Unstructured) (constraints.Matcher, error)
 }
+
+// ReviewNamespacer is an optional interface for TargetHandlers whose reviews
+// may be of namespaced objects. Templates may only opt into namespaced
+// Constraints if every one of their targets implements ReviewNamespacer, as
+// Client uses it to limit namespaced Constraints to objects in their own
+// namespace.
+type ReviewNamespacer interface {
+	// ReviewNamespace returns the namespace of the object under review, or ""
+	// if the object is not namespaced.
+	// Args:
+	//	review: the review returned by HandleReview
+	ReviewNamespace(review interface{}) (string, error)
+}
+
+// IndexableMatcher is an optional interface for TargetHandlers whose Matchers
+// only match reviews with particular index keys, such as the kinds or
+// namespaces of the objects under review. Client keeps an index of each
+// Constraint's keys so Review only runs the Matchers of Constraints which share
+// a key with the review. Results must not depend on whether the index is used:
+// a Matcher must neither match nor return an error for any review which shares
+// no key with its Constraint.
+type IndexableMatcher interface {
+	// ConstraintIndexKeys returns the index keys of the reviews constraint's
+	// Matcher may match. Returns nil if the Matcher may match reviews with any
+	// key.
+	ConstraintIndexKeys(constraint *unstructured.Unstructured) ([]string, error)
+
+	// ReviewIndexKeys returns the index keys of review, as returned by
+	// HandleReview. Returns false if review cannot be indexed, for example if
+	// Matchers would return an error for it, in which case Client runs every
+	// Matcher.
+	ReviewIndexKeys(review interface{}) ([]string, bool)
+}
+
+// ReviewKeyer is an optional interface for TargetHandlers whose reviews may be
+// answered from Client's decision cache. Reviews handled by any target which
+// does not implement ReviewKeyer are never cached.
+type ReviewKeyer interface {
+	// ReviewKey returns a canonical encoding of review, as returned by
+	// HandleReview. Reviews with equal keys must be treated identically by every
+	// Matcher and driver, so the key must cover everything they read from the
+	// review, including unexported fields. Returns false if review must not be
+	// cached.
+	ReviewKey(review interface{}) (string, bool)
+}
+
+// ExemptionMatcher is an optional interface for TargetHandlers which can exempt
+// objects from Constraints. Client requires it of every target an Exemption has
+// a selector for.
+type ExemptionMatcher interface {
+	// ToExemptionMatcher converts the object selector of an Exemption for this
+	// target to a Matcher for the reviews the Exemption applies to. Returns an
+	// error if selector is invalid.
+	// Args:
+	//	selector: the untyped JSON selector from the Exemption
+	ToExemptionMatcher(selector interface{}) (constraints.Matcher, error)
+}
@@ -2,6 +2,7 @@
 package handlertest
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -20,7 +21,11 @@ type Cache struct {
 	Namespaces sync.Map
 }
 
-var _ handler.Cache = &Cache{}
+var (
+	_ handler.Cache         = &Cache{}
+	_ handler.CacheReader   = &Cache{}
+	_ handler.CacheRestorer = &Cache{}
+)
 
 // Add inserts object into Cache if object is a Namespace.
 func (c *Cache) Add(key []string, object interface{}) error {
@@ -41,7 +46,61 @@ func (c *Cache) Add(key []string, object interface{}) error {
 	return nil
 }
 
+// Get returns the Namespace stored at key, or nil if there is none.
+func (c *Cache) Get(key []string) (interface{}, error) {
+	obj, found := c.Namespaces.Load(storage.Path(key).String())
+	if !found {
+		return nil, nil
+	}
+
+	return obj, nil
+}
+
 // Remove deletes an object from the cache by key.
 func (c *Cache) Remove(key []string) {
 	c.Namespaces.Delete(storage.Path(key).String())
 }
+
+// Restore adds the Namespaces stored under inventory.namespace to Cache.
+func (c *Cache) Restore(inventory interface{}) error {
+	root, ok := inventory.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	namespaces, ok := root["namespace"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, objs := range namespaces {
+		objMap, ok := objs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Namespaces are stored with an empty Name.
+		data, found := objMap[""]
+		if !found {
+			continue
+		}
+
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		obj := &Object{}
+		err = json.Unmarshal(bytes, obj)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidObject, err)
+		}
+
+		err = c.Add(obj.Key(), obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,11 @@
 package handlertest
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"github.com/open-policy-agent/opa/v1/storage"
+
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/constraints"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/handler"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
@@ -13,6 +16,14 @@ var _ handler.TargetHandler = &Handler{}
 
 var _ handler.Cacher = &Handler{}
 
+var _ handler.ReviewNamespacer = &Handler{}
+
+var _ handler.IndexableMatcher = &Handler{}
+
+var _ handler.ExemptionMatcher = &Handler{}
+
+var _ handler.ReviewKeyer = &Handler{}
+
 // TargetName is the default target name.
 const TargetName = "test.target"
 
@@ -82,6 +93,17 @@ func (h *Handler) HandleReview(obj interface{}) (bool, interface{}, error) {
 	}
 }
 
+// ReviewNamespace returns the namespace of the Object under review.
+func (h *Handler) ReviewNamespace(review interface{}) (string, error) {
+	r, ok := review.(*Review)
+	if !ok {
+		return "", fmt.Errorf("%w: got review type %T, want %T",
+			ErrInvalidType, review, &Review{})
+	}
+
+	return r.Object.Namespace, nil
+}
+
 // MatchSchema returns the JSON schema for constraint matching.
 func (h *Handler) MatchSchema() apiextensions.JSONSchemaProps {
 	return apiextensions.JSONSchemaProps{
@@ -124,6 +146,75 @@ func (h *Handler) ToMatcher(constraint *unstructured.Unstructured) (constraints.
 	return Matcher{Namespace: ns, Cache: h.Cache}, nil
 }
 
+// ToExemptionMatcher creates an ObjectSelector from an Exemption's selector,
+// which must be an object with optional "name" and "namespace" fields.
+func (h *Handler) ToExemptionMatcher(selector interface{}) (constraints.Matcher, error) {
+	obj, ok := selector.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: got selector type %T, want %T",
+			ErrInvalidType, selector, map[string]interface{}{})
+	}
+
+	name, _, err := unstructured.NestedString(obj, "name")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get name: %w", err)
+	}
+
+	namespace, _, err := unstructured.NestedString(obj, "namespace")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get namespace: %w", err)
+	}
+
+	return ObjectSelector{Name: name, Namespace: namespace}, nil
+}
+
+// ConstraintIndexKeys returns the namespace the Constraint matches, if any.
+func (h *Handler) ConstraintIndexKeys(constraint *unstructured.Unstructured) ([]string, error) {
+	ns, _, err := unstructured.NestedString(constraint.Object, "spec", "match", "matchNamespace")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get spec.matchNamespace: %w", err)
+	}
+
+	if ns == "" {
+		return nil, nil
+	}
+
+	return []string{ns}, nil
+}
+
+// ReviewIndexKeys returns the namespace of the Object under review. Reviews
+// of Objects whose Namespace is not cached cannot be indexed, as Matchers
+// with a namespace return an error for them.
+func (h *Handler) ReviewIndexKeys(review interface{}) ([]string, bool) {
+	r, ok := review.(*Review)
+	if !ok || h.Cache == nil {
+		return nil, false
+	}
+
+	key := Object{Namespace: r.Object.Namespace}.Key()
+	if _, exists := h.Cache.Namespaces.Load(storage.Path(key).String()); !exists {
+		return nil, false
+	}
+
+	return []string{r.Object.Namespace}, true
+}
+
+// ReviewKey returns the JSON encoding of the Review. Every field of Review is
+// exported, so the encoding covers all of it.
+func (h *Handler) ReviewKey(review interface{}) (string, bool) {
+	r, ok := review.(*Review)
+	if !ok {
+		return "", false
+	}
+
+	key, err := json.Marshal(r)
+	if err != nil {
+		return "", false
+	}
+
+	return string(key), true
+}
+
 // GetCache returns the Cache for the Handler.
 func (h *Handler) GetCache() handler.Cache {
 	if h.Cache == nil {
@@ -29,28 +29,65 @@ type Matcher struct {
 //
 // Matches all objects if the Matcher has no namespace specified.
 func (m Matcher) Match(review interface{}) (bool, error) {
+	matches, _, err := m.ExplainMatch(review)
+	return matches, err
+}
+
+// ExplainMatch is Match, but also describes why the object was or was not
+// matched.
+func (m Matcher) ExplainMatch(review interface{}) (bool, []string, error) {
 	if m.Namespace == "" {
-		return true, nil
+		return true, []string{"matches all namespaces"}, nil
 	}
 
 	if m.Cache == nil {
-		return false, fmt.Errorf("missing cache")
+		return false, nil, fmt.Errorf("missing cache")
 	}
 
 	reviewObj, ok := review.(*Review)
 	if !ok {
-		return false, fmt.Errorf("%w: got %T, want %T",
+		return false, nil, fmt.Errorf("%w: got %T, want %T",
 			ErrInvalidType, review, &Review{})
 	}
 
 	key := Object{Namespace: reviewObj.Object.Namespace}.Key()
 	_, exists := m.Cache.Namespaces.Load(storage.Path(key).String())
 	if !exists {
-		return false, fmt.Errorf("%w: namespace %q not in cache",
+		return false, nil, fmt.Errorf("%w: namespace %q not in cache",
 			ErrNotFound, m.Namespace)
 	}
 
-	return m.Namespace == reviewObj.Object.Namespace, nil
+	if m.Namespace != reviewObj.Object.Namespace {
+		return false, []string{fmt.Sprintf("object namespace %q is not %q",
+			reviewObj.Object.Namespace, m.Namespace)}, nil
+	}
+
+	return true, []string{fmt.Sprintf("object namespace is %q", m.Namespace)}, nil
+}
+
+var _ constraints.ExplainingMatcher = Matcher{}
+
+// ObjectSelector is a test matcher which matches Objects by Name and Namespace.
+// Empty fields match every Object.
+type ObjectSelector struct {
+	Name      string
+	Namespace string
+}
+
+// Match returns true if the object under review has the selected Name and
+// Namespace.
+func (s ObjectSelector) Match(review interface{}) (bool, error) {
+	reviewObj, ok := review.(*Review)
+	if !ok {
+		return false, fmt.Errorf("%w: got %T, want %T",
+			ErrInvalidType, review, &Review{})
+	}
+
+	if s.Name != "" && s.Name != reviewObj.Object.Name {
+		return false, nil
+	}
+
+	return s.Namespace == "" || s.Namespace == reviewObj.Object.Namespace, nil
 }
 
-var _ constraints.Matcher = Matcher{}
+var _ constraints.Matcher = ObjectSelector{}
@@ -11,12 +11,16 @@ const (
 	TemplateScope = "template"
 	// ConstraintScope means the state is associated with a constraint.
 	ConstraintScope = "constraint"
+	// ReviewScope means the stat is associated with an entire review.
+	ReviewScope = "review"
 
 	// UnknownDescription is used when the description is not available.
 	UnknownDescription = "unknown description"
 
 	// EngineSourceType identifies the source type as an engine.
 	EngineSourceType = "engine"
+	// ClientSourceType identifies the source type as the constraint client.
+	ClientSourceType = "client"
 )
 
 // RegoSource is the Source configuration for the Rego engine.
@@ -58,3 +62,29 @@ type StatsEntry struct {
 	Stats    []*Stat  `json:"stats"`
 	Labels   []*Label `json:"labels,omitempty"`
 }
+
+// DeepCopy returns a deep copy of the StatsEntry. Stat and Label values are
+// assumed to be immutable.
+func (e *StatsEntry) DeepCopy() *StatsEntry {
+	if e == nil {
+		return nil
+	}
+
+	out := &StatsEntry{Scope: e.Scope, StatsFor: e.StatsFor}
+	if e.Stats != nil {
+		out.Stats = make([]*Stat, len(e.Stats))
+		for i, stat := range e.Stats {
+			cpy := *stat
+			out.Stats[i] = &cpy
+		}
+	}
+	if e.Labels != nil {
+		out.Labels = make([]*Label, len(e.Labels))
+		for i, label := range e.Labels {
+			cpy := *label
+			out.Labels[i] = &cpy
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,255 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiconstraints "github.com/open-policy-agent/frameworks/constraint/pkg/apis/constraints"
+)
+
+// Effect is what should happen to a reviewed request.
+type Effect string
+
+const (
+	// EffectAllow means the request should be allowed without comment.
+	EffectAllow Effect = "allow"
+	// EffectWarn means the request should be allowed, but the requester warned.
+	EffectWarn Effect = "warn"
+	// EffectDeny means the request should be rejected.
+	EffectDeny Effect = "deny"
+)
+
+// strength orders Effects from least to most restrictive.
+func (e Effect) strength() int {
+	switch e {
+	case EffectAllow:
+		return 0
+	case EffectWarn:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ActionEffect assigns an Effect to an enforcement action.
+type ActionEffect struct {
+	Action string
+	Effect Effect
+}
+
+// DecisionPolicy configures how Decide combines the enforcement actions of
+// Results into a Decision.
+type DecisionPolicy struct {
+	// Precedence lists enforcement actions and their Effects from highest to
+	// lowest precedence. If a Result has several actions for an enforcement
+	// point, only the one with the highest precedence applies.
+	Precedence []ActionEffect
+
+	// UnknownEffect is the Effect of actions not in Precedence, which have lower
+	// precedence than every listed action. If empty, unknown actions deny
+	// requests so that misconfigured Constraints fail closed.
+	UnknownEffect Effect
+}
+
+// DefaultDecisionPolicy returns the policy where "deny" takes precedence over
+// "warn", which takes precedence over "dryrun".
+func DefaultDecisionPolicy() DecisionPolicy {
+	return DecisionPolicy{
+		Precedence: []ActionEffect{
+			{Action: string(apiconstraints.Deny), Effect: EffectDeny},
+			{Action: string(apiconstraints.Warn), Effect: EffectWarn},
+			{Action: string(apiconstraints.Dryrun), Effect: EffectAllow},
+		},
+	}
+}
+
+// resolve returns the action of actions with the highest precedence and its
+// Effect.
+func (p *DecisionPolicy) resolve(actions []string) (string, Effect) {
+	bestRank := -1
+	best := ""
+	for _, action := range actions {
+		rank := p.rank(action)
+		// Break ties between unknown actions by name so the result does not
+		// depend on the order of actions.
+		if bestRank == -1 || rank < bestRank || (rank == bestRank && action < best) {
+			bestRank = rank
+			best = action
+		}
+	}
+
+	return best, p.effect(best)
+}
+
+// rank returns the position of action in Precedence, or len(Precedence) if it
+// is unknown.
+func (p *DecisionPolicy) rank(action string) int {
+	for i, known := range p.Precedence {
+		if known.Action == action {
+			return i
+		}
+	}
+	return len(p.Precedence)
+}
+
+func (p *DecisionPolicy) effect(action string) Effect {
+	for _, known := range p.Precedence {
+		if known.Action == action {
+			return known.Effect
+		}
+	}
+	if p.UnknownEffect == "" {
+		return EffectDeny
+	}
+	return p.UnknownEffect
+}
+
+// Decision is the outcome of combining the Results of a review.
+type Decision struct {
+	TargetDecision
+
+	// ByTarget is the Decision for each target with Results.
+	ByTarget map[string]*TargetDecision
+}
+
+// TargetDecision is the outcome of combining some Results of a review.
+type TargetDecision struct {
+	// Effect is the most restrictive Effect of the Results.
+	Effect Effect
+
+	// Action is the enforcement action with the highest precedence among the
+	// Results, or empty if no Result applied.
+	Action string
+
+	// Denials are the messages of Results which deny the request, as
+	// "[<constraint name>] <message>". Ordered by target, then Constraint, and
+	// then message.
+	Denials []string
+
+	// Warnings are the messages of Results which warn the requester, in the
+	// same format and order as Denials.
+	Warnings []string
+}
+
+// Allowed returns true if the request should be admitted.
+func (d *TargetDecision) Allowed() bool {
+	return d.Effect != EffectDeny
+}
+
+// DenialMessage returns the reason the request was denied, or an empty string
+// if it was allowed.
+func (d *TargetDecision) DenialMessage() string {
+	return strings.Join(d.Denials, "\n")
+}
+
+func (d *TargetDecision) add(action string, effect Effect, policy *DecisionPolicy, msg string) {
+	if effect.strength() > d.Effect.strength() {
+		d.Effect = effect
+	}
+	if d.Action == "" || policy.rank(action) < policy.rank(d.Action) {
+		d.Action = action
+	}
+
+	switch effect {
+	case EffectDeny:
+		d.Denials = append(d.Denials, msg)
+	case EffectWarn:
+		d.Warnings = append(d.Warnings, msg)
+	case EffectAllow:
+	}
+}
+
+// Decide combines the Results of every target into the Decision to make for a
+// request at enforcementPoint.
+//
+// Results of Constraints with scoped enforcement actions use the actions the
+// Constraint declares for enforcementPoint, and are ignored if it declares
+// none. If enforcementPoint is empty, the ScopedEnforcementActions Client
+// determined for the review's enforcement point are used instead.
+func (r *Responses) Decide(enforcementPoint string, policy DecisionPolicy) Decision {
+	decision := Decision{
+		TargetDecision: TargetDecision{Effect: EffectAllow},
+		ByTarget:       make(map[string]*TargetDecision),
+	}
+	if r == nil {
+		return decision
+	}
+
+	targets := make([]string, 0, len(r.ByTarget))
+	for target := range r.ByTarget {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		resp := r.ByTarget[target]
+		if resp == nil {
+			continue
+		}
+
+		results := make([]*Result, len(resp.Results))
+		copy(results, resp.Results)
+		sort.SliceStable(results, func(i, j int) bool {
+			ci, cj := results[i].Constraint, results[j].Constraint
+			switch {
+			case ci == nil && cj != nil:
+				return true
+			case ci != nil && cj == nil:
+				return false
+			case ci != nil && constraintLess(ci, cj):
+				return true
+			case ci != nil && constraintLess(cj, ci):
+				return false
+			}
+			return results[i].Msg < results[j].Msg
+		})
+
+		targetDecision := &TargetDecision{Effect: EffectAllow}
+		for _, result := range results {
+			actions := result.actions(enforcementPoint)
+			if len(actions) == 0 {
+				continue
+			}
+
+			action, effect := policy.resolve(actions)
+			msg := result.Msg
+			if result.Constraint != nil {
+				msg = fmt.Sprintf("[%s] %s", result.Constraint.GetName(), result.Msg)
+			}
+
+			targetDecision.add(action, effect, &policy, msg)
+			decision.add(action, effect, &policy, msg)
+		}
+
+		if len(results) != 0 {
+			decision.ByTarget[target] = targetDecision
+		}
+	}
+
+	return decision
+}
+
+// actions returns the enforcement actions which apply to the Result at
+// enforcementPoint.
+func (r *Result) actions(enforcementPoint string) []string {
+	if !apiconstraints.IsEnforcementActionScoped(r.EnforcementAction) {
+		if r.EnforcementAction == "" {
+			return []string{string(apiconstraints.Deny)}
+		}
+		return []string{r.EnforcementAction}
+	}
+
+	if enforcementPoint == "" || r.Constraint == nil {
+		return r.ScopedEnforcementActions
+	}
+
+	actionsForEP, err := apiconstraints.GetEnforcementActionsForEP(r.Constraint, []string{enforcementPoint})
+	if err != nil {
+		// Client only accepts Constraints with valid scoped enforcement actions,
+		// so fall back to what it determined for the review.
+		return r.ScopedEnforcementActions
+	}
+
+	return actionsForEP[enforcementPoint]
+}
@@ -0,0 +1,183 @@
+//nolint:revive // Test file package name matches the package being tested.
+package types
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func makeConstraint(name string, scopedActions ...interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Foo",
+		"metadata": map[string]interface{}{"name": name},
+	}}
+	if len(scopedActions) != 0 {
+		u.Object["spec"] = map[string]interface{}{
+			"enforcementAction":        "scoped",
+			"scopedEnforcementActions": scopedActions,
+		}
+	}
+	return u
+}
+
+func scopedAction(action string, eps ...string) interface{} {
+	points := make([]interface{}, len(eps))
+	for i, ep := range eps {
+		points[i] = map[string]interface{}{"name": ep}
+	}
+	return map[string]interface{}{"action": action, "enforcementPoints": points}
+}
+
+func TestResponses_Decide(t *testing.T) {
+	webhookWarnAuditDeny := makeConstraint("scoped",
+		scopedAction("warn", "webhook"), scopedAction("deny", "audit"))
+
+	testCases := []struct {
+		name             string
+		responses        *Responses
+		enforcementPoint string
+		policy           DecisionPolicy
+		want             Decision
+	}{
+		{
+			name:      "nil responses",
+			responses: nil,
+			policy:    DefaultDecisionPolicy(),
+			want: Decision{
+				TargetDecision: TargetDecision{Effect: EffectAllow},
+				ByTarget:       map[string]*TargetDecision{},
+			},
+		},
+		{
+			name: "deny takes precedence over warn and dryrun",
+			responses: &Responses{ByTarget: map[string]*Response{
+				"b": {Results: []*Result{
+					{Msg: "dry", EnforcementAction: "dryrun", Constraint: makeConstraint("c")},
+				}},
+				"a": {Results: []*Result{
+					{Msg: "w2", EnforcementAction: "warn", Constraint: makeConstraint("b")},
+					{Msg: "d", EnforcementAction: "deny", Constraint: makeConstraint("a")},
+					{Msg: "w1", EnforcementAction: "warn", Constraint: makeConstraint("b")},
+				}},
+			}},
+			policy: DefaultDecisionPolicy(),
+			want: Decision{
+				TargetDecision: TargetDecision{
+					Effect:   EffectDeny,
+					Action:   "deny",
+					Denials:  []string{"[a] d"},
+					Warnings: []string{"[b] w1", "[b] w2"},
+				},
+				ByTarget: map[string]*TargetDecision{
+					"a": {
+						Effect:   EffectDeny,
+						Action:   "deny",
+						Denials:  []string{"[a] d"},
+						Warnings: []string{"[b] w1", "[b] w2"},
+					},
+					"b": {Effect: EffectAllow, Action: "dryrun"},
+				},
+			},
+		},
+		{
+			name: "unknown action fails closed",
+			responses: &Responses{ByTarget: map[string]*Response{
+				"a": {Results: []*Result{
+					{Msg: "m", EnforcementAction: "custom", Constraint: makeConstraint("a")},
+				}},
+			}},
+			policy: DefaultDecisionPolicy(),
+			want: Decision{
+				TargetDecision: TargetDecision{Effect: EffectDeny, Action: "custom", Denials: []string{"[a] m"}},
+				ByTarget: map[string]*TargetDecision{
+					"a": {Effect: EffectDeny, Action: "custom", Denials: []string{"[a] m"}},
+				},
+			},
+		},
+		{
+			name: "custom action",
+			responses: &Responses{ByTarget: map[string]*Response{
+				"a": {Results: []*Result{
+					{Msg: "m", EnforcementAction: "notify", Constraint: makeConstraint("a")},
+					{Msg: "n", EnforcementAction: "warn", Constraint: makeConstraint("b")},
+				}},
+			}},
+			policy: DecisionPolicy{
+				Precedence: []ActionEffect{
+					{Action: "notify", Effect: EffectWarn},
+					{Action: "warn", Effect: EffectWarn},
+				},
+				UnknownEffect: EffectAllow,
+			},
+			want: Decision{
+				TargetDecision: TargetDecision{Effect: EffectWarn, Action: "notify", Warnings: []string{"[a] m", "[b] n"}},
+				ByTarget: map[string]*TargetDecision{
+					"a": {Effect: EffectWarn, Action: "notify", Warnings: []string{"[a] m", "[b] n"}},
+				},
+			},
+		},
+		{
+			name: "scoped actions for enforcement point",
+			responses: &Responses{ByTarget: map[string]*Response{
+				"a": {Results: []*Result{
+					{Msg: "m", EnforcementAction: "scoped", ScopedEnforcementActions: []string{"deny"}, Constraint: webhookWarnAuditDeny},
+				}},
+			}},
+			enforcementPoint: "webhook",
+			policy:           DefaultDecisionPolicy(),
+			want: Decision{
+				TargetDecision: TargetDecision{Effect: EffectWarn, Action: "warn", Warnings: []string{"[scoped] m"}},
+				ByTarget: map[string]*TargetDecision{
+					"a": {Effect: EffectWarn, Action: "warn", Warnings: []string{"[scoped] m"}},
+				},
+			},
+		},
+		{
+			name: "scoped actions without enforcement point",
+			responses: &Responses{ByTarget: map[string]*Response{
+				"a": {Results: []*Result{
+					{Msg: "m", EnforcementAction: "scoped", ScopedEnforcementActions: []string{"warn", "deny"}, Constraint: webhookWarnAuditDeny},
+				}},
+			}},
+			policy: DefaultDecisionPolicy(),
+			want: Decision{
+				TargetDecision: TargetDecision{Effect: EffectDeny, Action: "deny", Denials: []string{"[scoped] m"}},
+				ByTarget: map[string]*TargetDecision{
+					"a": {Effect: EffectDeny, Action: "deny", Denials: []string{"[scoped] m"}},
+				},
+			},
+		},
+		{
+			name: "scoped actions for other enforcement point",
+			responses: &Responses{ByTarget: map[string]*Response{
+				"a": {Results: []*Result{
+					{Msg: "m", EnforcementAction: "scoped", Constraint: webhookWarnAuditDeny},
+				}},
+			}},
+			enforcementPoint: "shell",
+			policy:           DefaultDecisionPolicy(),
+			want: Decision{
+				TargetDecision: TargetDecision{Effect: EffectAllow},
+				ByTarget: map[string]*TargetDecision{
+					"a": {Effect: EffectAllow},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.responses.Decide(tc.enforcementPoint, tc.policy)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+
+			if got.Allowed() != (tc.want.Effect != EffectDeny) {
+				t.Errorf("got Allowed() = %v with Effect %q", got.Allowed(), got.Effect)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MatchDecision is what Client decided to do with a Constraint while
+// reviewing an object.
+type MatchDecision string
+
+const (
+	// MatchSkippedEnforcementPoint means the Constraint was skipped because it
+	// has no scoped enforcement actions for the review's enforcement points.
+	MatchSkippedEnforcementPoint MatchDecision = "SkippedEnforcementPoint"
+	// MatchSkippedOperation means the Constraint was skipped because its
+	// Template does not apply to the review's operation.
+	MatchSkippedOperation MatchDecision = "SkippedOperation"
+	// MatchNotMatched means the Constraint's Matcher did not match the review.
+	MatchNotMatched MatchDecision = "NotMatched"
+	// MatchError means the Constraint's Matcher returned an error, so the
+	// Constraint was autorejected.
+	MatchError MatchDecision = "Error"
+	// MatchExempted means the Constraint matched the review, but was not
+	// evaluated because an Exemption applied to the object.
+	MatchExempted MatchDecision = "Exempted"
+	// MatchInactive means the Constraint matched the review, but was not
+	// evaluated because the review happened outside the Constraint's
+	// spec.activeFrom and spec.activeUntil window.
+	MatchInactive MatchDecision = "Inactive"
+	// MatchQuarantined means the Constraint was skipped because it does not
+	// conform to the schema of its Template, which changed after the
+	// Constraint was added.
+	MatchQuarantined MatchDecision = "Quarantined"
+	// MatchEvaluated means the Constraint matched the review and was evaluated.
+	MatchEvaluated MatchDecision = "Evaluated"
+)
+
+// MatchExplanation explains why a Constraint did or did not apply to a review.
+type MatchExplanation struct {
+	// Constraint is the Constraint the explanation is for.
+	Constraint *unstructured.Unstructured `json:"constraint"`
+
+	Decision MatchDecision `json:"decision"`
+
+	// Reasons are human-readable explanations of the decision, if the target's
+	// Matchers provide them.
+	Reasons []string `json:"reasons,omitempty"`
+
+	// Error is the error returned by the Constraint's Matcher, if Decision is
+	// MatchError.
+	Error string `json:"error,omitempty"`
+}
+
+// DeepCopy returns a deep copy of the MatchExplanation.
+func (e *MatchExplanation) DeepCopy() *MatchExplanation {
+	if e == nil {
+		return nil
+	}
+
+	out := *e
+	out.Constraint = e.Constraint.DeepCopy()
+	if e.Reasons != nil {
+		out.Reasons = append([]string(nil), e.Reasons...)
+	}
+
+	return &out
+}
+
+// SortMatchExplanations sorts explanations by Constraint, in the same order as
+// Response.Sort sorts Results.
+func SortMatchExplanations(explanations []*MatchExplanation) {
+	sort.Slice(explanations, func(i, j int) bool {
+		return constraintLess(explanations[i].Constraint, explanations[j].Constraint)
+	})
+}
+
+// ExemptedConstraint records that an Exemption prevented a Constraint which
+// matched the review from being evaluated.
+type ExemptedConstraint struct {
+	Constraint *unstructured.Unstructured `json:"constraint"`
+
+	// Exemption is the name of the Exemption which applied. If several applied,
+	// this is the first by name.
+	Exemption string `json:"exemption"`
+}
+
+// DeepCopy returns a deep copy of the ExemptedConstraint.
+func (e *ExemptedConstraint) DeepCopy() *ExemptedConstraint {
+	if e == nil {
+		return nil
+	}
+
+	return &ExemptedConstraint{
+		Constraint: e.Constraint.DeepCopy(),
+		Exemption:  e.Exemption,
+	}
+}
+
+// SortExemptedConstraints sorts exempted by Constraint, in the same order as
+// Response.Sort sorts Results.
+func SortExemptedConstraints(exempted []*ExemptedConstraint) {
+	sort.Slice(exempted, func(i, j int) bool {
+		return constraintLess(exempted[i].Constraint, exempted[j].Constraint)
+	})
+}
+
+// InactiveConstraint records that a Constraint which matched the review was not
+// evaluated because it was not active at the time of the review.
+type InactiveConstraint struct {
+	Constraint *unstructured.Unstructured `json:"constraint"`
+
+	// Reason explains why the Constraint was inactive.
+	Reason string `json:"reason"`
+}
+
+// DeepCopy returns a deep copy of the InactiveConstraint.
+func (c *InactiveConstraint) DeepCopy() *InactiveConstraint {
+	if c == nil {
+		return nil
+	}
+
+	return &InactiveConstraint{
+		Constraint: c.Constraint.DeepCopy(),
+		Reason:     c.Reason,
+	}
+}
+
+// SortInactiveConstraints sorts inactive by Constraint, in the same order as
+// Response.Sort sorts Results.
+func SortInactiveConstraints(inactive []*InactiveConstraint) {
+	sort.Slice(inactive, func(i, j int) bool {
+		return constraintLess(inactive[i].Constraint, inactive[j].Constraint)
+	})
+}
@@ -0,0 +1,65 @@
+package types
+
+// TraceEvent is a single step a driver took evaluating a Constraint against a
+// review, in a form which can be serialized, filtered and rendered. Requested
+// with reviews.TraceEvents.
+type TraceEvent struct {
+	// Constraint is the Constraint being evaluated.
+	Constraint TracedConstraint `json:"constraint"`
+
+	// Op is the kind of step, such as "Enter", "Eval", "Exit" or "Fail".
+	Op string `json:"op"`
+
+	// QueryID identifies the query the step is part of, and ParentID the query
+	// which started that query.
+	QueryID  uint64 `json:"queryID"`
+	ParentID uint64 `json:"parentID"`
+
+	// Location is where the step is in the Template's code, if known.
+	Location *TraceLocation `json:"location,omitempty"`
+
+	// Node is the code being evaluated.
+	Node string `json:"node,omitempty"`
+
+	// Locals are the values of the variables in Node, by name.
+	Locals map[string]interface{} `json:"locals,omitempty"`
+
+	// Message is the message of steps which record notes.
+	Message string `json:"message,omitempty"`
+}
+
+// TracedConstraint identifies the Constraint a TraceEvent is for.
+type TracedConstraint struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// TraceLocation is a position in a Template's code.
+type TraceLocation struct {
+	// File identifies the module containing the position.
+	File string `json:"file,omitempty"`
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+}
+
+// DeepCopy returns a deep copy of the TraceEvent.
+func (e *TraceEvent) DeepCopy() *TraceEvent {
+	if e == nil {
+		return nil
+	}
+
+	out := *e
+	if e.Location != nil {
+		location := *e.Location
+		out.Location = &location
+	}
+	if e.Locals != nil {
+		out.Locals = make(map[string]interface{}, len(e.Locals))
+		for name, value := range e.Locals {
+			out.Locals[name] = deepCopyValue(value)
+		}
+	}
+
+	return &out
+}
@@ -36,12 +36,67 @@ type Result struct {
 	ScopedEnforcementActions []string `json:"scopedActions,omitempty"`
 }
 
+// DeepCopy returns a deep copy of the Result.
+func (r *Result) DeepCopy() *Result {
+	if r == nil {
+		return nil
+	}
+
+	out := *r
+	if r.Metadata != nil {
+		out.Metadata = deepCopyValue(r.Metadata).(map[string]interface{})
+	}
+	out.Constraint = r.Constraint.DeepCopy()
+	if r.ScopedEnforcementActions != nil {
+		out.ScopedEnforcementActions = append([]string(nil), r.ScopedEnforcementActions...)
+	}
+
+	return &out
+}
+
+// deepCopyValue copies the maps and slices of an untyped JSON-like value.
+// Other values are assumed to be immutable and are returned as-is.
+func deepCopyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, elem := range val {
+			out[k] = deepCopyValue(elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, elem := range val {
+			out[i] = deepCopyValue(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 // Response is a collection of Constraint violations for a particular Target.
 // Each Result represents a violation for a distinct Constraint.
 type Response struct {
 	Trace   *string
 	Target  string
 	Results []*Result
+
+	// TraceEvents are the steps taken evaluating each traced Constraint, if
+	// requested with reviews.TraceEvents. Trace remains the human-readable form.
+	TraceEvents []*TraceEvent
+
+	// MatchExplanations explain whether each of the target's Constraints applied
+	// to the review, if requested with reviews.ExplainMatching.
+	MatchExplanations []*MatchExplanation
+
+	// Exempted are the Constraints which matched the review but were not
+	// evaluated because of an Exemption.
+	Exempted []*ExemptedConstraint
+
+	// Inactive are the Constraints which matched the review but were not
+	// evaluated because they were not active at the time of the review.
+	Inactive []*InactiveConstraint
 }
 
 // AddResult adds a Result to the Response.
@@ -50,24 +105,77 @@ func (r *Response) AddResult(results *Result) {
 }
 
 // Sort sorts the Results in Response lexicographically first by the Constraint
-// Kind, and then by Constraint Name.
+// Kind, then by Constraint Namespace, and then by Constraint Name.
 func (r *Response) Sort() {
-	// Since Constraints are uniquely identified by Kind and Name, this guarantees
-	// a stable sort when each Result is for a different Constraint.
+	// Since Constraints are uniquely identified by Kind, Namespace, and Name,
+	// this guarantees a stable sort when each Result is for a different
+	// Constraint.
 	sort.Slice(r.Results, func(i, j int) bool {
-		resultI := r.Results[i]
-		resultJ := r.Results[j]
+		return constraintLess(r.Results[i].Constraint, r.Results[j].Constraint)
+	})
+}
+
+// constraintLess orders Constraints first by Kind, then by Namespace, and then
+// by Name.
+func constraintLess(constraintI, constraintJ *unstructured.Unstructured) bool {
+	kindI := constraintI.GetKind()
+	kindJ := constraintJ.GetKind()
+	if kindI != kindJ {
+		return kindI < kindJ
+	}
+
+	namespaceI := constraintI.GetNamespace()
+	namespaceJ := constraintJ.GetNamespace()
+	if namespaceI != namespaceJ {
+		return namespaceI < namespaceJ
+	}
+
+	return constraintI.GetName() < constraintJ.GetName()
+}
+
+// DeepCopy returns a deep copy of the Response.
+func (r *Response) DeepCopy() *Response {
+	if r == nil {
+		return nil
+	}
 
-		kindI := resultI.Constraint.GetKind()
-		kindJ := resultJ.Constraint.GetKind()
-		if kindI != kindJ {
-			return kindI < kindJ
+	out := &Response{Target: r.Target}
+	if r.Trace != nil {
+		trace := *r.Trace
+		out.Trace = &trace
+	}
+	if r.Results != nil {
+		out.Results = make([]*Result, len(r.Results))
+		for i, result := range r.Results {
+			out.Results[i] = result.DeepCopy()
+		}
+	}
+	if r.TraceEvents != nil {
+		out.TraceEvents = make([]*TraceEvent, len(r.TraceEvents))
+		for i, event := range r.TraceEvents {
+			out.TraceEvents[i] = event.DeepCopy()
 		}
+	}
+	if r.MatchExplanations != nil {
+		out.MatchExplanations = make([]*MatchExplanation, len(r.MatchExplanations))
+		for i, explanation := range r.MatchExplanations {
+			out.MatchExplanations[i] = explanation.DeepCopy()
+		}
+	}
+	if r.Exempted != nil {
+		out.Exempted = make([]*ExemptedConstraint, len(r.Exempted))
+		for i, exempted := range r.Exempted {
+			out.Exempted[i] = exempted.DeepCopy()
+		}
+	}
+	if r.Inactive != nil {
+		out.Inactive = make([]*InactiveConstraint, len(r.Inactive))
+		for i, inactive := range r.Inactive {
+			out.Inactive[i] = inactive.DeepCopy()
+		}
+	}
 
-		nameI := resultI.Constraint.GetName()
-		nameJ := resultJ.Constraint.GetName()
-		return nameI < nameJ
-	})
+	return out
 }
 
 // TraceDump returns a string representation of the Response for debugging.
@@ -108,6 +216,31 @@ type Responses struct {
 	ByTarget     map[string]*Response
 	Handled      map[string]bool
 	StatsEntries []*instrumentation.StatsEntry
+
+	// InvalidConstraints are the existing Constraints which AddTemplate found
+	// do not conform to the schema of the updated Template.
+	InvalidConstraints []*InvalidConstraint
+}
+
+// InvalidConstraint is a Constraint which does not conform to the schema of
+// its Template.
+type InvalidConstraint struct {
+	Constraint *unstructured.Unstructured `json:"constraint"`
+
+	// Error describes how the Constraint does not conform to the schema.
+	Error string `json:"error"`
+}
+
+// DeepCopy returns a deep copy of the InvalidConstraint.
+func (c *InvalidConstraint) DeepCopy() *InvalidConstraint {
+	if c == nil {
+		return nil
+	}
+
+	return &InvalidConstraint{
+		Constraint: c.Constraint.DeepCopy(),
+		Error:      c.Error,
+	}
 }
 
 // Results returns all Result objects from all Responses.
@@ -138,6 +271,38 @@ func (r *Responses) Results() []*Result {
 	return res
 }
 
+// DeepCopy returns a deep copy of the Responses.
+func (r *Responses) DeepCopy() *Responses {
+	if r == nil {
+		return nil
+	}
+
+	out := &Responses{
+		ByTarget: make(map[string]*Response, len(r.ByTarget)),
+		Handled:  make(map[string]bool, len(r.Handled)),
+	}
+	for target, resp := range r.ByTarget {
+		out.ByTarget[target] = resp.DeepCopy()
+	}
+	for target, handled := range r.Handled {
+		out.Handled[target] = handled
+	}
+	if r.StatsEntries != nil {
+		out.StatsEntries = make([]*instrumentation.StatsEntry, len(r.StatsEntries))
+		for i, entry := range r.StatsEntries {
+			out.StatsEntries[i] = entry.DeepCopy()
+		}
+	}
+	if r.InvalidConstraints != nil {
+		out.InvalidConstraints = make([]*InvalidConstraint, len(r.InvalidConstraints))
+		for i, invalid := range r.InvalidConstraints {
+			out.InvalidConstraints[i] = invalid.DeepCopy()
+		}
+	}
+
+	return out
+}
+
 // HandledCount returns the number of targets that were handled.
 func (r *Responses) HandledCount() int {
 	if r == nil {